@@ -1,9 +1,12 @@
 package app
 
 import (
+	"context"
 	"fmt"
-	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"text/template"
 
 	"github.com/burwei/repoexplainer/compfinder"
 	"github.com/burwei/repoexplainer/reportgen"
@@ -13,14 +16,309 @@ const (
 	FileName = "repoexplain.md"
 )
 
-func Run(rootPath string, out io.Writer) error {
-	// Use the base name of the root directory as the repo name
-	rootDirName := filepath.Base(rootPath)
-	rg := reportgen.NewReportGenerator(rootDirName, rootPath, compfinder.NewFinderFactory())
+// OutputFileName returns the name of the report file for the given output format.
+func OutputFileName(format string) string {
+	switch format {
+	case reportgen.FormatDOT:
+		return strings.TrimSuffix(FileName, filepath.Ext(FileName)) + ".dot"
+	case reportgen.FormatPlantUML:
+		return strings.TrimSuffix(FileName, filepath.Ext(FileName)) + ".puml"
+	case reportgen.FormatHTML:
+		return strings.TrimSuffix(FileName, filepath.Ext(FileName)) + ".html"
+	default:
+		return FileName
+	}
+}
+
+// ChunkFileName returns the name of the file for the given part (starting from 1) of a split report.
+func ChunkFileName(part int) string {
+	return ChunkPath(FileName, part)
+}
+
+// ChunkPath returns the path to the file for the given part (starting from 1) of a report split
+// from the path to the whole report, e.g. "docs/report-2.md" for "docs/report.md".
+func ChunkPath(filePath string, part int) string {
+	ext := filepath.Ext(filePath)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(filePath, ext), part, ext)
+}
+
+// RemoveChunks removes the files of the parts from the given one on, left by an earlier run
+// that split the report into more parts. It stops at the first part without a file.
+func RemoveChunks(filePath string, from int) error {
+	for part := from; ; part++ {
+		err := os.Remove(ChunkPath(filePath, part))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// WriteFile writes the data to a temporary file next to the file and renames it, so the file
+// is never left half written. The file gets the permissions 0644 if it doesn't exist yet.
+func WriteFile(filePath string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(filePath); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	// The temporary file is gone once it's been renamed
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.Write(data)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmpFile.Name(), perm)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), filePath)
+}
+
+// Option configures a Run.
+type Option func(*config)
+
+// config is what the options of a Run set.
+type config struct {
+	finderFactory reportgen.FinderFactory
+	options       reportgen.Options
+	progress      func(reportgen.Progress)
+	template      string
+}
+
+// finderList is a FinderFactory of a fixed set of finders.
+type finderList []reportgen.ComponentFinder
+
+func (fl finderList) GetFinders() []reportgen.ComponentFinder {
+	return fl
+}
+
+// WithOptions sets all the options of the report at once. The options given after it override its fields.
+func WithOptions(opts reportgen.Options) Option {
+	return func(c *config) {
+		c.options = opts
+	}
+}
+
+// WithFinderFactory sets the factory of the finders used instead of the default finders of the registry.
+// The files are only scanned in parallel if it's a reportgen.FinderCreator.
+func WithFinderFactory(factory reportgen.FinderFactory) Option {
+	return func(c *config) {
+		c.finderFactory = factory
+	}
+}
+
+// WithFinders sets the finders used instead of the default finders of the registry. The files are scanned one by one.
+func WithFinders(finders ...reportgen.ComponentFinder) Option {
+	return WithFinderFactory(finderList(finders))
+}
+
+// WithFormat sets the output format, reportgen.FormatMarkdown by default.
+func WithFormat(format string) Option {
+	return func(c *config) {
+		c.options.Format = format
+	}
+}
+
+// WithVisibility only keeps the components and members with the given visibility,
+// e.g. reportgen.VisibilityExported for the public API.
+func WithVisibility(visibility string) Option {
+	return func(c *config) {
+		c.options.Visibility = visibility
+	}
+}
+
+// WithExclude leaves the files and directories matching the glob patterns out of the report.
+func WithExclude(patterns ...string) Option {
+	return func(c *config) {
+		c.options.Exclude = append(c.options.Exclude, patterns...)
+	}
+}
+
+// WithMaxTokens leaves details out until the report fits into about n tokens.
+func WithMaxTokens(n int) Option {
+	return func(c *config) {
+		c.options.MaxTokens = n
+	}
+}
+
+// WithChunkTokens splits the report into parts of about n tokens each, returned in Result.Parts.
+func WithChunkTokens(n int) Option {
+	return func(c *config) {
+		c.options.ChunkTokens = n
+	}
+}
+
+// WithProgress sets the func called after every scanned file.
+// It's never called concurrently, but it may be called from another goroutine.
+func WithProgress(progress func(reportgen.Progress)) Option {
+	return func(c *config) {
+		c.progress = progress
+	}
+}
+
+// WithTemplate wraps the rendered report, or every part of it, in a text/template, e.g. to put it into a prompt.
+// The template is executed with a TemplateData. The template isn't counted in the token budgets.
+func WithTemplate(text string) Option {
+	return func(c *config) {
+		c.template = text
+	}
+}
+
+// TemplateData is what the template of WithTemplate is executed with.
+type TemplateData struct {
+	Report *reportgen.Report // Report is what the analysis has found
+	Output string            // Output is the rendered report, or the rendered part
+	Part   int               // Part is the number of the part starting from 1, 0 if the report isn't split
+	Parts  int               // Parts is the number of parts, 0 if the report isn't split
+}
+
+// Result is what a Run has found and rendered.
+type Result struct {
+	Report *reportgen.Report // Report is what the analysis has found, before rendering
+	Output string            // Output is the rendered report, empty if it's split into Parts
+	Parts  []string          // Parts are the parts of the report if WithChunkTokens is given
+}
+
+// Run analyzes the repo in rootPath and renders the report. The traversal and the scanning of the files
+// stop as soon as the context is canceled, in which case the error of the context is returned.
+func Run(ctx context.Context, rootPath string, opts ...Option) (result *Result, err error) {
+	c := newConfig(opts)
+	rg, err := newReportGenerator(c, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var tmpl *template.Template
+	if c.template != "" {
+		tmpl, err = template.New("report").Parse(c.template)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template: %s", err)
+		}
+	}
+
+	result = &Result{}
+	defer func() {
+		// Tell the cancellation apart from the other errors
+		if ctx.Err() != nil && err != nil {
+			err = ctx.Err()
+		}
+	}()
 
-	err := rg.GenerateReport(out)
+	result.Report, err = rg.Analyze(ctx)
 	if err != nil {
-		return fmt.Errorf("generating report: %s", err)
+		return nil, fmt.Errorf("analyzing repo: %s", err)
+	}
+
+	if c.options.ChunkTokens > 0 {
+		result.Parts, err = rg.RenderChunks(result.Report)
+		if err != nil {
+			return nil, fmt.Errorf("rendering report chunks: %s", err)
+		}
+	} else {
+		var builder strings.Builder
+		err = rg.Render(result.Report, &builder)
+		if err != nil {
+			return nil, fmt.Errorf("rendering report: %s", err)
+		}
+		result.Output = builder.String()
+	}
+
+	if tmpl != nil {
+		err = applyTemplate(tmpl, result)
+		if err != nil {
+			return nil, fmt.Errorf("executing template: %s", err)
+		}
+	}
+
+	return result, nil
+}
+
+// Analyze analyzes the repo in rootPath like Run, without rendering the report.
+func Analyze(ctx context.Context, rootPath string, opts ...Option) (report *reportgen.Report, err error) {
+	c := newConfig(opts)
+	rg, err := newReportGenerator(c, rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err = rg.Analyze(ctx)
+	if err != nil {
+		// Tell the cancellation apart from the other errors
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("analyzing repo: %s", err)
+	}
+
+	return report, nil
+}
+
+// newConfig applies the options to the defaults.
+func newConfig(opts []Option) *config {
+	c := &config{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.finderFactory == nil {
+		c.finderFactory = compfinder.NewFinderFactory()
+	}
+
+	return c
+}
+
+// newReportGenerator creates a ReportGenerator for the repo in rootPath.
+func newReportGenerator(c *config, rootPath string) (*reportgen.ReportGenerator, error) {
+	absPath, err := filepath.Abs(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path of %s: %s", rootPath, err)
+	}
+
+	// Use the base name of the root directory as the repo name
+	rootDirName := filepath.Base(absPath)
+	rg := reportgen.NewReportGenerator(rootDirName, absPath, c.finderFactory)
+	rg.SetOptions(c.options)
+	rg.SetProgress(c.progress)
+
+	return rg, nil
+}
+
+// applyTemplate wraps the output, or every part, in the template.
+func applyTemplate(tmpl *template.Template, result *Result) error {
+	if len(result.Parts) == 0 {
+		var builder strings.Builder
+		err := tmpl.Execute(&builder, TemplateData{Report: result.Report, Output: result.Output})
+		if err != nil {
+			return err
+		}
+		result.Output = builder.String()
+
+		return nil
+	}
+
+	for i, part := range result.Parts {
+		var builder strings.Builder
+		err := tmpl.Execute(&builder, TemplateData{Report: result.Report, Output: part, Part: i + 1, Parts: len(result.Parts)})
+		if err != nil {
+			return err
+		}
+		result.Parts[i] = builder.String()
 	}
 
 	return nil
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "server"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "vendor"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "server", "server.go"), []byte("package server\n\ntype Server struct {\n\tAddr string\n}\n\nfunc newServer() *Server {\n\treturn &Server{}\n}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "vendor", "lib.go"), []byte("package lib\n\ntype Lib struct {\n\tName string\n}\n"), 0644)
+
+	testCases := []struct {
+		name          string
+		opts          []Option
+		expComponents []string // expComponents are the names of the components found
+		expScanned    int      // expScanned is the number of files scanned
+		expParts      int
+	}{
+		{
+			name:          "Default finders",
+			expComponents: []string{"Lib", "Server", "newServer() *Server"},
+			expScanned:    2,
+		},
+		{
+			name:          "Excluded directories and exported components only",
+			opts:          []Option{WithExclude("vendor"), WithVisibility(reportgen.VisibilityExported)},
+			expComponents: []string{"Server"},
+			expScanned:    1,
+		},
+		{
+			name:          "Options override the fields of WithOptions",
+			opts:          []Option{WithOptions(reportgen.Options{Exclude: []string{"vendor"}, Stats: true}), WithChunkTokens(10)},
+			expComponents: []string{"Server", "newServer() *Server"},
+			expScanned:    1,
+			expParts:      2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mu := sync.Mutex{}
+			scanned := []string{}
+			opts := append(tc.opts, WithProgress(func(p reportgen.Progress) {
+				mu.Lock()
+				defer mu.Unlock()
+				scanned = append(scanned, p.Path)
+			}))
+
+			result, err := Run(context.Background(), tmpDir, opts...)
+
+			assert.NoError(t, err)
+			names := []string{}
+			for _, pkg := range result.Report.Packages {
+				for _, comp := range pkg.Components {
+					names = append(names, comp.Name)
+				}
+			}
+			assert.ElementsMatch(t, tc.expComponents, names)
+			assert.Len(t, scanned, tc.expScanned)
+			if tc.expParts > 0 {
+				assert.Empty(t, result.Output)
+				assert.GreaterOrEqual(t, len(result.Parts), tc.expParts)
+			} else {
+				assert.Contains(t, result.Output, "Server")
+				assert.Empty(t, result.Parts)
+			}
+			assert.Empty(t, result.Report.Warnings)
+		})
+	}
+}
+
+func TestRunReportJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"store/store.go":  "package store\n\ntype Store struct {\n}\n\nfunc (s *Store) Set(key string) {\n}\n\nfunc (s *Store) Get(key string) {\n}\n",
+		"store/delete.go": "package store\n\nfunc (s *Store) Delete(key string) {\n}\n\nfunc (s *Store) Clear() {\n}\n",
+		"api/users.py":    "class Client:\n    def get(self): ...\n",
+		"api/orders.py":   "class Client:\n    def get(self): ...\n",
+	}
+	for filePath, content := range files {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(filePath)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, filePath), []byte(content), 0644)
+	}
+
+	// The serialized report doesn't depend on the scheduling of the workers, nor on the cache
+	reports := map[string]bool{}
+	for _, opts := range []reportgen.Options{{Workers: 1}, {Workers: 8}, {Workers: 8}, {Workers: 8, Cache: true}, {Workers: 8, Cache: true}} {
+		result, err := Run(context.Background(), tmpDir, WithOptions(opts))
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(result.Report)
+		assert.NoError(t, err)
+		reports[string(data)] = true
+	}
+
+	assert.Len(t, reports, 1)
+}
+
+func TestRunCanceled(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := Run(ctx, tmpDir)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, result)
+}
+
+func TestRunWithTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\ntype Server struct {\n\tAddr string\n}\n"), 0644)
+
+	testCases := []struct {
+		name      string
+		opts      []Option
+		expOutput string // expOutput is the start of the output, or of every part
+		expErr    bool
+	}{
+		{
+			name:      "Output wrapped in the template",
+			opts:      []Option{WithTemplate("Explain {{.Report.Name}}:\n{{.Output}}")},
+			expOutput: "Explain " + filepath.Base(tmpDir) + ":\n# ",
+		},
+		{
+			name:      "Parts wrapped in the template",
+			opts:      []Option{WithTemplate("Part {{.Part}}/{{.Parts}}\n{{.Output}}"), WithChunkTokens(10)},
+			expOutput: "Part ",
+		},
+		{
+			name:   "Invalid template",
+			opts:   []Option{WithTemplate("{{.Output")},
+			expErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Run(context.Background(), tmpDir, tc.opts...)
+
+			if tc.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			outputs := result.Parts
+			if len(outputs) == 0 {
+				outputs = []string{result.Output}
+			}
+			for _, output := range outputs {
+				assert.True(t, strings.HasPrefix(output, tc.expOutput), output)
+			}
+		})
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	testCases := []struct {
+		name     string
+		filePath string
+		existing []byte      // existing is the content of the file before, none if nil
+		perm     os.FileMode // perm are the permissions of the existing file
+		expPerm  os.FileMode
+		expErr   bool
+	}{
+		{
+			name:     "New file",
+			filePath: filepath.Join(tmpDir, "new.md"),
+			expPerm:  0644,
+		},
+		{
+			name:     "Existing file keeps its permissions",
+			filePath: filepath.Join(tmpDir, "existing.md"),
+			existing: []byte("old report"),
+			perm:     0600,
+			expPerm:  0600,
+		},
+		{
+			name:     "Missing directory",
+			filePath: filepath.Join(tmpDir, "missing", "report.md"),
+			expErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.existing != nil {
+				os.WriteFile(tc.filePath, tc.existing, tc.perm)
+			}
+
+			err := WriteFile(tc.filePath, []byte("# report\n"))
+
+			if tc.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			content, _ := os.ReadFile(tc.filePath)
+			assert.Equal(t, "# report\n", string(content))
+			info, _ := os.Stat(tc.filePath)
+			assert.Equal(t, tc.expPerm, info.Mode().Perm())
+		})
+	}
+
+	// No temporary file is left behind
+	entries, _ := os.ReadDir(tmpDir)
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"new.md", "existing.md"}, names)
+}
+
+func TestChunkPath(t *testing.T) {
+	assert.Equal(t, "docs/report-2.md", ChunkPath("docs/report.md", 2))
+	assert.Equal(t, "report-1", ChunkPath("report", 1))
+	assert.Equal(t, "repoexplain-3.md", ChunkFileName(3))
+}
+
+func TestRemoveChunks(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "report.md")
+	for part := 1; part <= 4; part++ {
+		os.WriteFile(ChunkPath(filePath, part), []byte("# part\n"), 0644)
+	}
+
+	err := RemoveChunks(filePath, 3)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, ChunkPath(filePath, 2))
+	assert.NoFileExists(t, ChunkPath(filePath, 3))
+	assert.NoFileExists(t, ChunkPath(filePath, 4))
+}
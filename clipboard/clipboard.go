@@ -0,0 +1,150 @@
+// Package clipboard copies the report to the clipboard with the first backend that works:
+// the native clipboard tools, then the OSC 52 escape sequence of the terminal for headless
+// and SSH sessions, then a file whose path is printed instead.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	nativeclipboard "github.com/atotto/clipboard"
+)
+
+// DefaultOSC52MaxBytes is the size of the longest OSC 52 sequence sent to the terminal by default.
+// Many terminals ignore longer ones, e.g. hterm and the Chrome OS terminal.
+const DefaultOSC52MaxBytes = 100000
+
+// Backend copies text to the clipboard.
+type Backend interface {
+	// Name describes where the text is copied to, e.g. "clipboard".
+	Name() string
+
+	// Copy copies the text, or returns an error if the backend can't be used.
+	Copy(text string) error
+}
+
+// Native copies the text with the native clipboard of the OS: pbcopy on macOS, the Windows clipboard,
+// and xclip, xsel, wl-copy or termux-clipboard-set on the other systems.
+type Native struct{}
+
+func (n Native) Name() string {
+	return "clipboard"
+}
+
+func (n Native) Copy(text string) error {
+	if nativeclipboard.Unsupported {
+		return fmt.Errorf("no clipboard tool found, install xclip, xsel or wl-copy")
+	}
+
+	return nativeclipboard.WriteAll(text)
+}
+
+// OSC52 copies the text by writing the OSC 52 escape sequence to the terminal, which copies it to the clipboard
+// of the machine the terminal runs on, even over SSH. The terminal can't tell whether it has done it,
+// so it's only known that the sequence has been written.
+type OSC52 struct {
+	// Open opens the terminal. It opens /dev/tty if it's nil.
+	Open func() (io.WriteCloser, error)
+
+	// MaxBytes is the size of the longest sequence sent to the terminal, 0 means DefaultOSC52MaxBytes.
+	MaxBytes int
+
+	// Tmux wraps the sequence in the passthrough sequence of tmux, which passes it on to the terminal.
+	// It needs the allow-passthrough option of tmux 3.3 and later.
+	Tmux bool
+}
+
+// NewOSC52 creates an OSC52 for the terminal of the process, inside tmux if $TMUX is set.
+func NewOSC52() *OSC52 {
+	return &OSC52{Tmux: os.Getenv("TMUX") != ""}
+}
+
+func (o *OSC52) Name() string {
+	return "terminal clipboard (OSC 52)"
+}
+
+func (o *OSC52) Copy(text string) error {
+	sequence := o.Sequence(text)
+
+	maxBytes := o.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultOSC52MaxBytes
+	}
+	if len(sequence) > maxBytes {
+		return fmt.Errorf("the OSC 52 sequence of %d bytes is longer than the limit of %d bytes", len(sequence), maxBytes)
+	}
+
+	open := o.Open
+	if open == nil {
+		open = openTTY
+	}
+
+	terminal, err := open()
+	if err != nil {
+		return fmt.Errorf("opening terminal: %s", err)
+	}
+
+	_, err = io.WriteString(terminal, sequence)
+	if closeErr := terminal.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Sequence returns the escape sequence copying the text, wrapped for tmux if needed.
+func (o *OSC52) Sequence(text string) string {
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if !o.Tmux {
+		return sequence
+	}
+
+	// The escape characters of the passed on sequence are doubled
+	return "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// openTTY opens the controlling terminal, which is there even if stdout is redirected.
+func openTTY() (io.WriteCloser, error) {
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
+
+// File writes the text to a file, so it can be copied from there when there's no clipboard.
+type File struct {
+	Path  string                                   // Path is the path to the file
+	Write func(filePath string, data []byte) error // Write writes the file, os.WriteFile with 0644 if it's nil
+}
+
+func (f File) Name() string {
+	return f.Path
+}
+
+func (f File) Copy(text string) error {
+	if f.Write == nil {
+		return os.WriteFile(f.Path, []byte(text), 0644)
+	}
+
+	return f.Write(f.Path, []byte(text))
+}
+
+// Chain tries the backends one after the other.
+type Chain []Backend
+
+// Copy copies the text with the first backend that works, and returns it.
+// It returns the errors of every backend if none works.
+func (c Chain) Copy(text string) (Backend, error) {
+	errs := []error{}
+	for _, backend := range c {
+		err := backend.Copy(text)
+		if err == nil {
+			return backend, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %s", backend.Name(), err))
+	}
+
+	return nil, fmt.Errorf("copying to clipboard: %s", errors.Join(errs...))
+}
@@ -0,0 +1,173 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTerminal records what is written to it.
+type fakeTerminal struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (ft *fakeTerminal) Close() error {
+	ft.closed = true
+	return nil
+}
+
+// fakeBackend copies the text to its field, or fails with its error.
+type fakeBackend struct {
+	name   string
+	err    error
+	copied string
+}
+
+func (fb *fakeBackend) Name() string {
+	return fb.name
+}
+
+func (fb *fakeBackend) Copy(text string) error {
+	if fb.err != nil {
+		return fb.err
+	}
+
+	fb.copied = text
+	return nil
+}
+
+func TestOSC52(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("report"))
+
+	testCases := []struct {
+		name     string
+		text     string
+		maxBytes int
+		tmux     bool
+		expWrite string // expWrite is what the terminal receives
+		expErr   bool
+	}{
+		{
+			name:     "Plain sequence",
+			text:     "report",
+			expWrite: "\x1b]52;c;" + encoded + "\a",
+		},
+		{
+			name:     "tmux passthrough",
+			text:     "report",
+			tmux:     true,
+			expWrite: "\x1bPtmux;\x1b\x1b]52;c;" + encoded + "\a\x1b\\",
+		},
+		{
+			name:     "Longer than the limit",
+			text:     "report",
+			maxBytes: 10,
+			expErr:   true,
+		},
+		{
+			name:   "Longer than the default limit",
+			text:   strings.Repeat("a", DefaultOSC52MaxBytes),
+			expErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			terminal := &fakeTerminal{}
+			osc52 := &OSC52{
+				Open:     func() (io.WriteCloser, error) { return terminal, nil },
+				MaxBytes: tc.maxBytes,
+				Tmux:     tc.tmux,
+			}
+
+			err := osc52.Copy(tc.text)
+
+			if tc.expErr {
+				assert.Error(t, err)
+				assert.Empty(t, terminal.String())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expWrite, terminal.String())
+			assert.True(t, terminal.closed)
+		})
+	}
+
+	t.Run("No terminal", func(t *testing.T) {
+		osc52 := &OSC52{Open: func() (io.WriteCloser, error) { return nil, errors.New("no tty") }}
+		assert.Error(t, osc52.Copy("report"))
+	})
+}
+
+func TestFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "report.md")
+
+	err := File{Path: filePath}.Copy("report")
+
+	assert.NoError(t, err)
+	data, _ := os.ReadFile(filePath)
+	assert.Equal(t, "report", string(data))
+}
+
+func TestChain(t *testing.T) {
+	testCases := []struct {
+		name       string
+		errs       []error // errs are the errors of the backends, nil if it works
+		expBackend int     // expBackend is the index of the backend used, -1 if none
+	}{
+		{
+			name:       "First backend works",
+			errs:       []error{nil, nil},
+			expBackend: 0,
+		},
+		{
+			name:       "Falls back to the next backend",
+			errs:       []error{errors.New("no xclip"), errors.New("no tty"), nil},
+			expBackend: 2,
+		},
+		{
+			name:       "No backend works",
+			errs:       []error{errors.New("no xclip"), errors.New("no tty")},
+			expBackend: -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backends := []*fakeBackend{}
+			chain := Chain{}
+			for i, err := range tc.errs {
+				backend := &fakeBackend{name: string(rune('a' + i)), err: err}
+				backends = append(backends, backend)
+				chain = append(chain, backend)
+			}
+
+			used, err := chain.Copy("report")
+
+			if tc.expBackend < 0 {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "a: no xclip")
+				assert.Contains(t, err.Error(), "b: no tty")
+				assert.Nil(t, used)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Same(t, backends[tc.expBackend], used)
+			for i, backend := range backends {
+				if i == tc.expBackend {
+					assert.Equal(t, "report", backend.copied)
+				} else {
+					assert.Empty(t, backend.copied)
+				}
+			}
+		})
+	}
+}
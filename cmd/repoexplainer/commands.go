@@ -0,0 +1,398 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/burwei/repoexplainer/app"
+	"github.com/burwei/repoexplainer/compfinder/registry"
+	"github.com/burwei/repoexplainer/config"
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// newCommandFlags creates the flag set of a command with the flags of the analysis.
+func newCommandFlags(name string) (*flag.FlagSet, *cliFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return fs, defineFlags(fs)
+}
+
+// parseCommand parses the arguments of a command and prints its help if asked for.
+// It returns false with the exit code if the command must not run.
+func parseCommand(name string, fs *flag.FlagSet, f *cliFlags, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		return exitError, false
+	}
+
+	if *f.help {
+		cmd, _ := findCommand(name)
+		fmt.Printf("Usage: %s\n%s\n\nFlags:\n", cmd.synopsis(), cmd.description)
+		fs.SetOutput(os.Stdout)
+		fs.PrintDefaults()
+		return exitOK, false
+	}
+
+	return exitOK, true
+}
+
+// usageError prints the usage of a command with the error and returns the exit code of the errors.
+func usageError(name string, message string) int {
+	cmd, _ := findCommand(name)
+	log.Printf("%s, expected: %s", message, cmd.synopsis())
+	return exitError
+}
+
+// analyze analyzes the repo in absPath with the configuration, and stops on Ctrl-C.
+func analyze(absPath string, cfg *config.Config) (*reportgen.Report, error) {
+	opts, err := analysisOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := app.Analyze(ctx, absPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	printWarnings(report.Warnings)
+
+	return report, nil
+}
+
+// analyzeDir loads the configuration of the repo in dirPath and analyzes it.
+func analyzeDir(f *cliFlags, fs *flag.FlagSet, dirPath string) (*reportgen.Report, *config.Config, error) {
+	absPath, cfg, err := prepare(f, fs, dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report, err := analyze(absPath, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return report, cfg, nil
+}
+
+// runTree prints the directory structure.
+func runTree(args []string) int {
+	fs, f := newCommandFlags("tree")
+	depth := fs.Int("depth", 0, "Summarize the directories deeper than N levels by their number of files, 0 means no limit")
+	if code, ok := parseCommand("tree", fs, f, args); !ok {
+		return code
+	}
+
+	report, cfg, err := analyzeDir(f, fs, fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+
+	rg := reportgen.NewReportGenerator(report.Name, report.RootPath, nil)
+	rg.SetOptions(cfg.Options())
+	err = rg.RenderTree(report, *depth, os.Stdout)
+	if err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+}
+
+// runStats prints the statistics of the packages.
+func runStats(args []string) int {
+	fs, f := newCommandFlags("stats")
+	if code, ok := parseCommand("stats", fs, f, args); !ok {
+		return code
+	}
+
+	report, cfg, err := analyzeDir(f, fs, fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+
+	rg := reportgen.NewReportGenerator(report.Name, report.RootPath, nil)
+	rg.SetOptions(cfg.Options())
+	err = rg.RenderStats(report, os.Stdout)
+	if err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+}
+
+// runSymbols lists the symbols matching a pattern like grep, one per line with its file and line.
+func runSymbols(args []string) int {
+	fs, f := newCommandFlags("symbols")
+	ignoreCase := fs.Bool("i", false, "Match the pattern case-insensitively")
+	symbolType := fs.String("type", "", "Only list the symbols of a type: struct, interface, func or method")
+	if code, ok := parseCommand("symbols", fs, f, args); !ok {
+		return code
+	}
+
+	if fs.NArg() == 0 {
+		return usageError("symbols", "Missing pattern")
+	}
+
+	pattern := fs.Arg(0)
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fail(fmt.Errorf("invalid pattern %q: %s", fs.Arg(0), err))
+	}
+
+	report, _, err := analyzeDir(f, fs, fs.Arg(1))
+	if err != nil {
+		return fail(err)
+	}
+
+	matches := 0
+	for _, symbol := range report.Symbols() {
+		if (*symbolType != "" && symbol.Type != *symbolType) || !re.MatchString(symbol.Name) {
+			continue
+		}
+
+		fmt.Printf("%s: %s\n", symbolLocation(symbol), describeSymbol(symbol))
+		matches++
+	}
+
+	if matches == 0 {
+		return exitNoMatch
+	}
+
+	return exitOK
+}
+
+// runDiff lists the symbols added, removed or changed since a base directory or git revision.
+func runDiff(args []string) int {
+	fs, f := newCommandFlags("diff")
+	if code, ok := parseCommand("diff", fs, f, args); !ok {
+		return code
+	}
+
+	if fs.NArg() == 0 {
+		return usageError("diff", "Missing base")
+	}
+
+	// The base is analyzed with the configuration of the repo, not the one it had
+	absPath, cfg, err := prepare(f, fs, fs.Arg(1))
+	if err != nil {
+		return fail(err)
+	}
+
+	basePath, cleanup, err := resolveBase(fs.Arg(0), absPath)
+	if err != nil {
+		return fail(err)
+	}
+	defer cleanup()
+
+	baseReport, err := analyze(basePath, cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	report, err := analyze(absPath, cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	changes := reportgen.DiffSymbols(baseReport.Symbols(), report.Symbols())
+	for _, change := range changes {
+		switch change.Kind {
+		case reportgen.ChangeAdded:
+			fmt.Printf("+ %s (%s)\n", describeSymbol(change.New), symbolLocation(change.New))
+		case reportgen.ChangeRemoved:
+			fmt.Printf("- %s (%s)\n", describeSymbol(change.Old), symbolLocation(change.Old))
+		case reportgen.ChangeChanged:
+			fmt.Printf("~ %s %s: %s -> %s (%s)\n", change.New.Type, change.New.Name,
+				symbolSignature(change.Old), symbolSignature(change.New), symbolLocation(change.New))
+		}
+	}
+
+	if len(changes) > 0 {
+		return exitDifferences
+	}
+
+	return exitOK
+}
+
+// runFinders lists the registered finders with their options.
+func runFinders(args []string) int {
+	if len(args) > 0 {
+		return usageError("finders", "Unexpected arguments")
+	}
+
+	for _, finder := range registry.All() {
+		enabled := "disabled by default"
+		if finder.Default {
+			enabled = "enabled by default"
+		}
+
+		fmt.Printf("%s (%s, %s): %s\n", finder.Name, finder.Language, enabled, finder.Description)
+		for _, option := range finder.Options {
+			fmt.Printf("  %s.%s (default %s): %s\n", finder.Name, option.Name, option.Default, option.Description)
+		}
+	}
+
+	return exitOK
+}
+
+// runConfig prints the configuration of a repo after the flags, like "config show --stats ./repo".
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "show" {
+		return usageError("config", "Unknown config command")
+	}
+
+	fs, f := newCommandFlags("config")
+	if code, ok := parseCommand("config", fs, f, args[1:]); !ok {
+		return code
+	}
+
+	_, cfg, err := prepare(f, fs, fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+
+	err = cfg.Show(os.Stdout)
+	if err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+}
+
+// symbolLocation returns the file and line of a symbol, like "reportgen/report.go:42".
+func symbolLocation(symbol reportgen.Symbol) string {
+	if symbol.Line == 0 {
+		return symbol.File
+	}
+
+	return fmt.Sprintf("%s:%d", symbol.File, symbol.Line)
+}
+
+// describeSymbol describes a symbol by its type and name, and its signature if it's a func or a method.
+func describeSymbol(symbol reportgen.Symbol) string {
+	description := symbol.Type + " " + symbol.Name
+	if isCallable(symbol) {
+		description += symbol.Signature
+	}
+
+	return description
+}
+
+// symbolSignature returns the signature of a func or method, or the fields of a type between braces.
+func symbolSignature(symbol reportgen.Symbol) string {
+	if isCallable(symbol) {
+		return strings.TrimSpace(symbol.Signature)
+	}
+
+	return "{" + symbol.Signature + "}"
+}
+
+func isCallable(symbol reportgen.Symbol) bool {
+	return symbol.Type == reportgen.ComponentTypeFunc || symbol.Type == reportgen.SymbolTypeMethod
+}
+
+// resolveBase returns the directory of the base of a diff: the base itself if it's a directory,
+// otherwise a temporary copy of the repo at the base as a git revision. cleanup removes the copy.
+func resolveBase(base string, absPath string) (string, func(), error) {
+	if info, err := os.Stat(base); err == nil && info.IsDir() {
+		basePath, err := rootPath(base)
+		return basePath, func() {}, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "repoexplainer-diff-")
+	if err != nil {
+		return "", nil, fmt.Errorf("creating temporary directory: %s", err)
+	}
+	cleanup := func() {
+		os.RemoveAll(tmpDir)
+	}
+
+	// Keep the name of the root directory, it's the name of the repo in the report
+	basePath := filepath.Join(tmpDir, filepath.Base(absPath))
+	err = checkoutRevision(absPath, base, basePath)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("%s is neither a directory nor a git revision: %s", base, err)
+	}
+
+	return basePath, cleanup, nil
+}
+
+// checkoutRevision extracts the files of the directory at a git revision into the target directory.
+func checkoutRevision(dirPath, revision, target string) error {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "archive", "--format=tar", revision)
+	cmd.Dir = dirPath
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(stderr.String()))
+	}
+
+	err := os.MkdirAll(target, 0755)
+	if err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(&stdout)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		filePath := filepath.Join(target, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(filePath, target+string(os.PathSeparator)) {
+			continue // Ignore the paths out of the target, and the target itself
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(filePath, 0755)
+		case tar.TypeReg:
+			err = writeFile(filePath, reader, header.FileInfo().Mode().Perm())
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, filePath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeFile writes the content of a file, creating its directory if needed.
+func writeFile(filePath string, content io.Reader, perm os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
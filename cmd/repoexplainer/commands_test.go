@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureStdout returns what the function writes to the standard output, and its exit code.
+func captureStdout(t *testing.T, run func() int) (string, int) {
+	reader, writer, err := os.Pipe()
+	assert.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		output <- string(data)
+	}()
+
+	code := run()
+	writer.Close()
+
+	return <-output, code
+}
+
+// newTestRepo creates a repo whose packages have types and funcs of the same names, and a type with several methods.
+func newTestRepo(t *testing.T) string {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	repoDir := filepath.Join(t.TempDir(), "repo")
+	files := map[string]string{
+		"golang/finder.go": "package golang\n\ntype ComponentFinder struct {\n}\n\nfunc (cf *ComponentFinder) SetFile(filePath string) {}\n",
+		"python/finder.go": "package python\n\ntype ComponentFinder struct {\n}\n\nfunc (cf *ComponentFinder) SetFile(filePath string, module string) {}\n",
+		"cmd/a/main.go":    "package main\n\nfunc main() {}\n",
+		"cmd/b/main.go":    "package main\n\nfunc main() {\n}\n",
+		"clip/clip.go":     "package clip\n\ntype Chain []string\n\nfunc (c Chain) Copy(text string) error {\n}\n\nfunc Copy(text string) {\n}\n",
+		"store/store.go":   "package store\n\ntype Store struct {\n}\n\nfunc (s *Store) Set(key string) {\n}\n\nfunc (s *Store) Get(key string) {\n}\n\nfunc (s *Store) Delete(key string) {\n}\n\nfunc (s *Store) Clear() {\n}\n",
+	}
+	for filePath, content := range files {
+		os.MkdirAll(filepath.Join(repoDir, filepath.Dir(filePath)), 0755)
+		os.WriteFile(filepath.Join(repoDir, filePath), []byte(content), 0644)
+	}
+
+	return repoDir
+}
+
+func TestRunDiffSameTree(t *testing.T) {
+	repoDir := newTestRepo(t)
+
+	// The symbols of the same names must be compared with their own, whatever the order of the maps
+	for i := 0; i < 10; i++ {
+		output, code := captureStdout(t, func() int {
+			return runDiff([]string{repoDir, repoDir})
+		})
+
+		assert.Empty(t, output)
+		assert.Equal(t, exitOK, code)
+	}
+}
+
+func TestRunDiffCleanWorkingTree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := newTestRepo(t)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		assert.NoError(t, cmd.Run())
+	}
+
+	output, code := captureStdout(t, func() int {
+		return runDiff([]string{"HEAD", repoDir})
+	})
+
+	assert.Empty(t, output)
+	assert.Equal(t, exitOK, code)
+}
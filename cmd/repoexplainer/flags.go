@@ -0,0 +1,241 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/burwei/repoexplainer/config"
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// cliFlags are the flags of the analysis. They override the config files.
+type cliFlags struct {
+	help               *bool
+	file               *bool
+	output             *string
+	force              *bool
+	maxTokens          *int
+	chunkTokens        *int
+	visibility         *string
+	mermaid            *bool
+	format             *string
+	template           *string
+	includeBodies      stringList
+	includeBodiesUnder *int
+	includeFiles       stringList
+	plugins            stringList
+	enableFinders      stringList
+	disableFinders     stringList
+	finderOptions      stringList
+	exclude            stringList
+	tests              *bool
+	markers            *bool
+	coverage           *string
+	history            *bool
+	historyDays        *int
+	owners             *bool
+	cache              *bool
+	workers            *int
+	stats              *bool
+}
+
+// defineFlags defines the flags of the analysis in the flag set.
+func defineFlags(fs *flag.FlagSet) *cliFlags {
+	f := &cliFlags{}
+
+	// Define a help flag
+	f.help = fs.Bool("h", false, "Display help information")
+
+	// Define a file output flag
+	f.file = fs.Bool("f", false, "Write output to a file")
+
+	// Define the output path flags
+	f.output = fs.String("o", "", "Write output to a file at the path, or to stdout if it's -")
+	f.force = fs.Bool("force", false, "Let -o write a file in the analyzed directory")
+
+	// Define a token budget flag
+	f.maxTokens = fs.Int("max-tokens", 0, "Leave out details until the report fits into about N tokens")
+
+	// Define a chunk size flag
+	f.chunkTokens = fs.Int("chunk-tokens", 0, "Split the report into parts of about N tokens each")
+
+	// Define a visibility flag
+	f.visibility = fs.String("visibility", reportgen.VisibilityAll, "Components to show: all, exported or unexported")
+
+	// Define a class diagram flag
+	f.mermaid = fs.Bool("mermaid", false, "Add a Mermaid class diagram to the report")
+
+	// Define an output format flag
+	f.format = fs.String("format", reportgen.FormatMarkdown, "Output format: markdown, dot, plantuml or html")
+
+	// Define a template flag
+	f.template = fs.String("template", "", "Wrap the report in a text/template file")
+
+	// Define the source excerpt flags, the symbol and file flags can be repeated
+	fs.Var(&f.includeBodies, "include-body", "Show the source code of a symbol like pkg.Type.Method, can be repeated")
+	f.includeBodiesUnder = fs.Int("include-bodies-under", 0, "Show the source code of every func shorter than N lines")
+	fs.Var(&f.includeFiles, "include-file", "Show the whole files matching a glob pattern, can be repeated")
+
+	// Define a plugin flag, it can be repeated
+	fs.Var(&f.plugins, "plugin", "Run an external finder on the files matching the patterns, like '*.dsl=dsl-finder', can be repeated")
+
+	// Define the finder flags, they can be repeated
+	fs.Var(&f.enableFinders, "enable-finder", "Enable a finder that isn't enabled by default, can be repeated")
+	fs.Var(&f.disableFinders, "disable-finder", "Disable a finder, can be repeated")
+	fs.Var(&f.finderOptions, "finder-option", "Set an option of a finder, like 'go.tests=false', can be repeated")
+
+	// Define an exclude flag, it can be repeated
+	fs.Var(&f.exclude, "exclude", "Leave out the files and directories matching a glob pattern, can be repeated")
+
+	// Define a tests section flag
+	f.tests = fs.Bool("tests", false, "Add a tests section and show which components are tested by which tests")
+
+	// Define a markers flag
+	f.markers = fs.Bool("markers", false, "Add a markers section with the TODO, FIXME, HACK and Deprecated comments")
+
+	// Define a coverage profile flag
+	f.coverage = fs.String("coverage", "", "Annotate the funcs and methods with the coverage of a go test -coverprofile file")
+
+	// Define the git history flags
+	f.history = fs.Bool("history", false, "Annotate the files and packages with their git history")
+	f.historyDays = fs.Int("history-days", reportgen.DefaultHistoryDays, "Count the commits of the last N days in the git history")
+
+	// Define a code owners flag
+	f.owners = fs.Bool("owners", false, "Annotate the directories and components with their owners from CODEOWNERS")
+
+	// Define a cache flag
+	f.cache = fs.Bool("cache", false, "Cache the findings of every file in .repoexplainer/cache and only scan the changed files")
+
+	// Define a workers flag
+	f.workers = fs.Int("workers", 0, "Number of files scanned in parallel, 0 means the number of CPUs")
+
+	// Define a statistics flag
+	f.stats = fs.Bool("stats", false, "Add a statistics section with the size of every package")
+
+	return f
+}
+
+// apply overrides the config with the flags given on the command line.
+// The repeatable flags add to the lists of the config.
+func (f *cliFlags) apply(fs *flag.FlagSet, cfg *config.Config) error {
+	set := map[string]bool{}
+	fs.Visit(func(fl *flag.Flag) {
+		set[fl.Name] = true
+	})
+
+	if set["max-tokens"] {
+		cfg.Budgets.MaxTokens = *f.maxTokens
+	}
+	if set["chunk-tokens"] {
+		cfg.Budgets.ChunkTokens = *f.chunkTokens
+	}
+	if set["visibility"] {
+		cfg.Visibility = *f.visibility
+	}
+	if set["mermaid"] {
+		cfg.Sections.Mermaid = *f.mermaid
+	}
+	if set["format"] {
+		cfg.Format = *f.format
+	}
+	if set["template"] {
+		cfg.Template = *f.template
+	}
+	if set["include-bodies-under"] {
+		cfg.Filters.IncludeBodiesUnder = *f.includeBodiesUnder
+	}
+	if set["tests"] {
+		cfg.Sections.Tests = *f.tests
+	}
+	if set["markers"] {
+		cfg.Sections.Markers = *f.markers
+	}
+	if set["coverage"] {
+		cfg.Sections.Coverage = *f.coverage
+	}
+	if set["history"] {
+		cfg.Sections.History = *f.history
+	}
+	if set["history-days"] {
+		cfg.Sections.HistoryDays = *f.historyDays
+	}
+	if set["owners"] {
+		cfg.Sections.Owners = *f.owners
+	}
+	if set["cache"] {
+		cfg.Cache = *f.cache
+	}
+	if set["workers"] {
+		cfg.Workers = *f.workers
+	}
+	if set["stats"] {
+		cfg.Sections.Stats = *f.stats
+	}
+
+	cfg.Filters.IncludeBodies = append(cfg.Filters.IncludeBodies, f.includeBodies...)
+	cfg.Filters.IncludeFiles = append(cfg.Filters.IncludeFiles, f.includeFiles...)
+	cfg.Filters.Exclude = append(cfg.Filters.Exclude, f.exclude...)
+	cfg.Finders.Plugins = append(cfg.Finders.Plugins, f.plugins...)
+
+	// A finder enabled on the command line is enabled even if the config disables it, and the other way round
+	for _, name := range f.enableFinders {
+		cfg.Finders.Enable = append(cfg.Finders.Enable, name)
+		cfg.Finders.Disable = without(cfg.Finders.Disable, name)
+	}
+	for _, name := range f.disableFinders {
+		cfg.Finders.Disable = append(cfg.Finders.Disable, name)
+		cfg.Finders.Enable = without(cfg.Finders.Enable, name)
+	}
+
+	for _, value := range f.finderOptions {
+		name, key, optionValue, err := parseFinderOption(value)
+		if err != nil {
+			return err
+		}
+		if cfg.Finders.Options == nil {
+			cfg.Finders.Options = map[string]map[string]string{}
+		}
+		if cfg.Finders.Options[name] == nil {
+			cfg.Finders.Options[name] = map[string]string{}
+		}
+		cfg.Finders.Options[name][key] = optionValue
+	}
+
+	return nil
+}
+
+// without returns the list without the value.
+func without(list []string, value string) []string {
+	result := []string{}
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// parseFinderOption parses a finder option like "go.tests=false" into the finder name, the option name and the value.
+func parseFinderOption(value string) (string, string, string, error) {
+	option, optionValue, ok := strings.Cut(value, "=")
+	name, key, dotted := strings.Cut(option, ".")
+	if !ok || !dotted || name == "" || key == "" {
+		return "", "", "", fmt.Errorf("invalid finder option %q, expected NAME.KEY=VALUE", value)
+	}
+
+	return name, key, optionValue, nil
+}
+
+// stringList is a flag that can be given several times.
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, ",")
+}
+
+func (sl *stringList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
+}
@@ -1,100 +1,505 @@
 package main
 
 import (
-	"bytes"
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/burwei/repoexplainer/app"
+	"github.com/burwei/repoexplainer/clipboard"
+	"github.com/burwei/repoexplainer/compfinder"
+	"github.com/burwei/repoexplainer/config"
+	"github.com/burwei/repoexplainer/reportgen"
 )
 
+// Exit codes of the commands. Like for grep and diff, 1 isn't an error.
+const (
+	exitOK          = 0
+	exitNoMatch     = 1   // exitNoMatch means that symbols found no matching symbol
+	exitDifferences = 1   // exitDifferences means that diff found differences
+	exitError       = 2   // exitError means an invalid command line or configuration, or a failure
+	exitInterrupted = 130 // exitInterrupted means the analysis was stopped by Ctrl-C, like a shell reports it
+)
+
+// stdoutPath is the output path of stdout.
+const stdoutPath = "-"
+
+// command is a subcommand of repoexplainer.
+type command struct {
+	name        string
+	usage       string // usage are the arguments of the command
+	description string
+	run         func(args []string) int // run runs the command with the arguments after its name and returns the exit code
+}
+
+// commands returns the subcommands of repoexplainer.
+func commands() []command {
+	return []command{
+		{name: "report", usage: "[flags] [directory]", description: "Generate the report and copy it to the clipboard or write it to a file (default)", run: runReport},
+		{name: "tree", usage: "[flags] [--depth N] [directory]", description: "Print the directory structure", run: runTree},
+		{name: "symbols", usage: "[flags] [-i] [--type T] PATTERN [directory]", description: "List the components and methods whose qualified name matches a regular expression", run: runSymbols},
+		{name: "stats", usage: "[flags] [directory]", description: "Print the size of every package and the largest files and funcs", run: runStats},
+		{name: "diff", usage: "[flags] BASE [directory]", description: "List the components and methods added, removed or changed since BASE, a directory or a git revision", run: runDiff},
+		{name: "finders", description: "List the finders with their options", run: runFinders},
+		{name: "config", usage: "show [flags] [directory]", description: "Print the configuration from the config files and the flags", run: runConfig},
+	}
+}
+
+// synopsis returns how the command is run, like "repoexplainer tree [flags] [--depth N] [directory]".
+func (c command) synopsis() string {
+	return strings.TrimSpace("repoexplainer " + c.name + " " + c.usage)
+}
+
 func main() {
-	// Define a help flag
-	helpFlag := flag.Bool("h", false, "Display help information")
+	// The report is generated if no command is given
+	args := os.Args[1:]
+	name := "report"
+	if len(args) > 0 {
+		if _, ok := findCommand(args[0]); ok {
+			name = args[0]
+			args = args[1:]
+		}
+	}
 
-	// Define a file output flag
-	fileFlag := flag.Bool("f", false, "Write output to a file")
+	cmd, _ := findCommand(name)
+	os.Exit(cmd.run(args))
+}
 
-	flag.Parse()
+// findCommand returns the subcommand with the name.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// printHelp prints the help of repoexplainer.
+func printHelp() {
+	fmt.Println("repoexplainer - Analyze a repository and generate a repoexlain.md at current directory")
+	fmt.Println("\nUsage of repoexplainer:")
+	fmt.Println("  repoexplainer [report] [flags] [directory]")
+	fmt.Println("\nCommands:")
+	for _, cmd := range commands() {
+		fmt.Printf("  %s: %s\n", cmd.synopsis(), cmd.description)
+	}
+	fmt.Println("\nFlags of every command but finders:")
+	fmt.Println("  -h: Display help information")
+	fmt.Println("  -f: Write output to a file in the current directory")
+	fmt.Println("  -o PATH: Write output to a file at PATH, or to stdout if PATH is -. Parts of a split report go to PATH-1, PATH-2, ... before the extension")
+	fmt.Println("  --force: Let -o write a file in the analyzed directory, which the next runs would scan")
+	fmt.Println("  --max-tokens N: Leave out details until the report fits into about N tokens")
+	fmt.Println("  --chunk-tokens N: Split the report into parts of about N tokens each")
+	fmt.Println("  --visibility V: Components to show: all (default), exported (public API only) or unexported")
+	fmt.Println("  --mermaid: Add a Mermaid class diagram of the structs and interfaces to the report")
+	fmt.Println("  --format F: Output format: markdown (default), dot (Graphviz), plantuml or html")
+	fmt.Println("  --template FILE: Wrap the report in a Go text/template file, with the report in {{.Output}} and the analysis in {{.Report}}")
+	fmt.Println("  --include-body S: Show the source code of a symbol like pkg.Func or pkg.Type.Method, can be repeated")
+	fmt.Println("  --include-bodies-under N: Show the source code of every func and method shorter than N lines")
+	fmt.Println("  --include-file G: Show the whole files matching a glob pattern like '*.proto' or 'cmd/*/main.go', can be repeated")
+	fmt.Println("  --plugin P=CMD: Give the files matching the comma separated patterns P to the finder plugin CMD, which replies with their components as JSON, can be repeated")
+	fmt.Println("  --enable-finder NAME: Enable a finder that isn't enabled by default, see repoexplainer finders, can be repeated")
+	fmt.Println("  --disable-finder NAME: Disable a finder, e.g. markers, can be repeated")
+	fmt.Println("  --finder-option NAME.KEY=VALUE: Set an option of a finder, like go.tests=false or go.unexported=false, can be repeated")
+	fmt.Println("  --exclude G: Leave out the files and directories matching a glob pattern like '*.pb.go' or 'vendor', can be repeated")
+	fmt.Println("  --tests: Move the tests, benchmarks, fuzz targets and examples to a tests section with their test cases, and show which components they test")
+	fmt.Println("  --markers: Add a markers section with the TODO, FIXME, HACK and Deprecated comments, and mark the deprecated components")
+	fmt.Println("  --coverage FILE: Annotate the funcs and methods with their coverage from a go test -coverprofile file, and list the untested exported ones")
+	fmt.Println("  --history: Annotate the files and packages with their last modification date, commit count and top contributors from the local git history, and list the hot spots and stale packages")
+	fmt.Println("  --history-days N: Count the commits of the last N days for --history (default 90)")
+	fmt.Println("  --owners: Annotate the directories and components with their owners from the CODEOWNERS file (last match wins, like on GitHub)")
+	fmt.Println("  --cache: Cache the findings of every file in .repoexplainer/cache, so the next runs only scan the files that have changed")
+	fmt.Println("  --workers N: Number of files scanned in parallel (default: the number of CPUs)")
+	fmt.Println("  --stats: Add a statistics section with the lines, components and tests of every package, and the largest files and funcs")
+	fmt.Println("\nThe flags override the .repoexplainer.yaml of the repo, which overrides the user config in $XDG_CONFIG_HOME/repoexplainer/config.yaml.")
+	fmt.Println("The repeatable flags add to the lists of the config files.")
+	fmt.Println("\nExit codes: 0 on success, 1 if symbols matches nothing or diff finds differences, 2 on errors, 130 if interrupted.")
+	fmt.Println("\nExamples:")
+	fmt.Println("  repoexplainer .                  # Analyze the current directory and copy output to clipboard")
+	fmt.Println("  repoexplainer ./../another_repo  # Analyze a relative directory path and copy output to clipboard")
+	fmt.Println("  repoexplainer /path/to/the/repo  # Analyze an absolute directory path and copy output to clipboard")
+	fmt.Println("  repoexplainer -f .               # Analyze the current directory and write output to a file")
+	fmt.Println("  repoexplainer -o - . | less      # Analyze the current directory and write output to stdout")
+	fmt.Println("  repoexplainer -o ~/reports/api.md .  # Analyze the current directory and write output to ~/reports/api.md")
+	fmt.Println("  repoexplainer --max-tokens 8000  # Analyze the current directory and fit the report into about 8000 tokens")
+	fmt.Println("  repoexplainer --chunk-tokens 8000 -f .  # Write parts of about 8000 tokens to repoexplain-1.md, repoexplain-2.md, ...")
+	fmt.Println("  repoexplainer --visibility exported  # Analyze the current directory and only show its public API")
+	fmt.Println("  repoexplainer --finder-option go.tests=false .  # Analyze the current directory without the _test.go files")
+	fmt.Println("  repoexplainer --format dot -f .  # Write the package and type graphs to repoexplain.dot")
+	fmt.Println("  repoexplainer --format html -f . # Write an interactive report to repoexplain.html")
+	fmt.Println("  repoexplainer --coverage coverage.out .  # Show the coverage from go test -coverprofile=coverage.out ./...")
+	fmt.Println("  repoexplainer --include-body reportgen.ReportGenerator.GenerateReport .  # Show the code of GenerateReport in the report")
+	fmt.Println("  repoexplainer tree --depth 2 .   # Print the directory structure down to the second level")
+	fmt.Println("  repoexplainer symbols 'Render' . # List the components and methods whose name matches Render")
+	fmt.Println("  repoexplainer diff main .        # List the components and methods added, removed or changed since the main branch")
+}
+
+// runReport generates the report and copies it to the clipboard or writes it to files.
+func runReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	f := defineFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
 
 	// Check if the help flag was provided
-	if *helpFlag {
-		fmt.Println("repoexplainer - Analyze a repository and generate a repoexlain.md at current directory")
-		fmt.Println("\nUsage of repoexplainer:")
-		fmt.Println("  repoexplainer [directory]")
-		fmt.Println("  -h: Display help information")
-		fmt.Println("  -f: Write output to a file")
-		fmt.Println("\nExamples:")
-		fmt.Println("  repoexplainer .                  # Analyze the current directory and copy output to clipboard")
-		fmt.Println("  repoexplainer ./../another_repo  # Analyze a relative directory path and copy output to clipboard")
-		fmt.Println("  repoexplainer /path/to/the/repo  # Analyze an absolute directory path and copy output to clipboard")
-		fmt.Println("  repoexplainer -f .               # Analyze the current directory and write output to a file")
-		return
-	}
-
-	var dirPath string
-
-	// Check if the user has provided a directory path as an argument
-	if len(flag.Args()) > 0 {
-		// Use the provided directory path
-		dirPath = flag.Arg(0)
+	if *f.help {
+		printHelp()
+		return exitOK
+	}
+
+	absPath, cfg, err := prepare(f, fs, fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+
+	target, err := outputPath(f, absPath, cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	opts, err := analysisOptions(cfg)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Stop the analysis on Ctrl-C, and let Ctrl-C end the program as usual afterwards
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	result, err := app.Run(ctx, absPath, opts...)
+	stop()
+	if err != nil {
+		return fail(err)
+	}
+	printWarnings(result.Report.Warnings)
+
+	if cfg.Budgets.ChunkTokens > 0 {
+		err = writeChunks(result.Parts, target, cfg.Format)
 	} else {
-		// Default to the current working directory if no argument is provided
+		err = writeReport(result.Output, target, cfg.Format)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	return exitOK
+}
+
+// outputPath returns where the report is written: a file path, stdoutPath for stdout,
+// or an empty string for the clipboard. The report isn't written in the analyzed directory
+// unless it's forced, since it would be scanned by the next runs, except where the traversal doesn't go,
+// like hidden and excluded directories.
+func outputPath(f *cliFlags, absPath string, cfg *config.Config) (string, error) {
+	if *f.file && *f.output != "" {
+		return "", fmt.Errorf("-f and -o can't be used together")
+	}
+
+	// -f writes to the current working directory, wherever it is
+	if *f.file {
 		cwd, err := os.Getwd()
 		if err != nil {
-			log.Fatalf("Error getting current working directory: %s", err)
+			return "", fmt.Errorf("getting current working directory: %s", err)
 		}
-		dirPath = cwd
+
+		return filepath.Join(cwd, app.OutputFileName(cfg.Format)), nil
 	}
 
-	if !filepath.IsAbs(dirPath) {
-		cwd, err := os.Getwd()
+	if *f.output == "" || *f.output == stdoutPath {
+		return *f.output, nil
+	}
+
+	target, err := filepath.Abs(*f.output)
+	if err != nil {
+		return "", fmt.Errorf("getting absolute path of %s: %s", *f.output, err)
+	}
+	if *f.force {
+		return target, nil
+	}
+
+	// A symlink can lead into the analyzed directory, or out of it
+	resolvedTarget, err := resolveSymlinks(target)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %s", *f.output, err)
+	}
+	resolvedRoot, err := resolveSymlinks(absPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %s", absPath, err)
+	}
+
+	traverser := &reportgen.FileTraverser{RootPath: resolvedRoot, Exclude: cfg.Filters.Exclude}
+	if !traverser.Skips(resolvedTarget) {
+		return "", fmt.Errorf("refusing to write %s in the analyzed directory, the next runs would scan it; use --force to write it anyway", *f.output)
+	}
+
+	return target, nil
+}
+
+// resolveSymlinks returns the absolute path with its symlinks resolved.
+// The end of the path that doesn't exist yet, like a file about to be written, is kept as it is.
+func resolveSymlinks(absPath string) (string, error) {
+	missing := ""
+	for dirPath := absPath; ; dirPath = filepath.Dir(dirPath) {
+		resolved, err := filepath.EvalSymlinks(dirPath)
+		if err == nil {
+			return filepath.Join(resolved, missing), nil
+		}
+		if !os.IsNotExist(err) || dirPath == filepath.Dir(dirPath) {
+			return "", err
+		}
+
+		missing = filepath.Join(filepath.Base(dirPath), missing)
+	}
+}
+
+// writeReport writes the report to a file or stdout, or copies it to the clipboard if there's no target.
+func writeReport(output string, target string, format string) error {
+	switch target {
+	case "":
+		fallbackPath := clipboardFallbackPath(format)
+		backend, err := newClipboard(fallbackPath).Copy(output)
 		if err != nil {
-			log.Fatalf("Could not get current working directory: %s", err)
+			return err
 		}
-		dirPath = filepath.Join(cwd, dirPath)
+
+		if file, ok := backend.(clipboard.File); ok {
+			fmt.Printf("No clipboard is available, report written to %s instead.\n", file.Path)
+			return nil
+		}
+
+		fmt.Printf("Report copied to %s successfully!\n", backend.Name())
+		return keepTerminalCopy(backend, output, fallbackPath)
+	case stdoutPath:
+		_, err := os.Stdout.WriteString(output)
+		return err
 	}
 
-	// Clean up the path to resolve any ".." or "." segments
-	absPath := filepath.Clean(dirPath)
+	err := app.WriteFile(target, []byte(output))
+	if err != nil {
+		return fmt.Errorf("writing report file: %s", err)
+	}
 
-	// Write output to a file or copy to clipboard based on the flag
-	if *fileFlag {
-		// Write output to a file
-		cwd, err := os.Getwd()
+	fmt.Printf("Report written to %s successfully!\n", target)
+	return nil
+}
+
+// writeChunks writes the parts of the report to numbered files or one after the other to stdout,
+// or copies them to the clipboard one at a time and waits for the user before copying the next one.
+func writeChunks(parts []string, target string, format string) error {
+	switch target {
+	case "":
+		// Copied to the clipboard below
+	case stdoutPath:
+		_, err := os.Stdout.WriteString(strings.Join(parts, "\n"))
+		return err
+	default:
+		err := writeChunkFiles(parts, target, 1)
 		if err != nil {
-			log.Fatalf("getting current working directory: %s", err)
+			return err
 		}
 
-		file, err := os.Create(filepath.Join(cwd, app.FileName))
+		fmt.Printf("Report split into %d files from %s successfully!\n", len(parts), app.ChunkPath(target, 1))
+		return nil
+	}
+
+	// The parts that can't be copied are written to the fallback files, which mustn't be mixed with older ones
+	err := app.RemoveChunks(clipboardFallbackPath(format), len(parts)+1)
+	if err != nil {
+		return fmt.Errorf("removing old report file: %s", err)
+	}
+
+	stdin := bufio.NewReader(os.Stdin)
+	for i, part := range parts {
+		fallbackPath := app.ChunkPath(clipboardFallbackPath(format), i+1)
+		backend, err := newClipboard(fallbackPath).Copy(part)
 		if err != nil {
-			log.Fatalf("creating report file: %s", err)
+			return err
+		}
+
+		// The parts written to files don't need to wait for the user
+		if file, ok := backend.(clipboard.File); ok {
+			fmt.Printf("No clipboard is available, part %d/%d written to %s instead.\n", i+1, len(parts), file.Path)
+			continue
+		}
+
+		if i == len(parts)-1 {
+			fmt.Printf("Part %d/%d copied to %s successfully!\n", i+1, len(parts), backend.Name())
+			return keepTerminalCopy(backend, part, fallbackPath)
 		}
-		defer file.Close()
 
-		err = app.Run(absPath, file)
+		fmt.Printf("Part %d/%d copied to %s.\n", i+1, len(parts), backend.Name())
+		if err := keepTerminalCopy(backend, part, fallbackPath); err != nil {
+			return err
+		}
+		fmt.Print("Press Enter to copy the next part...")
+		if _, err := stdin.ReadString('\n'); err != nil {
+			// Nobody can press Enter, e.g. stdin isn't a terminal, so the next parts are written to files
+			fmt.Println()
+			err := writeChunkFiles(parts[i+1:], clipboardFallbackPath(format), i+2)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Can't wait for Enter, parts %d-%d written from %s instead.\n",
+				i+2, len(parts), app.ChunkPath(clipboardFallbackPath(format), i+2))
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// writeChunkFiles writes the parts to the numbered files of the target from the part first on,
+// and removes the files of the next parts left by an earlier run that split the report into more parts.
+func writeChunkFiles(parts []string, target string, first int) error {
+	for i, part := range parts {
+		err := app.WriteFile(app.ChunkPath(target, first+i), []byte(part))
 		if err != nil {
-			log.Fatalf("Error running app: %s", err)
+			return fmt.Errorf("writing report file: %s", err)
 		}
+	}
 
-		fmt.Println("Report file generated successfully!")
-	} else {
-		var buffer bytes.Buffer
+	err := app.RemoveChunks(target, first+len(parts))
+	if err != nil {
+		return fmt.Errorf("removing old report file: %s", err)
+	}
+
+	return nil
+}
+
+// newClipboard returns the clipboard backends tried one after the other: the native clipboard,
+// the terminal with OSC 52 for headless and SSH sessions, and the file at fallbackPath.
+func newClipboard(fallbackPath string) clipboard.Chain {
+	return clipboard.Chain{
+		clipboard.Native{},
+		clipboard.NewOSC52(),
+		clipboard.File{Path: fallbackPath, Write: app.WriteFile},
+	}
+}
+
+// keepTerminalCopy also writes the text copied with OSC 52 to the fallback file and prints its path,
+// since the terminal can ignore the sequence without telling.
+func keepTerminalCopy(backend clipboard.Backend, text string, fallbackPath string) error {
+	if _, ok := backend.(*clipboard.OSC52); !ok {
+		return nil
+	}
+
+	err := app.WriteFile(fallbackPath, []byte(text))
+	if err != nil {
+		return fmt.Errorf("writing report file: %s", err)
+	}
+
+	fmt.Printf("If your terminal doesn't support OSC 52, it's also in %s.\n", fallbackPath)
+	return nil
+}
+
+// clipboardFallbackPath returns where the report is written if there's no clipboard. It's in the
+// temporary directory rather than the current one, which may be the analyzed directory.
+func clipboardFallbackPath(format string) string {
+	return filepath.Join(os.TempDir(), app.OutputFileName(format))
+}
+
+// prepare loads the configuration of the repo in dirPath and overrides it with the flags.
+func prepare(f *cliFlags, fs *flag.FlagSet, dirPath string) (string, *config.Config, error) {
+	absPath, err := rootPath(dirPath)
+	if err != nil {
+		return "", nil, err
+	}
 
-		err := app.Run(absPath, &buffer)
+	cfg, err := loadConfig(f, fs, absPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return absPath, cfg, nil
+}
+
+// analysisOptions returns the options of an analysis with the configuration.
+// Every analysis needs its own options, since the finders keep what they've found.
+func analysisOptions(cfg *config.Config) ([]app.Option, error) {
+	opts := cfg.Options()
+	err := opts.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s", err)
+	}
+
+	finderConfig, err := cfg.FinderConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s", err)
+	}
+
+	finderFactory, err := compfinder.NewConfiguredFinderFactory(finderConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s", err)
+	}
+
+	template, err := cfg.ReadTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %s", err)
+	}
+
+	return []app.Option{app.WithOptions(opts), app.WithFinderFactory(finderFactory), app.WithTemplate(template)}, nil
+}
+
+// rootPath returns the absolute path to the directory to analyze, the current working directory if dirPath is empty.
+func rootPath(dirPath string) (string, error) {
+	// Default to the current working directory if no argument is provided
+	if dirPath == "" {
+		cwd, err := os.Getwd()
 		if err != nil {
-			log.Fatalf("Error running app: %s", err)
+			return "", fmt.Errorf("getting current working directory: %s", err)
 		}
+		dirPath = cwd
+	}
 
-		err = clipboard.WriteAll(buffer.String())
+	if !filepath.IsAbs(dirPath) {
+		cwd, err := os.Getwd()
 		if err != nil {
-			log.Fatalf("Error copying to clipboard: %s", err)
+			return "", fmt.Errorf("getting current working directory: %s", err)
 		}
+		dirPath = filepath.Join(cwd, dirPath)
+	}
+
+	// Clean up the path to resolve any ".." or "." segments
+	return filepath.Clean(dirPath), nil
+}
+
+// loadConfig loads the config files of the repo and overrides them with the flags.
+func loadConfig(f *cliFlags, fs *flag.FlagSet, absPath string) (*config.Config, error) {
+	cfg, err := config.Load(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %s", err)
+	}
+
+	err = f.apply(fs, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flags: %s", err)
+	}
+
+	return cfg, nil
+}
+
+// fail prints the error to stderr and returns the exit code of the error.
+func fail(err error) int {
+	if errors.Is(err, context.Canceled) {
+		log.Printf("Interrupted")
+		return exitInterrupted
+	}
+
+	log.Printf("Error: %s", err)
+	return exitError
+}
 
-		fmt.Println("Report copied to clipboard successfully!")
+// printWarnings prints the files and directories left out of the report to stderr.
+func printWarnings(warnings []reportgen.Warning) {
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
 	}
 }
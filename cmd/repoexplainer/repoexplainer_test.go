@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/burwei/repoexplainer/app"
+	"github.com/burwei/repoexplainer/config"
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	repoDir := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(filepath.Join(repoDir, "docs"), 0755)
+	os.Symlink(repoDir, filepath.Join(tmpDir, "link"))
+	cwd, _ := os.Getwd()
+
+	testCases := []struct {
+		name      string
+		args      []string
+		exclude   []string
+		expTarget string
+		expErr    string
+	}{
+		{
+			name:   "-f and -o together",
+			args:   []string{"-f", "-o", filepath.Join(tmpDir, "report.md")},
+			expErr: "-f and -o can't be used together",
+		},
+		{
+			name:      "-f writes to the current directory",
+			args:      []string{"-f"},
+			expTarget: filepath.Join(cwd, app.FileName),
+		},
+		{
+			name:      "Clipboard",
+			args:      []string{},
+			expTarget: "",
+		},
+		{
+			name:      "Stdout",
+			args:      []string{"-o", "-"},
+			expTarget: stdoutPath,
+		},
+		{
+			name:      "Out of the analyzed directory",
+			args:      []string{"-o", filepath.Join(tmpDir, "report.md")},
+			expTarget: filepath.Join(tmpDir, "report.md"),
+		},
+		{
+			name:   "In the analyzed directory",
+			args:   []string{"-o", filepath.Join(repoDir, "docs", "report.md")},
+			expErr: "refusing to write",
+		},
+		{
+			name:   "In the analyzed directory through a symlink",
+			args:   []string{"-o", filepath.Join(tmpDir, "link", "report.md")},
+			expErr: "refusing to write",
+		},
+		{
+			name:      "In the analyzed directory with --force",
+			args:      []string{"--force", "-o", filepath.Join(repoDir, "report.md")},
+			expTarget: filepath.Join(repoDir, "report.md"),
+		},
+		{
+			name:      "In a hidden directory of the analyzed directory",
+			args:      []string{"-o", filepath.Join(repoDir, ".reports", "report.md")},
+			expTarget: filepath.Join(repoDir, ".reports", "report.md"),
+		},
+		{
+			name:      "In an excluded directory of the analyzed directory",
+			args:      []string{"-o", filepath.Join(repoDir, "docs", "report.md")},
+			exclude:   []string{"docs"},
+			expTarget: filepath.Join(repoDir, "docs", "report.md"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("report", flag.ContinueOnError)
+			f := defineFlags(fs)
+			assert.NoError(t, fs.Parse(tc.args))
+			cfg := config.Default()
+			cfg.Filters.Exclude = tc.exclude
+
+			target, err := outputPath(f, repoDir, cfg)
+
+			if tc.expErr != "" {
+				assert.ErrorContains(t, err, tc.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expTarget, target)
+		})
+	}
+}
+
+func TestRunReportStable(t *testing.T) {
+	repoDir := newTestRepo(t)
+
+	// The report doesn't depend on the scheduling of the workers, nor on the cache
+	reports := map[string]bool{}
+	for _, args := range [][]string{
+		{"--workers", "1"},
+		{"--workers", "8"},
+		{"--workers", "8"},
+		{"--workers", "8", "--cache"},
+		{"--workers", "8", "--cache"},
+	} {
+		output, code := captureStdout(t, func() int {
+			return runReport(append(args, "-o", "-", repoDir))
+		})
+		assert.Equal(t, exitOK, code)
+		reports[output] = true
+	}
+
+	assert.Len(t, reports, 1)
+}
+
+func TestWriteChunks(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "report.md")
+	// An earlier run split the report into more parts
+	for part := 1; part <= 4; part++ {
+		os.WriteFile(app.ChunkPath(target, part), []byte("old\n"), 0644)
+	}
+
+	output, _ := captureStdout(t, func() int {
+		assert.NoError(t, writeChunks([]string{"# part 1\n", "# part 2\n"}, target, reportgen.FormatMarkdown))
+		return exitOK
+	})
+
+	assert.Contains(t, output, "Report split into 2 files")
+	data, _ := os.ReadFile(app.ChunkPath(target, 2))
+	assert.Equal(t, "# part 2\n", string(data))
+	assert.NoFileExists(t, app.ChunkPath(target, 3))
+	assert.NoFileExists(t, app.ChunkPath(target, 4))
+}
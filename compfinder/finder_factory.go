@@ -1,21 +1,140 @@
 package compfinder
 
 import (
-	"github.com/burwei/repoexplainer/compfinder/golang"
+	"fmt"
+
+	"github.com/burwei/repoexplainer/compfinder/plugin"
+	"github.com/burwei/repoexplainer/compfinder/registry"
 	"github.com/burwei/repoexplainer/reportgen"
+
+	// The finder packages register their finders when they are imported
+	_ "github.com/burwei/repoexplainer/compfinder/golang"
+	_ "github.com/burwei/repoexplainer/compfinder/marker"
+	_ "github.com/burwei/repoexplainer/compfinder/python"
 )
 
+// Config selects the registered finders and their options.
+type Config struct {
+	Enable  []string                     // Enable are the names of the finders enabled besides the default ones
+	Disable []string                     // Disable are the names of the finders left out, default or not
+	Options map[string]map[string]string // Options are the options of the finders by finder name
+	Plugins []plugin.Config              // Plugins are run as finders after the registered ones
+}
+
+// enabledFinder is a registered finder with its options.
+type enabledFinder struct {
+	finder  registry.Finder
+	options map[string]string
+}
+
 // FinderFactory is a struct that manages a collection of ComponentFinders.
 type FinderFactory struct {
 	Finders []reportgen.ComponentFinder
+	enabled []enabledFinder
+	plugins []plugin.Config
+}
+
+// NewFinderFactory creates the default finders of the registry, and a finder for each of the plugins.
+// It panics if a default finder can't be created with its default options.
+func NewFinderFactory(plugins ...plugin.Config) *FinderFactory {
+	ff := &FinderFactory{plugins: plugins}
+	for _, finder := range registry.All() {
+		if finder.Default {
+			ff.enabled = append(ff.enabled, enabledFinder{finder: finder})
+		}
+	}
+
+	finders, err := ff.createFinders()
+	if err != nil {
+		panic(err)
+	}
+	ff.Finders = finders
+
+	return ff
 }
 
-func NewFinderFactory() *FinderFactory {
-	golangCompFinder := golang.NewComponentFinder()
+// NewConfiguredFinderFactory creates the finders of the registry selected by the config, with their options,
+// and a finder for each of the plugins.
+func NewConfiguredFinderFactory(config Config) (*FinderFactory, error) {
+	disabled := map[string]bool{}
+	for _, name := range config.Disable {
+		if _, ok := registry.Lookup(name); !ok {
+			return nil, fmt.Errorf("unknown finder %q", name)
+		}
+		disabled[name] = true
+	}
 
-	return &FinderFactory{
-		Finders: []reportgen.ComponentFinder{golangCompFinder},
+	enabled := map[string]bool{}
+	for _, name := range config.Enable {
+		if _, ok := registry.Lookup(name); !ok {
+			return nil, fmt.Errorf("unknown finder %q", name)
+		}
+		enabled[name] = true
 	}
+
+	for name := range config.Options {
+		if _, ok := registry.Lookup(name); !ok {
+			return nil, fmt.Errorf("options of unknown finder %q", name)
+		}
+	}
+
+	for _, pluginConfig := range config.Plugins {
+		if err := pluginConfig.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	ff := &FinderFactory{plugins: config.Plugins}
+	for _, finder := range registry.All() {
+		if (finder.Default || enabled[finder.Name]) && !disabled[finder.Name] {
+			ff.enabled = append(ff.enabled, enabledFinder{finder: finder, options: config.Options[finder.Name]})
+		}
+	}
+
+	finders, err := ff.createFinders()
+	if err != nil {
+		return nil, err
+	}
+	ff.Finders = finders
+
+	return ff, nil
+}
+
+// Enabled returns the names of the enabled finders of the registry.
+func (ff *FinderFactory) Enabled() []string {
+	names := []string{}
+	for _, enabled := range ff.enabled {
+		names = append(names, enabled.finder.Name)
+	}
+
+	return names
+}
+
+// createFinders creates the enabled finders and the plugin finders.
+func (ff *FinderFactory) createFinders() ([]reportgen.ComponentFinder, error) {
+	finders := []reportgen.ComponentFinder{}
+	for _, enabled := range ff.enabled {
+		finder, err := enabled.finder.Create(enabled.options)
+		if err != nil {
+			return nil, fmt.Errorf("creating finder %s: %s", enabled.finder.Name, err)
+		}
+		finders = append(finders, finder)
+	}
+
+	for _, config := range ff.plugins {
+		finders = append(finders, plugin.NewFinder(config))
+	}
+
+	return finders, nil
+}
+
+// NewFinders creates a new set of the ComponentFinders, with nothing found yet.
+// Every worker scanning files in parallel gets its own set.
+func (ff *FinderFactory) NewFinders() []reportgen.ComponentFinder {
+	// The finders have been created with the same options already, so they can't fail
+	finders, _ := ff.createFinders()
+
+	return finders
 }
 
 // GetFinders creates instances of ComponentFinders and returns them.
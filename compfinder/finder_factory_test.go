@@ -0,0 +1,85 @@
+package compfinder
+
+import (
+	"testing"
+
+	"github.com/burwei/repoexplainer/compfinder/golang"
+	"github.com/burwei/repoexplainer/compfinder/marker"
+	"github.com/burwei/repoexplainer/compfinder/plugin"
+	"github.com/burwei/repoexplainer/compfinder/python"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfiguredFinderFactory(t *testing.T) {
+	testCases := []struct {
+		name       string
+		config     Config
+		expEnabled []string
+		expFinders int
+		expErr     bool
+	}{
+		{
+			name:       "Default finders",
+			expEnabled: []string{"go", "markers", "python"},
+			expFinders: 3,
+		},
+		{
+			name:       "Disabled finder",
+			config:     Config{Disable: []string{"markers", "python"}},
+			expEnabled: []string{"go"},
+			expFinders: 1,
+		},
+		{
+			name:       "Finder options and plugins",
+			config:     Config{Options: map[string]map[string]string{"go": {"tests": "false"}}, Plugins: []plugin.Config{{Command: []string{"dsl-finder"}, Files: []string{"*.dsl"}}}},
+			expEnabled: []string{"go", "markers", "python"},
+			expFinders: 4,
+		},
+		{
+			name:   "Unknown finder",
+			config: Config{Enable: []string{"cobol"}},
+			expErr: true,
+		},
+		{
+			name:   "Options of an unknown finder",
+			config: Config{Options: map[string]map[string]string{"cobol": {"tests": "false"}}},
+			expErr: true,
+		},
+		{
+			name:   "Unknown option",
+			config: Config{Options: map[string]map[string]string{"go": {"colors": "true"}}},
+			expErr: true,
+		},
+		{
+			name:   "Invalid plugin",
+			config: Config{Plugins: []plugin.Config{{Command: []string{"dsl-finder"}}}},
+			expErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ff, err := NewConfiguredFinderFactory(tc.config)
+
+			if tc.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expEnabled, ff.Enabled())
+			assert.Len(t, ff.GetFinders(), tc.expFinders)
+			assert.Len(t, ff.NewFinders(), tc.expFinders)
+		})
+	}
+}
+
+func TestNewFinderFactory(t *testing.T) {
+	ff := NewFinderFactory()
+
+	finders := ff.GetFinders()
+	assert.Len(t, finders, 3)
+	assert.IsType(t, &golang.ComponentFinder{}, finders[0])
+	assert.IsType(t, &marker.MarkerFinder{}, finders[1])
+	assert.IsType(t, &python.ComponentFinder{}, finders[2])
+	assert.NotSame(t, finders[0], ff.NewFinders()[0])
+}
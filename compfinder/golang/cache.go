@@ -0,0 +1,66 @@
+package golang
+
+import (
+	"encoding/json"
+
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// cacheVersion identifies the findings of the ComponentFinder in the cache.
+// Change it whenever a change of the finders makes them find something else in the same file.
+const cacheVersion = "golang/2"
+
+// fileResult is everything the finders found in a single file.
+type fileResult struct {
+	Structs    reportgen.ComponentMap `json:"structs,omitempty"`
+	Interfaces reportgen.ComponentMap `json:"interfaces,omitempty"`
+	Funcs      reportgen.ComponentMap `json:"funcs,omitempty"`
+	Imports    []string               `json:"imports,omitempty"`
+	Module     string                 `json:"module,omitempty"`
+	Docs       map[string]string      `json:"docs,omitempty"`      // Docs maps the identifiers to their doc comments
+	Ranges     map[string][2]int      `json:"ranges,omitempty"`    // Ranges maps the identifiers to their first and last lines
+	TestCases  map[string][]string    `json:"testCases,omitempty"` // TestCases maps the test funcs to their test case names
+}
+
+// Version identifies the findings of the ComponentFinder in the cache.
+// The test files are only scanned if the options include them.
+func (cf *ComponentFinder) Version() string {
+	if !cf.options.IncludeTests {
+		return cacheVersion + "-notests"
+	}
+
+	return cacheVersion
+}
+
+// FileResult returns everything found in the current file.
+func (cf *ComponentFinder) FileResult() ([]byte, error) {
+	result := fileResult{
+		Structs:    cf.structFinder.fileComponents(),
+		Interfaces: cf.interfaceFinder.fileComponents(),
+		Funcs:      cf.funcFinder.fileComponents(),
+		Docs:       cf.docFinder.currentFileDocs(),
+		Ranges:     cf.lineFinder.fileRanges(),
+		TestCases:  cf.testCaseFinder.fileTestCases(),
+	}
+	result.Imports, result.Module = cf.importFinder.fileImportsAndModule()
+
+	return json.Marshal(result)
+}
+
+// RestoreFileResult adds everything found in a file by an earlier run, as if the file was processed again.
+func (cf *ComponentFinder) RestoreFileResult(filePath string, data []byte) error {
+	var result fileResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	cf.structFinder.restoreComponents(result.Structs)
+	cf.interfaceFinder.restoreComponents(result.Interfaces)
+	cf.funcFinder.restoreComponents(result.Funcs)
+	cf.importFinder.restoreImports(filePath, result.Imports, result.Module)
+	cf.docFinder.restoreDocs(filePath, result.Docs)
+	cf.lineFinder.restoreRanges(filePath, result.Ranges)
+	cf.testCaseFinder.restoreTestCases(filePath, result.TestCases)
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package golang
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentFinderRestoreFileResult(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "Struct with methods in another file",
+			files: map[string]string{
+				"server/server.go": `
+package server
+
+import "fmt"
+
+// Server serves.
+type Server struct {
+    Addr string
+}
+`,
+				"server/start.go": `
+package server
+
+import (
+	"net/http"
+)
+
+// Start starts the server.
+func (s *Server) Start() error {
+	return http.ListenAndServe(s.Addr, nil)
+}
+
+func New() *Server {
+	return &Server{}
+}
+`,
+			},
+		},
+		{
+			name: "Interfaces, modules and test cases",
+			files: map[string]string{
+				"go.mod": "module example.com/app\n",
+				"store/store.go": `
+package store
+
+type Store interface {
+	Get(key string) string
+}
+`,
+				"store/store_test.go": `
+package store
+
+import "example.com/app/store"
+
+func TestGet(t *testing.T) {
+	testCases := []struct {
+		name string
+	}{
+		{name: "Missing key"},
+	}
+}
+`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			paths := make([]string, 0, len(tc.files))
+			for path := range tc.files {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			// Scan every file and keep its result
+			scanned := NewComponentFinder()
+			results := map[string][]byte{}
+			for _, path := range paths {
+				scanned.SetFile(path)
+				for _, line := range strings.Split(tc.files[path], "\n") {
+					scanned.FindComponent(line)
+				}
+
+				result, err := scanned.FileResult()
+				assert.NoError(t, err)
+				results[path] = result
+			}
+
+			// Restore the results in another order, without scanning
+			restored := NewComponentFinder()
+			for i := len(paths) - 1; i >= 0; i-- {
+				assert.NoError(t, restored.RestoreFileResult(paths[i], results[paths[i]]))
+			}
+
+			assert.Equal(t, scanned.GetComponents(), restored.GetComponents())
+			assert.Equal(t, scanned.GetDependencies(), restored.GetDependencies())
+		})
+	}
+}
+
+func TestComponentFinderRestoreInvalidFileResult(t *testing.T) {
+	cf := NewComponentFinder()
+
+	assert.Error(t, cf.RestoreFileResult("a/a.go", []byte("{")))
+	assert.Equal(t, reportgen.ComponentMap{}, cf.GetComponents())
+}
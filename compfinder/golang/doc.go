@@ -0,0 +1,120 @@
+package golang
+
+import (
+	"strings"
+	"sync"
+)
+
+// DocFinder is a finder for the doc comments of the top-level declarations within Go files.
+// The doc comment of a declaration is the comment right above it, without any blank lines in between.
+type DocFinder struct {
+	mu       sync.Mutex
+	docs     map[string]string // docs maps "filePath:Identifier" to the doc comment
+	filePath string
+	pending  []string
+	fileDocs map[string]string // fileDocs maps the identifiers of the current file to their doc comments
+}
+
+func NewDocFinder() *DocFinder {
+	return &DocFinder{
+		docs:     map[string]string{},
+		fileDocs: map[string]string{},
+	}
+}
+
+// SetFile sets the directory path and file name for the current file being processed.
+// It's the beginning of a new file.
+func (df *DocFinder) SetFile(filePath string) {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	df.filePath = filePath
+	df.pending = nil
+	df.fileDocs = map[string]string{}
+}
+
+// FindComponent takes a line of code, including its comments, and collects the doc comment
+// of the declaration on that line.
+func (df *DocFinder) FindComponent(line string) {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	// Doc comments of top-level declarations start at the beginning of the line
+	if strings.HasPrefix(line, "//") {
+		df.pending = append(df.pending, strings.TrimSpace(strings.TrimPrefix(line, "//")))
+		return
+	}
+
+	if len(df.pending) > 0 {
+		if ident := declaredIdentifier(line); ident != "" {
+			df.docs[getDocKey(df.filePath, ident)] = strings.Join(df.pending, "\n")
+			df.fileDocs[ident] = strings.Join(df.pending, "\n")
+		}
+	}
+
+	df.pending = nil
+}
+
+// GetDoc returns the doc comment of a declaration in a file.
+// Methods are identified by "ReceiverType.MethodName".
+func (df *DocFinder) GetDoc(filePath, ident string) string {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	return df.docs[getDocKey(filePath, ident)]
+}
+
+// currentFileDocs returns the doc comments found in the current file by identifier.
+func (df *DocFinder) currentFileDocs() map[string]string {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	return df.fileDocs
+}
+
+// restoreDocs adds the doc comments found in a file earlier, as if the file was processed again.
+func (df *DocFinder) restoreDocs(filePath string, docs map[string]string) {
+	df.mu.Lock()
+	defer df.mu.Unlock()
+
+	for ident, doc := range docs {
+		df.docs[getDocKey(filePath, ident)] = doc
+	}
+}
+
+func getDocKey(filePath, ident string) string {
+	return filePath + ":" + ident
+}
+
+// declaredIdentifier returns the identifier declared by a "type" or "func" line,
+// e.g. "Server" for "type Server struct {" and "Server.Start" for "func (s *Server) Start() error {".
+func declaredIdentifier(line string) string {
+	parts := strings.Fields(line)
+	if len(parts) < 2 {
+		return ""
+	}
+
+	switch parts[0] {
+	case "type":
+		// Grouped declarations like "type (" have no identifier on the line
+		return identifierPrefix(parts[1])
+	case TypeFunc:
+		signature, receiver := extractFuncSignature(line)
+		name := identifierPrefix(signature)
+		if receiver != "" {
+			return identifierPrefix(receiver) + "." + name
+		}
+		return name
+	default:
+		return ""
+	}
+}
+
+// identifierPrefix returns the identifier at the beginning of s, e.g. "Map" for "Map[T any](m T)".
+func identifierPrefix(s string) string {
+	if idx := strings.IndexAny(s, "([ "); idx != -1 {
+		return s[:idx]
+	}
+
+	return s
+}
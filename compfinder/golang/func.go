@@ -14,6 +14,7 @@ type FuncFinder struct {
 	components  reportgen.ComponentMap
 	filePath    string
 	packageName string
+	fileKeys    []string // fileKeys are the keys of the funcs found in the current file
 }
 
 func NewFuncFinder() *FuncFinder {
@@ -30,6 +31,7 @@ func (ff *FuncFinder) SetFile(filePath string) {
 
 	ff.filePath = filePath
 	ff.packageName = ""
+	ff.fileKeys = nil
 }
 
 func (ff *FuncFinder) FindComponent(line string) {
@@ -45,9 +47,9 @@ func (ff *FuncFinder) FindComponent(line string) {
 	if strings.HasPrefix(line, "func ") {
 		funcSignature, receiver := extractFuncSignature(line)
 		if funcSignature != "" {
-			compKey := getFuncCompKey(receiver, funcSignature)
+			compKey := getFuncCompKey(filepath.Dir(ff.filePath), receiver, funcSignature)
 
-			// In Go, there can't be multiple functions with the same name with same receiver type
+			// In Go, there can't be multiple functions with the same name with same receiver type in a package
 			// So, we don't need to handle duplicate function definitions
 			ff.components[compKey] = reportgen.Component{
 				File:    ff.filePath,
@@ -55,6 +57,7 @@ func (ff *FuncFinder) FindComponent(line string) {
 				Name:    funcSignature,
 				Type:    TypeFunc,
 			}
+			ff.fileKeys = append(ff.fileKeys, compKey)
 		}
 	}
 }
@@ -73,28 +76,63 @@ func (ff *FuncFinder) GetComponents() reportgen.ComponentMap {
 	return compCopy
 }
 
+// fileComponents returns the funcs found in the current file.
+func (ff *FuncFinder) fileComponents() reportgen.ComponentMap {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	comps := reportgen.ComponentMap{}
+	for _, key := range ff.fileKeys {
+		comps[key] = ff.components[key]
+	}
+
+	return comps
+}
+
+// restoreComponents adds the funcs found in a file earlier, as if the file was processed again.
+func (ff *FuncFinder) restoreComponents(comps reportgen.ComponentMap) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+
+	for key, comp := range comps {
+		ff.components[key] = comp
+	}
+}
+
+// ConvertFuncCompKey returns the key of the struct a func is a method of, empty if it's not a method,
+// and the key of the func itself, like "path/to/dir:FuncName" or "path/to/dir:ReceiverType.FuncName".
 func (ff *FuncFinder) ConvertFuncCompKey(compKey string) (string, string) {
-	parts := strings.Split(compKey, ":")
 	comp := ff.components[compKey]
-	structCompKey := filepath.Dir(comp.File) + ":" + parts[0]
-	funcName := strings.Split(comp.Name, "(")[0]
-	dirPathBasedCompKey := filepath.Dir(comp.File) + ":" + funcName
+	receiver, _ := splitFuncCompKey(compKey)
+	dirPathBasedCompKey := filepath.Dir(comp.File) + compKey[strings.LastIndex(compKey, ":"):]
 
 	// receiver part of the compKey is empty
-	if parts[0] == "" {
+	if receiver == "" {
 		return "", dirPathBasedCompKey
 	}
 
-	return structCompKey, dirPathBasedCompKey
+	return filepath.Dir(comp.File) + ":" + receiver, dirPathBasedCompKey
 }
 
-func getFuncCompKey(receiver, funcSignature string) string {
+// getFuncCompKey returns the key of a func, "path/to/dir:ReceiverType.FuncName" or "path/to/dir:FuncName",
+// so the methods of the types of the same name in different packages don't collide.
+func getFuncCompKey(dirPath, receiver, funcSignature string) string {
 	funcName := strings.Split(funcSignature, "(")[0]
 	if receiver == "" {
-		return ":" + funcName
+		return dirPath + ":" + funcName
+	}
+
+	return dirPath + ":" + receiver + "." + funcName
+}
+
+// splitFuncCompKey returns the receiver type and the name of the func of a key made by getFuncCompKey.
+func splitFuncCompKey(compKey string) (string, string) {
+	ident := compKey[strings.LastIndex(compKey, ":")+1:]
+	if receiver, funcName, ok := strings.Cut(ident, "."); ok {
+		return receiver, funcName
 	}
 
-	return receiver + ":" + funcName
+	return "", ident
 }
 
 // Assumes method signatures line follows the pattern "func (r ReceiverType) MethodName() ReturnType {".
@@ -26,7 +26,7 @@ func SimpleFunc() int {
 }
 `,
 			expectedComp: reportgen.ComponentMap{
-				":SimpleFunc": reportgen.Component{
+				"simple:SimpleFunc": reportgen.Component{
 					File:    "simple/simple.go",
 					Package: "simple",
 					Name:    "SimpleFunc() int",
@@ -49,7 +49,7 @@ func (cs *ComplexStruct) GetValue() int {
 }
 `,
 			expectedComp: reportgen.ComponentMap{
-				"ComplexStruct:GetValue": reportgen.Component{
+				"complex:ComplexStruct.GetValue": reportgen.Component{
 					File:    "complex/complex.go",
 					Package: "complex",
 					Name:    "GetValue() int",
@@ -72,13 +72,13 @@ func SecondFunc() int {
 }
 `,
 			expectedComp: reportgen.ComponentMap{
-				":FirstFunc": reportgen.Component{
+				"multi:FirstFunc": reportgen.Component{
 					File:    "multi/multi.go",
 					Package: "multi",
 					Name:    "FirstFunc() string",
 					Type:    TypeFunc,
 				},
-				":SecondFunc": reportgen.Component{
+				"multi:SecondFunc": reportgen.Component{
 					File:    "multi/multi.go",
 					Package: "multi",
 					Name:    "SecondFunc() int",
@@ -0,0 +1,168 @@
+package golang
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// ImportFinder finds the packages imported by Go files and the module paths declared in go.mod files.
+// The module paths are used to find the directories of the imported packages within the repo.
+type ImportFinder struct {
+	mu          sync.Mutex
+	imports     map[string]map[string]bool // imports maps a directory path to the imported package paths
+	modules     map[string]string          // modules maps a module path to the directory of its go.mod
+	filePath    string
+	inImportBlk bool
+	fileImports []string // fileImports are the package paths imported by the current file
+	fileModule  string   // fileModule is the module path declared by the current go.mod file
+}
+
+func NewImportFinder() *ImportFinder {
+	return &ImportFinder{
+		imports: map[string]map[string]bool{},
+		modules: map[string]string{},
+	}
+}
+
+// SetFile sets the directory path and file name for the current file being processed.
+// It's the beginning of a new file.
+func (imf *ImportFinder) SetFile(filePath string) {
+	imf.mu.Lock()
+	defer imf.mu.Unlock()
+
+	imf.filePath = filePath
+	imf.inImportBlk = false
+	imf.fileImports = nil
+	imf.fileModule = ""
+}
+
+func (imf *ImportFinder) FindComponent(line string) {
+	imf.mu.Lock()
+	defer imf.mu.Unlock()
+
+	if filepath.Base(imf.filePath) == "go.mod" {
+		if strings.HasPrefix(line, "module ") {
+			modulePath := strings.Trim(strings.TrimSpace(line[len("module "):]), `"`)
+			imf.modules[modulePath] = filepath.Dir(imf.filePath)
+			imf.fileModule = modulePath
+		}
+		return
+	}
+
+	if filepath.Ext(imf.filePath) != ".go" {
+		return
+	}
+
+	trimmed := strings.TrimSpace(line)
+
+	// Import block detection logic
+	if imf.inImportBlk {
+		if trimmed == ")" {
+			imf.inImportBlk = false
+			return
+		}
+
+		imf.addImport(trimmed)
+		return
+	}
+
+	if !strings.HasPrefix(line, "import ") && !strings.HasPrefix(line, "import(") {
+		return
+	}
+
+	spec := strings.TrimSpace(strings.TrimPrefix(line, "import"))
+	if spec == "(" {
+		imf.inImportBlk = true
+		return
+	}
+
+	imf.addImport(spec)
+}
+
+// GetDependencies returns the imported packages of every directory,
+// with the directories of the packages that belong to a module in the repo.
+func (imf *ImportFinder) GetDependencies() reportgen.DependencyMap {
+	imf.mu.Lock()
+	defer imf.mu.Unlock()
+
+	deps := reportgen.DependencyMap{}
+	for dir, importPaths := range imf.imports {
+		for importPath := range importPaths {
+			deps[dir] = append(deps[dir], reportgen.Dependency{
+				ImportPath: importPath,
+				Dir:        imf.resolveImport(importPath),
+			})
+		}
+
+		sort.Slice(deps[dir], func(i, j int) bool {
+			return deps[dir][i].ImportPath < deps[dir][j].ImportPath
+		})
+	}
+
+	return deps
+}
+
+// addImport records an import spec like `"fmt"` or `rg "github.com/burwei/repoexplainer/reportgen"`.
+func (imf *ImportFinder) addImport(spec string) {
+	start := strings.Index(spec, `"`)
+	end := strings.LastIndex(spec, `"`)
+	if start == -1 || end <= start {
+		return
+	}
+
+	importPath := spec[start+1 : end]
+	imf.fileImports = append(imf.fileImports, importPath)
+	imf.recordImport(filepath.Dir(imf.filePath), importPath)
+}
+
+func (imf *ImportFinder) recordImport(dir, importPath string) {
+	if imf.imports[dir] == nil {
+		imf.imports[dir] = map[string]bool{}
+	}
+	imf.imports[dir][importPath] = true
+}
+
+// fileImportsAndModule returns the package paths imported by the current file,
+// or the module path declared by it if it's a go.mod file.
+func (imf *ImportFinder) fileImportsAndModule() ([]string, string) {
+	imf.mu.Lock()
+	defer imf.mu.Unlock()
+
+	return imf.fileImports, imf.fileModule
+}
+
+// restoreImports adds the imports and the module of a file found earlier, as if the file was processed again.
+func (imf *ImportFinder) restoreImports(filePath string, imports []string, module string) {
+	imf.mu.Lock()
+	defer imf.mu.Unlock()
+
+	dir := filepath.Dir(filePath)
+	for _, importPath := range imports {
+		imf.recordImport(dir, importPath)
+	}
+
+	if module != "" {
+		imf.modules[module] = dir
+	}
+}
+
+// resolveImport returns the directory of an imported package if it's in one of the modules found,
+// otherwise an empty string. The longest matching module path wins for nested modules.
+func (imf *ImportFinder) resolveImport(importPath string) string {
+	bestModule := ""
+	for modulePath := range imf.modules {
+		if (importPath == modulePath || strings.HasPrefix(importPath, modulePath+"/")) && len(modulePath) > len(bestModule) {
+			bestModule = modulePath
+		}
+	}
+
+	if bestModule == "" {
+		return ""
+	}
+
+	return filepath.Join(imf.modules[bestModule], filepath.FromSlash(strings.TrimPrefix(importPath, bestModule)))
+}
@@ -0,0 +1,102 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportFinderGetDependencies(t *testing.T) {
+	type file struct {
+		path    string
+		content string
+	}
+
+	testCases := []struct {
+		name        string
+		files       []file
+		expectedDep reportgen.DependencyMap
+	}{
+		{
+			name: "Single import",
+			files: []file{
+				{
+					path: "repo/main.go",
+					content: `
+package main
+
+import "fmt"
+`,
+				},
+			},
+			expectedDep: reportgen.DependencyMap{
+				"repo": {{ImportPath: "fmt"}},
+			},
+		},
+		{
+			name: "Import block with aliases resolved against go.mod",
+			files: []file{
+				{
+					path: "repo/app/app.go",
+					content: `
+package app
+
+import (
+	"fmt"
+
+	rg "example.com/repo/reportgen"
+	_ "example.com/repo/internal/plugin"
+)
+
+func Run() {}
+`,
+				},
+				{
+					path: "repo/go.mod",
+					content: `
+module example.com/repo
+
+go 1.21
+`,
+				},
+			},
+			expectedDep: reportgen.DependencyMap{
+				"repo/app": {
+					{ImportPath: "example.com/repo/internal/plugin", Dir: "repo/internal/plugin"},
+					{ImportPath: "example.com/repo/reportgen", Dir: "repo/reportgen"},
+					{ImportPath: "fmt"},
+				},
+			},
+		},
+		{
+			name: "Imports of other files are ignored",
+			files: []file{
+				{
+					path: "repo/README.md",
+					content: `
+import "fmt"
+`,
+				},
+			},
+			expectedDep: reportgen.DependencyMap{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			imf := NewImportFinder()
+			for _, f := range tc.files {
+				imf.SetFile(f.path)
+
+				// Simulating line-by-line reading
+				for _, line := range strings.Split(f.content, "\n") {
+					imf.FindComponent(line)
+				}
+			}
+
+			assert.Equal(t, tc.expectedDep, imf.GetDependencies())
+		})
+	}
+}
@@ -15,11 +15,13 @@ type InterfaceFinder struct {
 	currentInterface string
 	filePath         string
 	packageName      string
+	fileKeys         map[string]bool // fileKeys are the keys of the interfaces found in the current file
 }
 
 func NewInterfaceFinder() *InterfaceFinder {
 	return &InterfaceFinder{
 		components: reportgen.ComponentMap{},
+		fileKeys:   map[string]bool{},
 	}
 }
 
@@ -32,6 +34,7 @@ func (ifd *InterfaceFinder) SetFile(filePath string) {
 	ifd.filePath = filePath
 	ifd.packageName = ""
 	ifd.currentInterface = ""
+	ifd.fileKeys = map[string]bool{}
 }
 
 func (ifd *InterfaceFinder) FindComponent(line string) {
@@ -48,6 +51,7 @@ func (ifd *InterfaceFinder) FindComponent(line string) {
 		if interfaceName := extractInterfaceName(line); interfaceName != "" { // detailed check
 			compKey := getInterfaceCompKey(ifd.filePath, interfaceName)
 			ifd.currentInterface = interfaceName
+			ifd.fileKeys[compKey] = true
 
 			// In Go, there is only one interface with the same name in the same directory
 			// So, we can ignore the duplicate interface definition
@@ -104,6 +108,31 @@ func (ifd *InterfaceFinder) GetComponents() reportgen.ComponentMap {
 	return compCopy
 }
 
+// fileComponents returns the interfaces found in the current file.
+func (ifd *InterfaceFinder) fileComponents() reportgen.ComponentMap {
+	ifd.mu.Lock()
+	defer ifd.mu.Unlock()
+
+	comps := reportgen.ComponentMap{}
+	for key := range ifd.fileKeys {
+		comps[key] = ifd.components[key]
+	}
+
+	return comps
+}
+
+// restoreComponents adds the interfaces found in a file earlier, as if the file was processed again.
+func (ifd *InterfaceFinder) restoreComponents(comps reportgen.ComponentMap) {
+	ifd.mu.Lock()
+	defer ifd.mu.Unlock()
+
+	for key, comp := range comps {
+		if _, ok := ifd.components[key]; !ok {
+			ifd.components[key] = comp
+		}
+	}
+}
+
 func getInterfaceCompKey(filePath, interfaceName string) string {
 	return filepath.Dir(filePath) + ":" + interfaceName
 }
@@ -0,0 +1,130 @@
+package golang
+
+import (
+	"strings"
+	"sync"
+)
+
+// LineFinder finds the line ranges of the top-level declarations within Go files.
+// It must be given every line of the file, so it can count them.
+// Lines inside multi-line comments or strings should be given as empty lines.
+type LineFinder struct {
+	mu       sync.Mutex
+	ranges   map[string][2]int // ranges maps "filePath:Identifier" to the first and the last line
+	filePath string
+	lineNum  int
+	depth    int    // depth counts the open brackets, parentheses and braces
+	current  string // current is the identifier of the declaration being processed
+	start    int
+	idents   []string // idents are the identifiers whose ranges are found in the current file
+}
+
+func NewLineFinder() *LineFinder {
+	return &LineFinder{
+		ranges: map[string][2]int{},
+	}
+}
+
+// SetFile sets the directory path and file name for the current file being processed.
+// It's the beginning of a new file.
+func (lf *LineFinder) SetFile(filePath string) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	lf.filePath = filePath
+	lf.lineNum = 0
+	lf.depth = 0
+	lf.current = ""
+	lf.idents = nil
+}
+
+func (lf *LineFinder) FindComponent(line string) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	lf.lineNum++
+
+	// Top-level declarations start at the beginning of the line
+	if lf.depth == 0 && (strings.HasPrefix(line, "type ") || strings.HasPrefix(line, "func ")) {
+		if ident := declaredIdentifier(line); ident != "" {
+			lf.current = ident
+			lf.start = lf.lineNum
+		}
+	}
+
+	lf.depth += countBrackets(line)
+
+	// The declaration ends when all its brackets are closed
+	if lf.current != "" && lf.depth <= 0 {
+		lf.ranges[getDocKey(lf.filePath, lf.current)] = [2]int{lf.start, lf.lineNum}
+		lf.idents = append(lf.idents, lf.current)
+		lf.current = ""
+		lf.depth = 0
+	}
+}
+
+// GetRange returns the first and the last line of a declaration in a file, or zeros if it's not found.
+// Methods are identified by "ReceiverType.MethodName".
+func (lf *LineFinder) GetRange(filePath, ident string) (int, int) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	lineRange := lf.ranges[getDocKey(filePath, ident)]
+	return lineRange[0], lineRange[1]
+}
+
+// fileRanges returns the line ranges found in the current file by identifier.
+func (lf *LineFinder) fileRanges() map[string][2]int {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	ranges := map[string][2]int{}
+	for _, ident := range lf.idents {
+		ranges[ident] = lf.ranges[getDocKey(lf.filePath, ident)]
+	}
+
+	return ranges
+}
+
+// restoreRanges adds the line ranges found in a file earlier, as if the file was processed again.
+func (lf *LineFinder) restoreRanges(filePath string, ranges map[string][2]int) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for ident, lineRange := range ranges {
+		lf.ranges[getDocKey(filePath, ident)] = lineRange
+	}
+}
+
+// countBrackets returns the number of opened minus the number of closed brackets, parentheses and braces,
+// ignoring the ones in string and rune literals.
+func countBrackets(line string) int {
+	count := 0
+	var quote rune
+	escaped := false
+
+	for _, char := range line {
+		if quote != 0 {
+			switch {
+			case escaped:
+				escaped = false
+			case char == '\\' && quote != '`':
+				escaped = true
+			case char == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch char {
+		case '"', '\'', '`':
+			quote = char
+		case '(', '[', '{':
+			count++
+		case ')', ']', '}':
+			count--
+		}
+	}
+
+	return count
+}
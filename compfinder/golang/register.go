@@ -0,0 +1,32 @@
+package golang
+
+import (
+	"github.com/burwei/repoexplainer/compfinder/registry"
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+func init() {
+	registry.Register(registry.Finder{
+		Name:        "go",
+		Language:    "Go",
+		Description: "Structs, interfaces, funcs and methods with their doc comments, imports and table-driven test cases",
+		Default:     true,
+		Options: []registry.Option{
+			{Name: "tests", Default: "true", Description: "Scan the _test.go files"},
+			{Name: "unexported", Default: "true", Description: "Keep the unexported types, funcs and methods"},
+		},
+		New: func(options map[string]string) (reportgen.ComponentFinder, error) {
+			tests, err := registry.BoolOption(options, "tests")
+			if err != nil {
+				return nil, err
+			}
+
+			unexported, err := registry.BoolOption(options, "unexported")
+			if err != nil {
+				return nil, err
+			}
+
+			return NewComponentFinderWithOptions(Options{IncludeTests: tests, IncludeUnexported: unexported}), nil
+		},
+	})
+}
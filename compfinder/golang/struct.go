@@ -16,11 +16,13 @@ type StructFinder struct {
 	currentStruct string
 	filePath      string
 	packageName   string
+	fileKeys      map[string]bool // fileKeys are the keys of the structs found in the current file
 }
 
 func NewStructFinder() *StructFinder {
 	return &StructFinder{
 		components: reportgen.ComponentMap{},
+		fileKeys:   map[string]bool{},
 	}
 }
 
@@ -33,6 +35,7 @@ func (sf *StructFinder) SetFile(filePath string) {
 	sf.filePath = filePath
 	sf.packageName = ""
 	sf.currentStruct = ""
+	sf.fileKeys = map[string]bool{}
 }
 
 func (sf *StructFinder) FindComponent(line string) {
@@ -49,6 +52,7 @@ func (sf *StructFinder) FindComponent(line string) {
 		if structName := extractStructName(line); structName != "" { // detailed check
 			compKey := getStructCompKey(sf.filePath, structName)
 			sf.currentStruct = structName
+			sf.fileKeys[compKey] = true
 
 			// In Go, there is only one struct with the same name in the same directory
 			// So, we can ignore the duplicate struct definition
@@ -106,6 +110,31 @@ func (sf *StructFinder) GetComponents() reportgen.ComponentMap {
 	return compCopy
 }
 
+// fileComponents returns the structs found in the current file.
+func (sf *StructFinder) fileComponents() reportgen.ComponentMap {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	comps := reportgen.ComponentMap{}
+	for key := range sf.fileKeys {
+		comps[key] = sf.components[key]
+	}
+
+	return comps
+}
+
+// restoreComponents adds the structs found in a file earlier, as if the file was processed again.
+func (sf *StructFinder) restoreComponents(comps reportgen.ComponentMap) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	for key, comp := range comps {
+		if _, ok := sf.components[key]; !ok {
+			sf.components[key] = comp
+		}
+	}
+}
+
 func getStructCompKey(filePath, structName string) string {
 	return filepath.Dir(filePath) + ":" + structName
 }
@@ -0,0 +1,111 @@
+package golang
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	// testCaseField matches the name field of a test case in a table-driven test, e.g. `name: "Simple struct",`
+	testCaseField = regexp.MustCompile(`^\s*\{?\s*(name|desc|description|title|scenario)\s*:\s*"((?:[^"\\]|\\.)*)"`)
+	// subtestRun matches a subtest with a literal name, e.g. `t.Run("empty input", func(t *testing.T) {`
+	subtestRun = regexp.MustCompile(`\b[tbf]\.Run\("((?:[^"\\]|\\.)*)"`)
+)
+
+// TestCaseFinder finds the names of the test cases of the table-driven tests and the subtests
+// within Go test files.
+type TestCaseFinder struct {
+	mu       sync.Mutex
+	cases    map[string][]string // cases maps "filePath:FuncName" to the test case names
+	filePath string
+	current  string   // current is the name of the top-level func being processed
+	funcs    []string // funcs are the names of the funcs with test cases in the current file
+}
+
+func NewTestCaseFinder() *TestCaseFinder {
+	return &TestCaseFinder{
+		cases: map[string][]string{},
+	}
+}
+
+// SetFile sets the directory path and file name for the current file being processed.
+// It's the beginning of a new file.
+func (tcf *TestCaseFinder) SetFile(filePath string) {
+	tcf.mu.Lock()
+	defer tcf.mu.Unlock()
+
+	tcf.filePath = filePath
+	tcf.current = ""
+	tcf.funcs = nil
+}
+
+func (tcf *TestCaseFinder) FindComponent(line string) {
+	tcf.mu.Lock()
+	defer tcf.mu.Unlock()
+
+	if !strings.HasSuffix(tcf.filePath, "_test.go") {
+		return
+	}
+
+	if strings.HasPrefix(line, "func ") {
+		tcf.current = declaredIdentifier(line)
+		return
+	}
+
+	// The top-level func ends with a closing brace at the beginning of the line
+	if strings.HasPrefix(line, "}") {
+		tcf.current = ""
+		return
+	}
+
+	if tcf.current == "" {
+		return
+	}
+
+	name := ""
+	if match := testCaseField.FindStringSubmatch(line); match != nil {
+		name = match[2]
+	} else if match := subtestRun.FindStringSubmatch(line); match != nil {
+		name = match[1]
+	}
+
+	if name != "" {
+		key := getDocKey(tcf.filePath, tcf.current)
+		if len(tcf.cases[key]) == 0 {
+			tcf.funcs = append(tcf.funcs, tcf.current)
+		}
+		tcf.cases[key] = append(tcf.cases[key], name)
+	}
+}
+
+// GetTestCases returns the test case names of a test func in a file, in the order they are declared.
+func (tcf *TestCaseFinder) GetTestCases(filePath, funcName string) []string {
+	tcf.mu.Lock()
+	defer tcf.mu.Unlock()
+
+	return tcf.cases[getDocKey(filePath, funcName)]
+}
+
+// fileTestCases returns the test case names found in the current file by func name.
+func (tcf *TestCaseFinder) fileTestCases() map[string][]string {
+	tcf.mu.Lock()
+	defer tcf.mu.Unlock()
+
+	cases := map[string][]string{}
+	for _, funcName := range tcf.funcs {
+		cases[funcName] = tcf.cases[getDocKey(tcf.filePath, funcName)]
+	}
+
+	return cases
+}
+
+// restoreTestCases adds the test case names found in a file earlier, as if the file was processed again.
+func (tcf *TestCaseFinder) restoreTestCases(filePath string, cases map[string][]string) {
+	tcf.mu.Lock()
+	defer tcf.mu.Unlock()
+
+	for funcName, names := range cases {
+		tcf.cases[getDocKey(filePath, funcName)] = names
+	}
+}
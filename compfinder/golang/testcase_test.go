@@ -0,0 +1,98 @@
+package golang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestCaseFinderGetTestCases(t *testing.T) {
+	testCases := []struct {
+		name          string
+		filePath      string
+		fileContent   string
+		funcName      string
+		expectedCases []string
+	}{
+		{
+			name:     "Table-driven test",
+			filePath: "a/a_test.go",
+			fileContent: `
+package a
+
+func TestAdd(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected int
+	}{
+		{
+			name:     "Zero",
+			expected: 0,
+		},
+		{name: "Escaped \"quotes\"", expected: 1},
+	}
+}
+`,
+			funcName:      "TestAdd",
+			expectedCases: []string{"Zero", "Escaped \\\"quotes\\\""},
+		},
+		{
+			name:     "Subtests with literal names",
+			filePath: "a/a_test.go",
+			fileContent: `
+package a
+
+func BenchmarkAdd(b *testing.B) {
+	b.Run("small", func(b *testing.B) {})
+	b.Run(name, func(b *testing.B) {})
+}
+`,
+			funcName:      "BenchmarkAdd",
+			expectedCases: []string{"small"},
+		},
+		{
+			name:     "Only test files are searched",
+			filePath: "a/a.go",
+			fileContent: `
+package a
+
+func Run() {
+	t.Run("not a test", nil)
+}
+`,
+			funcName:      "Run",
+			expectedCases: nil,
+		},
+		{
+			name:     "Cases belong to the enclosing func",
+			filePath: "a/a_test.go",
+			fileContent: `
+package a
+
+func TestA(t *testing.T) {
+	t.Run("a", nil)
+}
+
+func TestB(t *testing.T) {
+	t.Run("b", nil)
+}
+`,
+			funcName:      "TestB",
+			expectedCases: []string{"b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tcf := NewTestCaseFinder()
+			tcf.SetFile(tc.filePath)
+
+			for _, line := range strings.Split(tc.fileContent, "\n") {
+				tcf.FindComponent(line)
+			}
+
+			assert.Equal(t, tc.expectedCases, tcf.GetTestCases(tc.filePath, tc.funcName))
+		})
+	}
+}
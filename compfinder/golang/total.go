@@ -1,25 +1,53 @@
 package golang
 
 import (
+	"sort"
 	"strings"
-	"sync"
+	"unicode"
 
 	"github.com/burwei/repoexplainer/reportgen"
 )
 
+// Options are the options of the ComponentFinder.
+type Options struct {
+	IncludeTests      bool // IncludeTests scans the _test.go files
+	IncludeUnexported bool // IncludeUnexported keeps the unexported types, funcs and methods
+}
+
+// DefaultOptions returns the options of a ComponentFinder finding everything.
+func DefaultOptions() Options {
+	return Options{IncludeTests: true, IncludeUnexported: true}
+}
+
 type ComponentFinder struct {
+	options            Options
+	skipFile           bool // skipFile is true if the current file is left out by the options
 	structFinder       *StructFinder
 	interfaceFinder    *InterfaceFinder
 	funcFinder         *FuncFinder
+	importFinder       *ImportFinder
+	docFinder          *DocFinder
+	lineFinder         *LineFinder
+	testCaseFinder     *TestCaseFinder
 	inMultiLineComment int
 	inMultiLineString  bool
 }
 
 func NewComponentFinder() *ComponentFinder {
+	return NewComponentFinderWithOptions(DefaultOptions())
+}
+
+// NewComponentFinderWithOptions creates a ComponentFinder finding what the options ask for.
+func NewComponentFinderWithOptions(options Options) *ComponentFinder {
 	return &ComponentFinder{
+		options:         options,
 		structFinder:    NewStructFinder(),
 		interfaceFinder: NewInterfaceFinder(),
 		funcFinder:      NewFuncFinder(),
+		importFinder:    NewImportFinder(),
+		docFinder:       NewDocFinder(),
+		lineFinder:      NewLineFinder(),
+		testCaseFinder:  NewTestCaseFinder(),
 	}
 }
 
@@ -27,56 +55,75 @@ func (cf *ComponentFinder) SetFile(filePath string) {
 	cf.structFinder.SetFile(filePath)
 	cf.interfaceFinder.SetFile(filePath)
 	cf.funcFinder.SetFile(filePath)
+	cf.importFinder.SetFile(filePath)
+	cf.docFinder.SetFile(filePath)
+	cf.lineFinder.SetFile(filePath)
+	cf.testCaseFinder.SetFile(filePath)
 
 	cf.inMultiLineComment = 0
 	cf.inMultiLineString = false
+	cf.skipFile = !cf.options.IncludeTests && strings.HasSuffix(filePath, "_test.go")
 }
 
 func (cf *ComponentFinder) FindComponent(line string) {
+	if cf.skipFile {
+		return
+	}
+
+	// The doc comments are needed before the comments are removed
+	if cf.inMultiLineComment == 0 && !cf.inMultiLineString {
+		cf.docFinder.FindComponent(line)
+	}
+
 	// remove inline comments if any
 	line = strings.Split(line, "//")[0]
 	if line == "" {
+		cf.lineFinder.FindComponent(line)
 		return
 	}
 
 	cf.checkMultilineCommentOrString(line)
 	if cf.inMultiLineComment != 0 || cf.inMultiLineString {
+		// The line finder counts every line, but the content doesn't matter
+		cf.lineFinder.FindComponent("")
 		return
 	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(3)
-
-	go func() {
-		cf.structFinder.FindComponent(line)
-		wg.Done()
-	}()
-
-	go func() {
-		cf.interfaceFinder.FindComponent(line)
-		wg.Done()
-	}()
-
-	go func() {
-		cf.funcFinder.FindComponent(line)
-		wg.Done()
-	}()
-
-	wg.Wait()
+	// The files are scanned in parallel by the ReportGenerator, the lines of a file are scanned in order
+	cf.structFinder.FindComponent(line)
+	cf.interfaceFinder.FindComponent(line)
+	cf.funcFinder.FindComponent(line)
+	cf.importFinder.FindComponent(line)
+	cf.lineFinder.FindComponent(line)
+	cf.testCaseFinder.FindComponent(line)
 }
 
 func (cf *ComponentFinder) GetComponents() reportgen.ComponentMap {
 	components := reportgen.ComponentMap{}
 
 	for key, val := range cf.structFinder.GetComponents() {
+		val.Doc = cf.docFinder.GetDoc(val.File, val.Name)
+		val.Line, val.EndLine = cf.lineFinder.GetRange(val.File, val.Name)
 		components[key] = val
 	}
 
 	for key, val := range cf.interfaceFinder.GetComponents() {
+		val.Doc = cf.docFinder.GetDoc(val.File, val.Name)
+		val.Line, val.EndLine = cf.lineFinder.GetRange(val.File, val.Name)
 		components[key] = val
 	}
 
+	withMethods := map[string]bool{} // withMethods are the keys of the components given methods
 	for key, val := range cf.funcFinder.GetComponents() {
+		// The key of a func is "path/to/dir:ReceiverType.FuncName"
+		docIdent := identifierPrefix(val.Name)
+		receiver, _ := splitFuncCompKey(key)
+		if receiver != "" {
+			docIdent = receiver + "." + docIdent
+		}
+		val.Doc = cf.docFinder.GetDoc(val.File, docIdent)
+		val.Line, val.EndLine = cf.lineFinder.GetRange(val.File, docIdent)
+		val.TestCases = cf.testCaseFinder.GetTestCases(val.File, docIdent)
 		structCompKey, dirPathBasedCompKey := cf.funcFinder.ConvertFuncCompKey(key)
 		if structCompKey == "" {
 			components[dirPathBasedCompKey] = val
@@ -84,26 +131,106 @@ func (cf *ComponentFinder) GetComponents() reportgen.ComponentMap {
 
 		if structComp, ok := components[structCompKey]; ok {
 			// The function is a method of a struct, add it to the struct's methods
+			methodLocations := map[string]reportgen.Location{}
+			for name, location := range structComp.MethodLocations {
+				methodLocations[name] = location
+			}
+			if val.Line != 0 {
+				methodLocations[identifierPrefix(val.Name)] = reportgen.Location{File: val.File, Line: val.Line, EndLine: val.EndLine}
+			}
+			if len(methodLocations) == 0 {
+				methodLocations = nil
+			}
+
 			components[structCompKey] = reportgen.Component{
-				File:    structComp.File,
-				Name:    structComp.Name,
-				Package: structComp.Package,
-				Type:    structComp.Type,
-				Fields:  structComp.Fields,
-				Methods: append(structComp.Methods, val.Name),
+				File:            structComp.File,
+				Name:            structComp.Name,
+				Package:         structComp.Package,
+				Type:            structComp.Type,
+				Fields:          structComp.Fields,
+				Methods:         append(structComp.Methods, val.Name),
+				Doc:             structComp.Doc,
+				Line:            structComp.Line,
+				EndLine:         structComp.EndLine,
+				MethodLocations: methodLocations,
 			}
+			withMethods[structCompKey] = true
 		} else {
 			// The function has a receiver, but the struct is not found
 			// Usually this shouldn't happen, because this GetComponents() will be called
 			// after all files are processed, and the struct should be found by then.
-			// But, just in case, we add the function to the components map with the dirPathBasedCompKey
+			// But, just in case, we add the function to the components map with the dirPathBasedCompKey.
+			// Methods of other types, like "type Chain []Backend", are named after their receiver type.
+			if receiver != "" {
+				val.Name = receiver + "." + val.Name
+			}
 			components[dirPathBasedCompKey] = val
 		}
 	}
 
+	for key := range withMethods {
+		sortMethods(components[key])
+	}
+
+	if !cf.options.IncludeUnexported {
+		removeUnexported(components)
+	}
+
 	return components
 }
 
+// sortMethods sorts the methods of a struct in the order of their definitions, by file, line and name,
+// since the funcs are found in no particular order.
+func sortMethods(comp reportgen.Component) {
+	sort.SliceStable(comp.Methods, func(i, j int) bool {
+		a := comp.MethodLocations[identifierPrefix(comp.Methods[i])]
+		b := comp.MethodLocations[identifierPrefix(comp.Methods[j])]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return comp.Methods[i] < comp.Methods[j]
+	})
+}
+
+// removeUnexported removes the unexported components and methods.
+func removeUnexported(components reportgen.ComponentMap) {
+	for key, comp := range components {
+		if !isExported(comp.Name) {
+			delete(components, key)
+			continue
+		}
+
+		var methods []string
+		for _, method := range comp.Methods {
+			if isExported(method) {
+				methods = append(methods, method)
+			}
+		}
+		comp.Methods = methods
+
+		for name := range comp.MethodLocations {
+			if !isExported(name) {
+				delete(comp.MethodLocations, name)
+			}
+		}
+		components[key] = comp
+	}
+}
+
+// isExported reports whether a name, or a signature starting with a name, is exported.
+func isExported(name string) bool {
+	return name != "" && unicode.IsUpper([]rune(name)[0])
+}
+
+// GetDependencies returns the packages imported by the Go files of every directory.
+func (cf *ComponentFinder) GetDependencies() reportgen.DependencyMap {
+	return cf.importFinder.GetDependencies()
+}
+
 func (cf *ComponentFinder) checkMultilineCommentOrString(line string) {
 	if strings.Contains(line, "/*") {
 		cf.inMultiLineComment++
@@ -114,28 +241,34 @@ func (cf *ComponentFinder) checkMultilineCommentOrString(line string) {
 	}
 
 	// The multiline string detection logic might not be perfect, but it's good enough most of the time.
-	// We haven't considered (1) backticks inside single line comments, (2) escape characters
+	// We haven't considered backticks inside single line comments.
 	if strings.Contains(line, "`") {
-		var insideDoubleQuotes, insideSingleQuotes bool
+		var quote rune
+		escaped := false
 
 		for _, char := range line {
-			switch char {
-			case '"':
-				insideDoubleQuotes = !insideDoubleQuotes
-			case '\'':
-				insideSingleQuotes = !insideSingleQuotes
-			case '`':
-				if insideDoubleQuotes || insideSingleQuotes {
-					// backtick inside quotes, so it's not the start or end of a multi-line string
-					return // Exit early since we found a backtick inside quotes
-				} else {
-					// Found a backtick not inside quotes, toggle inMultiLineString and exit
-					cf.inMultiLineString = !cf.inMultiLineString
-					return
+			switch {
+			case cf.inMultiLineString:
+				// Only a backtick ends a raw string
+				if char == '`' {
+					cf.inMultiLineString = false
 				}
+			case quote != 0:
+				// backticks inside quotes are not the start or end of a multi-line string
+				switch {
+				case escaped:
+					escaped = false
+				case char == '\\':
+					escaped = true
+				case char == quote:
+					quote = 0
+				}
+			case char == '"' || char == '\'':
+				quote = char
+			case char == '`':
+				// A raw string starts here, it's multi-line unless it ends on the same line
+				cf.inMultiLineString = true
 			}
 		}
-
-		// backtick inside quotes, so it's not the start or end of a multi-line string
 	}
 }
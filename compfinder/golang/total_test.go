@@ -32,6 +32,8 @@ type SimpleStruct struct {
 					Name:    "SimpleStruct",
 					Type:    TypeStruct,
 					Fields:  []string{"ID int"},
+					Line:    4,
+					EndLine: 6,
 				},
 			},
 		},
@@ -57,6 +59,11 @@ func (s *StructWithMethods) GetName() string {
 					Type:    TypeStruct,
 					Fields:  []string{"Name string"},
 					Methods: []string{"GetName() string"},
+					Line:    4,
+					EndLine: 6,
+					MethodLocations: map[string]reportgen.Location{
+						"GetName": {File: "methods/methods.go", Line: 8, EndLine: 10},
+					},
 				},
 			},
 		},
@@ -85,6 +92,8 @@ func (s *Struct) GetName() string {
 					Name:    "Interface",
 					Type:    TypeInterface,
 					Methods: []string{"GetName() string"},
+					Line:    4,
+					EndLine: 6,
 				},
 				"implementation:Struct": reportgen.Component{
 					File:    "implementation/implementation.go",
@@ -93,6 +102,11 @@ func (s *Struct) GetName() string {
 					Type:    TypeStruct,
 					Fields:  []string{"Name string"},
 					Methods: []string{"GetName() string"},
+					Line:    8,
+					EndLine: 10,
+					MethodLocations: map[string]reportgen.Location{
+						"GetName": {File: "implementation/implementation.go", Line: 12, EndLine: 14},
+					},
 				},
 			},
 		},
@@ -125,6 +139,8 @@ func Add(a, b int) int {
 					Name:    "Interface",
 					Type:    TypeInterface,
 					Methods: []string{"GetName() string"},
+					Line:    4,
+					EndLine: 6,
 				},
 				"allthree:Struct": reportgen.Component{
 					File:    "allthree/allthree.go",
@@ -133,12 +149,94 @@ func Add(a, b int) int {
 					Type:    TypeStruct,
 					Fields:  []string{"Name string"},
 					Methods: []string{"GetName() string"},
+					Line:    8,
+					EndLine: 10,
+					MethodLocations: map[string]reportgen.Location{
+						"GetName": {File: "allthree/allthree.go", Line: 12, EndLine: 14},
+					},
 				},
 				"allthree:Add": reportgen.Component{
 					File:    "allthree/allthree.go",
 					Package: "allthree",
 					Name:    "Add(a, b int) int",
 					Type:    TypeFunc,
+					Line:    16,
+					EndLine: 18,
+				},
+			},
+		},
+		{
+			name:     "Doc comments of struct and function",
+			filePath: "docs/docs.go",
+			fileContent: `
+// Package docs is documented.
+package docs
+
+// Server serves requests.
+// It's safe for concurrent use.
+type Server struct {
+    Addr string // the address to listen on
+}
+
+// Start starts the server.
+func (s *Server) Start() error {
+    return nil
+}
+
+// NewServer creates a Server.
+
+func NewServer() *Server {
+	return &Server{}
+}
+`,
+			expectedComp: reportgen.ComponentMap{
+				"docs:Server": reportgen.Component{
+					File:    "docs/docs.go",
+					Package: "docs",
+					Name:    "Server",
+					Type:    TypeStruct,
+					Fields:  []string{"Addr string"},
+					Methods: []string{"Start() error"},
+					Doc:     "Server serves requests.\nIt's safe for concurrent use.",
+					Line:    7,
+					EndLine: 9,
+					MethodLocations: map[string]reportgen.Location{
+						"Start": {File: "docs/docs.go", Line: 12, EndLine: 14},
+					},
+				},
+				"docs:NewServer": reportgen.Component{
+					File:    "docs/docs.go",
+					Package: "docs",
+					Name:    "NewServer() *Server",
+					Type:    TypeFunc,
+					Line:    18,
+					EndLine: 20,
+				},
+			},
+		},
+		{
+			name:     "Raw strings on one line and across lines",
+			filePath: "raw/raw.go",
+			fileContent: "\n" +
+				"package raw\n" +
+				"\n" +
+				"var quote = strings.Trim(s, `\"`)\n" +
+				"\n" +
+				"var usage = `\n" +
+				"func NotAFunc() {}\n" +
+				"`\n" +
+				"\n" +
+				"func Trim(s string) string {\n" +
+				"	return strings.Trim(s, \"`\")\n" +
+				"}\n",
+			expectedComp: reportgen.ComponentMap{
+				"raw:Trim": reportgen.Component{
+					File:    "raw/raw.go",
+					Package: "raw",
+					Name:    "Trim(s string) string",
+					Type:    TypeFunc,
+					Line:    10,
+					EndLine: 12,
 				},
 			},
 		},
@@ -161,3 +259,127 @@ func Add(a, b int) int {
 		})
 	}
 }
+
+func TestComponentFinderSameTypeInPackages(t *testing.T) {
+	files := map[string]string{
+		"golang/finder.go": "package golang\n\ntype ComponentFinder struct {\n}\n\nfunc (cf *ComponentFinder) SetFile(filePath string) {}\n",
+		"python/finder.go": "package python\n\ntype ComponentFinder struct {\n}\n\nfunc (cf *ComponentFinder) FindComponent(line string) {}\n",
+		"cmd/a/main.go":    "package main\n\nfunc main() {}\n",
+		"cmd/b/main.go":    "package main\n\nfunc main() {}\n",
+		"clip/clip.go":     "package clip\n\ntype Chain []string\n\nfunc (c Chain) Copy(text string) {}\n\nfunc Copy(text string) {}\n",
+	}
+
+	cf := NewComponentFinder()
+	for _, filePath := range []string{"golang/finder.go", "python/finder.go", "cmd/a/main.go", "cmd/b/main.go", "clip/clip.go"} {
+		cf.SetFile(filePath)
+		for _, line := range strings.Split(files[filePath], "\n") {
+			cf.FindComponent(line)
+		}
+	}
+
+	components := cf.GetComponents()
+
+	assert.Equal(t, []string{"SetFile(filePath string)"}, components["golang:ComponentFinder"].Methods)
+	assert.Equal(t, []string{"FindComponent(line string)"}, components["python:ComponentFinder"].Methods)
+	assert.Equal(t, "cmd/a/main.go", components["cmd/a:main"].File)
+	assert.Equal(t, "cmd/b/main.go", components["cmd/b:main"].File)
+	assert.Equal(t, "Chain.Copy(text string)", components["clip:Chain.Copy"].Name)
+	assert.Equal(t, "Copy(text string)", components["clip:Copy"].Name)
+}
+
+func TestComponentFinderMethodOrder(t *testing.T) {
+	files := map[string]string{
+		"store/store.go":  "package store\n\ntype Store struct {\n}\n\nfunc (s *Store) Set(key string) {\n}\n\nfunc (s *Store) Get(key string) {\n}\n",
+		"store/delete.go": "package store\n\nfunc (s *Store) Delete(key string) {\n}\n\nfunc (s *Store) Clear() {\n}\n",
+	}
+
+	// The funcs are found in no particular order, the methods are in the order of their definitions
+	for i := 0; i < 10; i++ {
+		cf := NewComponentFinder()
+		for _, filePath := range []string{"store/delete.go", "store/store.go"} {
+			cf.SetFile(filePath)
+			for _, line := range strings.Split(files[filePath], "\n") {
+				cf.FindComponent(line)
+			}
+		}
+
+		assert.Equal(t, []string{"Delete(key string)", "Clear()", "Set(key string)", "Get(key string)"}, cf.GetComponents()["store:Store"].Methods)
+	}
+}
+
+func TestComponentFinderOptions(t *testing.T) {
+	files := map[string]string{
+		"store/store.go": `
+package store
+
+type Store struct {
+    items map[string]string
+}
+
+func (s *Store) Get(key string) string {
+    return s.items[key]
+}
+
+func (s *Store) lock() {
+}
+
+type entry struct {
+    key string
+}
+`,
+		"store/store_test.go": `
+package store
+
+func TestGet(t *testing.T) {
+}
+`,
+	}
+
+	testCases := []struct {
+		name       string
+		options    Options
+		expKeys    []string
+		expMethods []string // expMethods are the methods of Store
+	}{
+		{
+			name:       "Default options",
+			options:    DefaultOptions(),
+			expKeys:    []string{"store:Store", "store:entry", "store:TestGet"},
+			expMethods: []string{"Get(key string) string", "lock()"},
+		},
+		{
+			name:       "Without tests",
+			options:    Options{IncludeUnexported: true},
+			expKeys:    []string{"store:Store", "store:entry"},
+			expMethods: []string{"Get(key string) string", "lock()"},
+		},
+		{
+			name:       "Without unexported",
+			options:    Options{IncludeTests: true},
+			expKeys:    []string{"store:Store", "store:TestGet"},
+			expMethods: []string{"Get(key string) string"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cf := NewComponentFinderWithOptions(tc.options)
+			for _, filePath := range []string{"store/store.go", "store/store_test.go"} {
+				cf.SetFile(filePath)
+				for _, line := range strings.Split(files[filePath], "\n") {
+					cf.FindComponent(line)
+				}
+			}
+
+			components := cf.GetComponents()
+
+			keys := []string{}
+			for key := range components {
+				keys = append(keys, key)
+			}
+			assert.ElementsMatch(t, tc.expKeys, keys)
+			assert.ElementsMatch(t, tc.expMethods, components["store:Store"].Methods)
+			assert.Len(t, components["store:Store"].MethodLocations, len(tc.expMethods))
+		})
+	}
+}
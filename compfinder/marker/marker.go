@@ -0,0 +1,221 @@
+package marker
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// cacheVersion identifies the markers found by the MarkerFinder in the cache.
+// Change it whenever a change of the finder makes it find other markers in the same file.
+const cacheVersion = "marker/1"
+
+var (
+	// noteMarker matches notes like "TODO: text", "FIXME(bob): text" or "HACK text" at the start of a comment
+	noteMarker = regexp.MustCompile(`^(TODO|FIXME|HACK)\b(?:\([^)]*\))?:?\s*(.*)$`)
+	// deprecatedMarker matches the "Deprecated: text" paragraph of a doc comment
+	deprecatedMarker = regexp.MustCompile(`^Deprecated:\s*(.*)$`)
+)
+
+// MarkerFinder finds the TODO, FIXME and HACK notes and the "Deprecated:" paragraphs in the comments
+// of the files of every language with a known comment syntax.
+// It finds no components, the markers are returned by GetMarkers.
+type MarkerFinder struct {
+	mu             sync.Mutex
+	markers        []reportgen.Marker
+	filePath       string
+	syntax         reportgen.CommentSyntax
+	lineNum        int
+	inBlockComment bool
+	inRawString    bool  // inRawString is true inside a backquoted string spanning several lines
+	fileStart      int   // fileStart is the index of the first marker of the current file
+	pending        []int // pending are the indexes of the markers in the comment block above the current line
+}
+
+func NewMarkerFinder() *MarkerFinder {
+	return &MarkerFinder{}
+}
+
+// SetFile sets the directory path and file name for the current file being processed.
+// It's the beginning of a new file.
+func (mf *MarkerFinder) SetFile(filePath string) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	mf.filePath = filePath
+	mf.syntax = reportgen.CommentSyntaxOf(filePath)
+	mf.lineNum = 0
+	mf.inBlockComment = false
+	mf.inRawString = false
+	mf.pending = nil
+	mf.fileStart = len(mf.markers)
+}
+
+func (mf *MarkerFinder) FindComponent(line string) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	mf.lineNum++
+	if mf.syntax == (reportgen.CommentSyntax{}) {
+		return
+	}
+
+	// A blank line inside a block comment is still part of the comment
+	if mf.inBlockComment && strings.TrimSpace(line) == "" {
+		return
+	}
+
+	comments, hasCode := mf.splitComments(line)
+
+	// The markers in a comment block are followed by the code right below it, unless a blank line is in between
+	if hasCode {
+		for _, i := range mf.pending {
+			mf.markers[i].NextCodeLine = mf.lineNum
+		}
+		mf.pending = nil
+	} else if len(comments) == 0 {
+		mf.pending = nil
+	}
+
+	for _, comment := range comments {
+		kind, text, ok := parseMarker(comment)
+		if !ok {
+			continue
+		}
+
+		mf.markers = append(mf.markers, reportgen.Marker{
+			Kind: kind,
+			Text: text,
+			File: mf.filePath,
+			Line: mf.lineNum,
+		})
+		if !hasCode {
+			mf.pending = append(mf.pending, len(mf.markers)-1)
+		}
+	}
+}
+
+// GetComponents returns no components, the MarkerFinder only finds markers.
+func (mf *MarkerFinder) GetComponents() reportgen.ComponentMap {
+	return reportgen.ComponentMap{}
+}
+
+// GetMarkers returns the markers in the order they are found.
+func (mf *MarkerFinder) GetMarkers() []reportgen.Marker {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	return append([]reportgen.Marker{}, mf.markers...)
+}
+
+// Version identifies the markers found by the MarkerFinder in the cache.
+func (mf *MarkerFinder) Version() string {
+	return cacheVersion
+}
+
+// FileResult returns the markers found in the current file.
+func (mf *MarkerFinder) FileResult() ([]byte, error) {
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	return json.Marshal(mf.markers[mf.fileStart:])
+}
+
+// RestoreFileResult adds the markers found in a file by an earlier run, as if the file was processed again.
+func (mf *MarkerFinder) RestoreFileResult(filePath string, result []byte) error {
+	var markers []reportgen.Marker
+	if err := json.Unmarshal(result, &markers); err != nil {
+		return err
+	}
+
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	mf.markers = append(mf.markers, markers...)
+	mf.fileStart = len(mf.markers)
+
+	return nil
+}
+
+// splitComments returns the texts of the comments on a line and whether the line has any code,
+// ignoring the comment syntax inside string literals.
+func (mf *MarkerFinder) splitComments(line string) ([]string, bool) {
+	comments := []string{}
+	hasCode := false
+	var quote byte
+	if mf.inRawString {
+		quote = '`'
+		hasCode = true
+	}
+	escaped := false
+
+	for i := 0; i < len(line); i++ {
+		rest := line[i:]
+
+		if mf.inBlockComment {
+			end := strings.Index(rest, mf.syntax.BlockEnd)
+			if end == -1 {
+				comments = append(comments, rest)
+				break
+			}
+
+			comments = append(comments, rest[:end])
+			mf.inBlockComment = false
+			i += end + len(mf.syntax.BlockEnd) - 1
+			continue
+		}
+
+		if quote != 0 {
+			switch {
+			case quote == '`':
+				if line[i] == '`' {
+					quote = 0
+				}
+			case escaped:
+				escaped = false
+			case line[i] == '\\':
+				escaped = true
+			case line[i] == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case mf.syntax.Line != "" && strings.HasPrefix(rest, mf.syntax.Line):
+			mf.inRawString = false
+			return append(comments, rest[len(mf.syntax.Line):]), hasCode
+		case mf.syntax.BlockStart != "" && strings.HasPrefix(rest, mf.syntax.BlockStart):
+			mf.inBlockComment = true
+			i += len(mf.syntax.BlockStart) - 1
+		case line[i] == '"' || line[i] == '\'' || line[i] == '`':
+			quote = line[i]
+			hasCode = true
+		case line[i] != ' ' && line[i] != '\t':
+			hasCode = true
+		}
+	}
+
+	mf.inRawString = quote == '`'
+
+	return comments, hasCode
+}
+
+// parseMarker returns the kind and the text of the marker at the start of a comment.
+func parseMarker(comment string) (string, string, bool) {
+	// Lines of block comments often start with an asterisk
+	comment = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(comment), "*"))
+
+	if match := noteMarker.FindStringSubmatch(comment); match != nil {
+		return match[1], strings.TrimSpace(match[2]), true
+	}
+
+	if match := deprecatedMarker.FindStringSubmatch(comment); match != nil {
+		return reportgen.MarkerDeprecated, strings.TrimSpace(match[1]), true
+	}
+
+	return "", "", false
+}
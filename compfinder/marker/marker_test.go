@@ -0,0 +1,143 @@
+package marker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkerFinderGetMarkers(t *testing.T) {
+	testCases := []struct {
+		name            string
+		filePath        string
+		fileContent     string
+		expectedMarkers []reportgen.Marker
+	}{
+		{
+			name:     "Notes in line comments",
+			filePath: "a/a.go",
+			fileContent: `
+package a
+
+func Run() {
+	// TODO(bob): handle errors
+	x := 1 // FIXME off by one
+	s := "// TODO: not a comment"
+	// HACK: works around a bug
+}
+`,
+			expectedMarkers: []reportgen.Marker{
+				{Kind: reportgen.MarkerTodo, Text: "handle errors", File: "a/a.go", Line: 5, NextCodeLine: 6},
+				{Kind: reportgen.MarkerFixme, Text: "off by one", File: "a/a.go", Line: 6},
+				{Kind: reportgen.MarkerHack, Text: "works around a bug", File: "a/a.go", Line: 8, NextCodeLine: 9},
+			},
+		},
+		{
+			name:     "Deprecated paragraph of a doc comment",
+			filePath: "a/a.go",
+			fileContent: `
+package a
+
+// Old does things.
+//
+// Deprecated: Use New instead.
+func Old() {}
+
+// Deprecated: not followed by a declaration
+
+func New() {}
+`,
+			expectedMarkers: []reportgen.Marker{
+				{Kind: reportgen.MarkerDeprecated, Text: "Use New instead.", File: "a/a.go", Line: 6, NextCodeLine: 7},
+				{Kind: reportgen.MarkerDeprecated, Text: "not followed by a declaration", File: "a/a.go", Line: 9},
+			},
+		},
+		{
+			name:     "Block comments",
+			filePath: "a/a.c",
+			fileContent: `
+/*
+ * TODO: free the buffer
+
+ */
+int main() { /* FIXME: return code */ return 0; }
+`,
+			expectedMarkers: []reportgen.Marker{
+				{Kind: reportgen.MarkerTodo, Text: "free the buffer", File: "a/a.c", Line: 3, NextCodeLine: 6},
+				{Kind: reportgen.MarkerFixme, Text: "return code", File: "a/a.c", Line: 6},
+			},
+		},
+		{
+			name:     "Python comments",
+			filePath: "a/a.py",
+			fileContent: `
+# TODO: type hints
+def run():
+    return "# TODO: not a comment"
+`,
+			expectedMarkers: []reportgen.Marker{
+				{Kind: reportgen.MarkerTodo, Text: "type hints", File: "a/a.py", Line: 2, NextCodeLine: 3},
+			},
+		},
+		{
+			name:     "Raw strings across lines",
+			filePath: "a/a.go",
+			fileContent: "package a\n" +
+				"\n" +
+				"var s = `\n" +
+				"// TODO: not a comment\n" +
+				"` // TODO: a comment\n",
+			expectedMarkers: []reportgen.Marker{
+				{Kind: reportgen.MarkerTodo, Text: "a comment", File: "a/a.go", Line: 5},
+			},
+		},
+		{
+			name:            "Files without a known comment syntax",
+			filePath:        "a/notes.txt",
+			fileContent:     "TODO: nothing\n// TODO: nothing either\n",
+			expectedMarkers: []reportgen.Marker{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mf := NewMarkerFinder()
+			mf.SetFile(tc.filePath)
+
+			for _, line := range strings.Split(tc.fileContent, "\n") {
+				mf.FindComponent(line)
+			}
+
+			assert.Equal(t, tc.expectedMarkers, mf.GetMarkers())
+		})
+	}
+}
+
+func TestMarkerFinderRestoreFileResult(t *testing.T) {
+	files := []struct {
+		path    string
+		content string
+	}{
+		{path: "a/a.go", content: "// TODO: first\nfunc A() {}\n"},
+		{path: "a/b.go", content: "package a\n"},
+		{path: "b/b.py", content: "x = 1  # FIXME: second\n"},
+	}
+
+	scanned := NewMarkerFinder()
+	restored := NewMarkerFinder()
+	for _, file := range files {
+		scanned.SetFile(file.path)
+		for _, line := range strings.Split(file.content, "\n") {
+			scanned.FindComponent(line)
+		}
+
+		result, err := scanned.FileResult()
+		assert.NoError(t, err)
+		assert.NoError(t, restored.RestoreFileResult(file.path, result))
+	}
+
+	assert.Len(t, restored.GetMarkers(), 2)
+	assert.Equal(t, scanned.GetMarkers(), restored.GetMarkers())
+}
@@ -0,0 +1,18 @@
+package marker
+
+import (
+	"github.com/burwei/repoexplainer/compfinder/registry"
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+func init() {
+	registry.Register(registry.Finder{
+		Name:        "markers",
+		Language:    "any",
+		Description: "TODO, FIXME and HACK notes and Deprecated paragraphs in the comments",
+		Default:     true,
+		New: func(options map[string]string) (reportgen.ComponentFinder, error) {
+			return NewMarkerFinder(), nil
+		},
+	})
+}
@@ -0,0 +1,427 @@
+// Package plugin runs component finders written in any language as external processes,
+// so a team can add a finder for its own languages and DSLs without changing repoexplainer.
+//
+// A plugin is started once, on the first file it's given, and reads one JSON Request per line from stdin.
+// It must reply to every request with one JSON Response per line on stdout, in the order of the requests,
+// and exit when stdin is closed. Anything written to stderr is ignored.
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// cacheVersion identifies the components found by a plugin in the cache, together with its name and command.
+// Change it whenever the protocol changes.
+const cacheVersion = "plugin/1"
+
+// DefaultTimeout is how long a plugin may take to reply to a request before it's stopped.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultShutdownTimeout is how long a plugin may take to exit once its input is closed before it's killed.
+const DefaultShutdownTimeout = 5 * time.Second
+
+// Config declares a plugin.
+type Config struct {
+	Name    string        // Name identifies the plugin in the warnings, the base name of the executable if empty
+	Command []string      // Command is the executable and its arguments
+	Files   []string      // Files are glob patterns of the names of the files given to the plugin, e.g. "*.dsl"
+	Timeout time.Duration // Timeout is how long the plugin may take to reply, 0 means DefaultTimeout
+
+	// ShutdownTimeout is how long the plugin may take to exit once its input is closed, 0 means DefaultShutdownTimeout
+	ShutdownTimeout time.Duration
+}
+
+// ParseFlag parses a plugin declared like "*.dsl,*.rules=dsl-finder --strict": the comma separated
+// file patterns, an equal sign and the command, split at the spaces.
+func ParseFlag(value string) (Config, error) {
+	patterns, command, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(patterns) == "" || len(strings.Fields(command)) == 0 {
+		return Config{}, fmt.Errorf("invalid plugin %q, expected PATTERNS=COMMAND", value)
+	}
+
+	config := Config{Command: strings.Fields(command)}
+	for _, pattern := range strings.Split(patterns, ",") {
+		config.Files = append(config.Files, strings.TrimSpace(pattern))
+	}
+
+	return config, config.Validate()
+}
+
+// Validate checks whether the plugin is declared with a command and valid file patterns.
+func (c Config) Validate() error {
+	if len(c.Command) == 0 {
+		return fmt.Errorf("plugin %s has no command", c.name())
+	}
+
+	if len(c.Files) == 0 {
+		return fmt.Errorf("plugin %s has no file patterns", c.name())
+	}
+
+	for _, pattern := range c.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("plugin %s has an invalid file pattern %q: %s", c.name(), pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func (c Config) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if len(c.Command) > 0 {
+		return filepath.Base(c.Command[0])
+	}
+
+	return "plugin"
+}
+
+// matches reports whether the file is given to the plugin.
+func (c Config) matches(filePath string) bool {
+	for _, pattern := range c.Files {
+		if ok, _ := filepath.Match(pattern, filepath.Base(filePath)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Request is what a plugin is given for every file.
+type Request struct {
+	File    string `json:"file"`    // File is the full path to the file
+	Content string `json:"content"` // Content is the content of the file
+}
+
+// Response is what a plugin replies for every file.
+type Response struct {
+	// Components are the components found in the file. The file of a component is the requested file if empty.
+	Components []reportgen.Component `json:"components"`
+
+	// Error is set if the plugin failed to process the file. The file is skipped with a warning.
+	Error string `json:"error,omitempty"`
+}
+
+// Finder is a ComponentFinder running a plugin. The lines of a file are collected and sent to the plugin
+// in one request when the next file is set or the components are asked for.
+// The failures of the plugin are reported as warnings and only skip the files concerned.
+type Finder struct {
+	mu             sync.Mutex
+	config         Config
+	components     reportgen.ComponentMap
+	warnings       []reportgen.Warning
+	filePath       string
+	lines          []string
+	pending        bool                  // pending is true if the lines of the current file haven't been sent yet
+	fileComponents []reportgen.Component // fileComponents are the components found in the current file
+	failed         bool                  // failed is true if the plugin failed on the current file
+	process        *process              // process is the running plugin, nil if not started yet
+}
+
+// NewFinder creates a Finder running the plugin. The plugin is only started when it's given a file.
+func NewFinder(config Config) *Finder {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	return &Finder{
+		config:     config,
+		components: reportgen.ComponentMap{},
+	}
+}
+
+// SetFile sends the previous file to the plugin, and starts collecting the lines of the file
+// if the plugin is given files like it.
+func (f *Finder) SetFile(filePath string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flush()
+	f.filePath = filePath
+	f.lines = nil
+	f.fileComponents = nil
+	f.failed = false
+	f.pending = f.config.matches(filePath)
+}
+
+func (f *Finder) FindComponent(line string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.pending {
+		f.lines = append(f.lines, line)
+	}
+}
+
+// GetComponents returns the components found by the plugin, keyed by "path/to/dir:Name".
+func (f *Finder) GetComponents() reportgen.ComponentMap {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flush()
+	return f.components
+}
+
+// GetWarnings returns the files the plugin failed on since the last call.
+func (f *Finder) GetWarnings() []reportgen.Warning {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flush()
+	warnings := f.warnings
+	f.warnings = nil
+
+	return warnings
+}
+
+// Close sends the current file to the plugin and stops it.
+// The plugin is started again if the Finder is given other files.
+func (f *Finder) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flush()
+	if f.process == nil {
+		return nil
+	}
+
+	err := f.process.stop(f.config.ShutdownTimeout)
+	f.process = nil
+
+	return err
+}
+
+// flush sends the lines of the current file to the plugin and adds the components it replies with.
+func (f *Finder) flush() {
+	if !f.pending {
+		return
+	}
+	f.pending = false
+
+	if f.process == nil {
+		process, err := startProcess(f.config.Command)
+		if err != nil {
+			f.warn("starting plugin "+f.config.name(), err)
+			return
+		}
+		f.process = process
+	}
+
+	content := strings.Join(f.lines, "\n")
+	if len(f.lines) > 0 {
+		content += "\n"
+	}
+
+	response, err := f.process.request(Request{File: f.filePath, Content: content}, f.config.Timeout)
+	if err != nil {
+		// The plugin can't be trusted to reply to the next requests in order anymore
+		f.process.kill()
+		f.process = nil
+		f.warn("running plugin "+f.config.name(), err)
+		return
+	}
+
+	if response.Error != "" {
+		f.warn("running plugin "+f.config.name(), fmt.Errorf("%s", response.Error))
+		return
+	}
+
+	f.addComponents(f.filePath, response.Components)
+	f.fileComponents = response.Components
+}
+
+// addComponents adds the components found in a file, keyed by the directory of their file and their name.
+func (f *Finder) addComponents(filePath string, comps []reportgen.Component) {
+	for _, comp := range comps {
+		if comp.File == "" {
+			comp.File = filePath
+		}
+
+		f.components[filepath.Dir(comp.File)+":"+comp.Name] = comp
+	}
+}
+
+// warn reports a failure of the plugin on the current file.
+func (f *Finder) warn(op string, err error) {
+	f.failed = true
+	f.warnings = append(f.warnings, reportgen.Warning{Path: f.filePath, Op: op, Err: err})
+}
+
+// Version identifies the components found by the plugin in the cache.
+func (f *Finder) Version() string {
+	return cacheVersion + "/" + f.config.name() + "/" + strings.Join(f.config.Command, " ")
+}
+
+// FileResult returns the components found in the current file.
+// If the plugin failed on the file, it returns reportgen.ErrIncompleteResult too, so the file isn't cached.
+func (f *Finder) FileResult() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.flush()
+	result, err := json.Marshal(f.fileComponents)
+	if err == nil && f.failed {
+		err = reportgen.ErrIncompleteResult
+	}
+
+	return result, err
+}
+
+// RestoreFileResult adds the components found in a file by an earlier run, as if the plugin was given the file again.
+func (f *Finder) RestoreFileResult(filePath string, result []byte) error {
+	var comps []reportgen.Component
+	if err := json.Unmarshal(result, &comps); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.addComponents(filePath, comps)
+	return nil
+}
+
+// process is a running plugin.
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan responseLine
+}
+
+// responseLine is a line read from the stdout of a plugin.
+type responseLine struct {
+	line []byte
+	err  error
+}
+
+func startProcess(command []string) (*process, error) {
+	cmd := exec.Command(command[0], command[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &process{cmd: cmd, stdin: stdin, responses: make(chan responseLine)}
+
+	// Read the replies in the background, so a request can time out
+	go func() {
+		defer close(p.responses)
+
+		reader := bufio.NewReader(stdout)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				p.responses <- responseLine{line: line}
+			}
+			if err != nil {
+				if err != io.EOF {
+					p.responses <- responseLine{err: err}
+				}
+				return
+			}
+		}
+	}()
+
+	return p, nil
+}
+
+// request sends a request and waits for the reply. The timeout covers both, since a plugin that
+// doesn't read its stdin blocks the request once the pipe is full.
+func (p *process) request(request Request, timeout time.Duration) (Response, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return Response{}, err
+	}
+
+	deadline := time.After(timeout)
+
+	// The write is given up on the deadline, and ends when the plugin is killed
+	written := make(chan error, 1)
+	go func() {
+		_, err := p.stdin.Write(append(data, '\n'))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		if err != nil {
+			return Response{}, fmt.Errorf("sending request: %s", err)
+		}
+	case <-deadline:
+		return Response{}, fmt.Errorf("request not read within %s", timeout)
+	}
+
+	select {
+	case reply, ok := <-p.responses:
+		if !ok {
+			return Response{}, fmt.Errorf("plugin exited without replying")
+		}
+		if reply.err != nil {
+			return Response{}, fmt.Errorf("reading reply: %s", reply.err)
+		}
+
+		var response Response
+		if err := json.Unmarshal(reply.line, &response); err != nil {
+			return Response{}, fmt.Errorf("invalid reply %q: %s", strings.TrimSpace(string(reply.line)), err)
+		}
+		return response, nil
+	case <-deadline:
+		return Response{}, fmt.Errorf("no reply within %s", timeout)
+	}
+}
+
+// stop closes the stdin of the plugin and waits for it to exit, or kills it after the timeout.
+func (p *process) stop(timeout time.Duration) error {
+	p.stdin.Close()
+
+	// Drain the replies nobody asked for, so the plugin isn't blocked writing them
+	drained := make(chan struct{})
+	go func() {
+		for range p.responses {
+		}
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return p.cmd.Wait()
+	case <-time.After(timeout):
+		// Wait closes stdout once the plugin is killed, even if a child process still holds it
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+		return fmt.Errorf("plugin didn't exit within %s after its input was closed", timeout)
+	}
+}
+
+// kill stops the plugin right away.
+func (p *process) kill() {
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	go func() {
+		for range p.responses {
+		}
+	}()
+	go p.cmd.Wait()
+}
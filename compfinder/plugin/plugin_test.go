@@ -0,0 +1,287 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMain runs the test binary as a fake plugin when it's started by a Finder.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("FAKE_PLUGIN"); mode != "" {
+		runFakePlugin(mode)
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runFakePlugin replies with a component for every "rule NAME" line of the files.
+// "broken" files get an error, "garbage" files an invalid reply and "slow" files no reply in time.
+// In the "deaf" mode it never reads its stdin, and in the "stubborn" mode it doesn't exit when stdin is closed.
+func runFakePlugin(mode string) {
+	if mode == "deaf" {
+		time.Sleep(10 * time.Second)
+		return
+	}
+	if mode == "stubborn" {
+		defer time.Sleep(10 * time.Second)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var request Request
+		json.Unmarshal(scanner.Bytes(), &request)
+
+		switch {
+		case strings.Contains(request.File, "broken"):
+			fmt.Println(`{"error": "unexpected token"}`)
+			continue
+		case strings.Contains(request.File, "garbage"):
+			fmt.Println("not json")
+			continue
+		case strings.Contains(request.File, "slow"):
+			time.Sleep(10 * time.Second)
+			continue
+		}
+
+		response := Response{Components: []reportgen.Component{}}
+		for i, line := range strings.Split(request.Content, "\n") {
+			if name, ok := strings.CutPrefix(line, "rule "); ok {
+				response.Components = append(response.Components, reportgen.Component{
+					Package: mode,
+					Name:    name,
+					Type:    "rule",
+					Line:    i + 1,
+				})
+			}
+		}
+
+		data, _ := json.Marshal(response)
+		fmt.Println(string(data))
+	}
+}
+
+// newFakeFinder creates a Finder running the test binary as a plugin for the .rules files.
+// The timeouts are generous, since the test binary may take a while to start and exit, e.g. with -race.
+func newFakeFinder(t *testing.T) *Finder {
+	return newFakeFinderWithTimeouts(t, "rules", 10*time.Second, 10*time.Second)
+}
+
+func newFakeFinderWithTimeouts(t *testing.T, mode string, timeout, shutdownTimeout time.Duration) *Finder {
+	t.Setenv("FAKE_PLUGIN", mode)
+
+	return NewFinder(Config{
+		Name:            "rules",
+		Command:         []string{os.Args[0]},
+		Files:           []string{"*.rules"},
+		Timeout:         timeout,
+		ShutdownTimeout: shutdownTimeout,
+	})
+}
+
+// scan gives the lines of the files to the finder.
+func scan(finder *Finder, files map[string]string, order []string) {
+	for _, filePath := range order {
+		finder.SetFile(filePath)
+		for _, line := range strings.Split(files[filePath], "\n") {
+			finder.FindComponent(line)
+		}
+	}
+}
+
+func TestFinder(t *testing.T) {
+	testCases := []struct {
+		name          string
+		files         []string // files are the paths to the files given to the finder in order
+		expComponents []string // expComponents are the keys of the components found
+		expWarnings   []string // expWarnings are the paths of the files the plugin failed on
+		timeout       time.Duration
+	}{
+		{
+			name:          "Only matching files are given to the plugin",
+			files:         []string{"/repo/a.rules", "/repo/main.go", "/repo/sub/b.rules"},
+			expComponents: []string{"/repo:Allow", "/repo:Deny", "/repo/sub:Allow", "/repo/sub:Deny"},
+		},
+		{
+			name:          "Errors only skip the files concerned",
+			files:         []string{"/repo/broken.rules", "/repo/a.rules"},
+			expComponents: []string{"/repo:Allow", "/repo:Deny"},
+			expWarnings:   []string{"/repo/broken.rules"},
+		},
+		{
+			name:          "The plugin is restarted after an invalid reply",
+			files:         []string{"/repo/garbage.rules", "/repo/a.rules"},
+			expComponents: []string{"/repo:Allow", "/repo:Deny"},
+			expWarnings:   []string{"/repo/garbage.rules"},
+		},
+		{
+			name:          "The plugin is restarted after a timeout",
+			files:         []string{"/repo/slow.rules", "/repo/a.rules"},
+			expComponents: []string{"/repo:Allow", "/repo:Deny"},
+			expWarnings:   []string{"/repo/slow.rules"},
+			timeout:       3 * time.Second,
+		},
+		{
+			name:  "No plugin is started without matching files",
+			files: []string{"/repo/main.go"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			finder := newFakeFinder(t)
+			if tc.timeout > 0 {
+				finder = newFakeFinderWithTimeouts(t, "rules", tc.timeout, 10*time.Second)
+			}
+			files := map[string]string{}
+			for _, filePath := range tc.files {
+				files[filePath] = "rule Allow\n# comment\nrule Deny"
+			}
+
+			scan(finder, files, tc.files)
+			comps := finder.GetComponents()
+			warnings := finder.GetWarnings()
+			assert.NoError(t, finder.Close())
+
+			keys := []string{}
+			for key, comp := range comps {
+				keys = append(keys, key)
+				assert.Equal(t, "rules", comp.Package)
+				assert.NotEmpty(t, comp.File)
+			}
+			assert.ElementsMatch(t, tc.expComponents, keys)
+
+			warningPaths := []string{}
+			for _, warning := range warnings {
+				warningPaths = append(warningPaths, warning.Path)
+				assert.Equal(t, "running plugin rules", warning.Op)
+			}
+			assert.ElementsMatch(t, tc.expWarnings, warningPaths)
+			assert.Empty(t, finder.GetWarnings())
+		})
+	}
+}
+
+func TestFinderFileResult(t *testing.T) {
+	finder := newFakeFinder(t)
+	finder.SetFile("/repo/a.rules")
+	finder.FindComponent("rule Allow")
+	result, err := finder.FileResult()
+	assert.NoError(t, err)
+	assert.NoError(t, finder.Close())
+
+	restored := NewFinder(Config{Name: "rules", Command: []string{"missing-plugin"}, Files: []string{"*.rules"}})
+	assert.NoError(t, restored.RestoreFileResult("/repo/a.rules", result))
+
+	assert.Equal(t, finder.GetComponents(), restored.GetComponents())
+	assert.Contains(t, restored.GetComponents(), "/repo:Allow")
+	assert.Empty(t, restored.GetWarnings())
+}
+
+func TestFinderFileResultFailure(t *testing.T) {
+	finder := newFakeFinder(t)
+	finder.SetFile("/repo/broken.rules")
+	finder.FindComponent("rule Allow")
+	_, err := finder.FileResult()
+	assert.ErrorIs(t, err, reportgen.ErrIncompleteResult)
+
+	// The failure doesn't stick to the next file
+	finder.SetFile("/repo/a.rules")
+	finder.FindComponent("rule Allow")
+	_, err = finder.FileResult()
+	assert.NoError(t, err)
+	assert.NoError(t, finder.Close())
+}
+
+func TestFinderStuckPlugin(t *testing.T) {
+	t.Run("Plugin not reading its stdin", func(t *testing.T) {
+		finder := newFakeFinderWithTimeouts(t, "deaf", 500*time.Millisecond, 10*time.Second)
+
+		// The request is larger than the buffer of the pipe
+		start := time.Now()
+		finder.SetFile("/repo/a.rules")
+		finder.FindComponent(strings.Repeat("rule Allow\n", 30000))
+		warnings := finder.GetWarnings()
+
+		assert.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0].Err.Error(), "not read within")
+		assert.NoError(t, finder.Close())
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Plugin not exiting", func(t *testing.T) {
+		finder := newFakeFinderWithTimeouts(t, "stubborn", 10*time.Second, 500*time.Millisecond)
+
+		start := time.Now()
+		finder.SetFile("/repo/a.rules")
+		finder.FindComponent("rule Allow")
+		assert.Contains(t, finder.GetComponents(), "/repo:Allow")
+
+		assert.Error(t, finder.Close())
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
+
+func TestFinderMissingCommand(t *testing.T) {
+	finder := NewFinder(Config{Name: "rules", Command: []string{"/no/such/plugin"}, Files: []string{"*.rules"}})
+	finder.SetFile("/repo/a.rules")
+	finder.FindComponent("rule Allow")
+
+	assert.Empty(t, finder.GetComponents())
+	warnings := finder.GetWarnings()
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "starting plugin rules", warnings[0].Op)
+	assert.NoError(t, finder.Close())
+}
+
+func TestParseFlag(t *testing.T) {
+	testCases := []struct {
+		name      string
+		value     string
+		expConfig Config
+		expErr    bool
+	}{
+		{
+			name:      "Patterns and command with arguments",
+			value:     "*.dsl, *.rules=dsl-finder --strict",
+			expConfig: Config{Command: []string{"dsl-finder", "--strict"}, Files: []string{"*.dsl", "*.rules"}},
+		},
+		{
+			name:   "Missing command",
+			value:  "*.dsl=",
+			expErr: true,
+		},
+		{
+			name:   "Missing patterns",
+			value:  "dsl-finder",
+			expErr: true,
+		},
+		{
+			name:   "Invalid pattern",
+			value:  "[.dsl=dsl-finder",
+			expErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ParseFlag(tc.value)
+
+			if tc.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expConfig, config)
+		})
+	}
+}
@@ -0,0 +1,465 @@
+package python
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+const (
+	TypeClass = "class"
+	TypeFunc  = "func"
+)
+
+// cacheVersion identifies the components found by the ComponentFinder in the cache.
+// Change it whenever a change of the finder makes it find other components in the same file.
+const cacheVersion = "python/2"
+
+// Options are the options of the ComponentFinder.
+type Options struct {
+	IncludeTests bool // IncludeTests scans the test files, like test_*.py and *_test.py
+}
+
+// DefaultOptions returns the options of a ComponentFinder finding everything.
+func DefaultOptions() Options {
+	return Options{IncludeTests: true}
+}
+
+// scope is a class or function definition whose body is being processed.
+type scope struct {
+	indent     int    // indent is the indentation of the definition
+	bodyIndent int    // bodyIndent is the indentation of the body, -1 before its first statement
+	key        string // key is the key of the class or the function component
+	method     string // method is the name of the method if the definition is in a class
+	isClass    bool
+}
+
+// ComponentFinder finds the classes and the module-level functions of Python files.
+// Indentation scopes the statements: the methods and the annotated fields, like the fields of
+// dataclasses and pydantic models, are the ones right in the body of a class. Nested functions and
+// classes are part of the function or the class they are defined in.
+//
+// The package of a component is the dotted name of its module, which follows the __init__.py files
+// of the repo. It's only known once every file is given, so GetComponents works it out.
+// If a module has an __all__ list, its components are exported if they are in it,
+// otherwise by the names not starting with an underscore.
+type ComponentFinder struct {
+	mu         sync.Mutex
+	options    Options
+	modules    *moduleFinder
+	components reportgen.ComponentMap
+	fileKeys   map[string]bool // fileKeys are the keys of the components found in the current file
+	filePath   string
+	skipFile   bool // skipFile is true if the current file isn't a Python file or is left out by the options
+	lineNum    int
+	scanner    statementScanner
+	statement  []string // statement are the lines of the statement going on
+	stmtStart  int
+	decorators []string // decorators are the decorators of the definition that comes next
+	scopes     []scope
+	exports    map[string]bool // exports are the names in the __all__ list of the current file, nil without a list
+}
+
+func NewComponentFinder() *ComponentFinder {
+	return NewComponentFinderWithOptions(DefaultOptions())
+}
+
+// NewComponentFinderWithOptions creates a ComponentFinder finding what the options ask for.
+func NewComponentFinderWithOptions(options Options) *ComponentFinder {
+	return &ComponentFinder{
+		options:    options,
+		modules:    newModuleFinder(),
+		components: reportgen.ComponentMap{},
+		fileKeys:   map[string]bool{},
+	}
+}
+
+// SetFile sets the directory path and file name for the current file being processed.
+// It's the beginning of a new file.
+func (cf *ComponentFinder) SetFile(filePath string) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	cf.filePath = filePath
+	cf.modules.addFile(filePath)
+	cf.skipFile = filepath.Ext(filePath) != ".py" || (!cf.options.IncludeTests && isTestFile(filePath))
+	cf.fileKeys = map[string]bool{}
+	cf.lineNum = 0
+	cf.scanner.reset()
+	cf.statement = nil
+	cf.decorators = nil
+	cf.scopes = nil
+	cf.exports = nil
+}
+
+func (cf *ComponentFinder) FindComponent(line string) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	cf.lineNum++
+	if cf.skipFile {
+		return
+	}
+
+	code := cf.scanner.scan(line)
+	if len(cf.statement) == 0 {
+		// Blank and comment lines don't end the scopes, whatever their indentation
+		if strings.TrimSpace(code) == "" {
+			return
+		}
+		cf.stmtStart = cf.lineNum
+	}
+
+	cf.statement = append(cf.statement, code)
+	if cf.scanner.continues(code) {
+		return
+	}
+
+	stmt := strings.Join(cf.statement, "\n")
+	cf.statement = nil
+	cf.processStatement(stmt, cf.stmtStart, cf.lineNum)
+}
+
+// processStatement finds the components of a whole statement from its first to its last line.
+func (cf *ComponentFinder) processStatement(stmt string, start, end int) {
+	indent := indentation(stmt)
+	text := strings.TrimSpace(stmt)
+
+	// The statement ends the definitions it isn't indented into
+	for len(cf.scopes) > 0 && indent <= cf.scopes[len(cf.scopes)-1].indent {
+		cf.scopes = cf.scopes[:len(cf.scopes)-1]
+	}
+	for _, s := range cf.scopes {
+		cf.extend(s, end)
+	}
+
+	if len(cf.scopes) > 0 {
+		top := &cf.scopes[len(cf.scopes)-1]
+		if top.bodyIndent == -1 {
+			top.bodyIndent = indent
+			if doc, ok := parseDocstring(text); ok {
+				cf.setDoc(*top, doc)
+				return
+			}
+		}
+
+		if top.isClass && indent == top.bodyIndent {
+			cf.processClassStatement(*top, text, indent, start, end)
+			return
+		}
+
+		// Nested blocks and function bodies define nothing the report shows
+		cf.decorators = nil
+		return
+	}
+
+	// Only the statements right in the module define its components, not the ones in if or try blocks
+	if indent == 0 {
+		cf.processModuleStatement(text, indent, start, end)
+		return
+	}
+	cf.decorators = nil
+}
+
+// processModuleStatement finds the classes, functions and the __all__ list of a module.
+func (cf *ComponentFinder) processModuleStatement(text string, indent, start, end int) {
+	if decorator, ok := strings.CutPrefix(text, "@"); ok {
+		cf.decorators = append(cf.decorators, decorator)
+		return
+	}
+	decorators := cf.decorators
+	cf.decorators = nil
+
+	if class, ok := parseClass(text); ok {
+		name := class.name
+		if class.params != "" {
+			name += "(" + class.params + ")"
+		}
+
+		key := cf.componentKey(class.name)
+		cf.addComponent(key, reportgen.Component{
+			File:    cf.filePath,
+			Name:    name + tags(false, decorators),
+			Type:    TypeClass,
+			Line:    start,
+			EndLine: end,
+		})
+		cf.openScope(class, scope{indent: indent, bodyIndent: -1, key: key, isClass: true})
+		return
+	}
+
+	if function, ok := parseDef(text); ok {
+		if skipDefinition(function, decorators) {
+			return
+		}
+
+		key := cf.componentKey(function.name)
+		cf.addComponent(key, reportgen.Component{
+			File:    cf.filePath,
+			Name:    signature(function, false, decorators),
+			Type:    TypeFunc,
+			Line:    start,
+			EndLine: end,
+		})
+		cf.openScope(function, scope{indent: indent, bodyIndent: -1, key: key})
+		return
+	}
+
+	if names, replace, ok := parseAll(text); ok {
+		if replace || cf.exports == nil {
+			cf.exports = map[string]bool{}
+		}
+		for _, name := range names {
+			cf.exports[name] = true
+		}
+
+		// __all__ is usually at the top of the module, but it can come after the definitions too
+		for key := range cf.fileKeys {
+			cf.components[key] = cf.withVisibility(key, cf.components[key])
+		}
+	}
+}
+
+// processClassStatement finds the methods, properties and annotated fields in the body of a class.
+func (cf *ComponentFinder) processClassStatement(class scope, text string, indent, start, end int) {
+	if decorator, ok := strings.CutPrefix(text, "@"); ok {
+		cf.decorators = append(cf.decorators, decorator)
+		return
+	}
+	decorators := cf.decorators
+	cf.decorators = nil
+
+	comp := cf.components[class.key]
+	if method, ok := parseDef(text); ok {
+		if skipDefinition(method, decorators) {
+			return
+		}
+
+		if isProperty(decorators) {
+			field := method.name
+			if method.result != "" {
+				field += ": " + method.result
+			}
+			comp.Fields = append(comp.Fields, field+tags(method.async, decorators))
+		} else {
+			comp.Methods = append(comp.Methods, signature(method, !hasDecorator(decorators, "staticmethod"), decorators))
+		}
+
+		if comp.MethodLocations == nil {
+			comp.MethodLocations = map[string]reportgen.Location{}
+		}
+		comp.MethodLocations[method.name] = reportgen.Location{File: cf.filePath, Line: start, EndLine: end}
+		cf.components[class.key] = comp
+		cf.openScope(method, scope{indent: indent, bodyIndent: -1, key: class.key, method: method.name})
+		return
+	}
+
+	if field, ok := parseField(text); ok {
+		comp.Fields = append(comp.Fields, field)
+		cf.components[class.key] = comp
+	}
+}
+
+// openScope starts the body of a definition, unless the body is on the same line.
+func (cf *ComponentFinder) openScope(def definition, s scope) {
+	if def.body == "" {
+		cf.scopes = append(cf.scopes, s)
+	}
+}
+
+// extend makes the definition of a scope end at the line.
+func (cf *ComponentFinder) extend(s scope, end int) {
+	comp := cf.components[s.key]
+	if s.method == "" {
+		comp.EndLine = end
+	} else {
+		location := comp.MethodLocations[s.method]
+		location.EndLine = end
+		comp.MethodLocations[s.method] = location
+	}
+	cf.components[s.key] = comp
+}
+
+// setDoc sets the docstring of a class or a function. The docstrings of the methods aren't kept.
+func (cf *ComponentFinder) setDoc(s scope, doc string) {
+	if s.method != "" {
+		return
+	}
+
+	comp := cf.components[s.key]
+	comp.Doc = doc
+	cf.components[s.key] = comp
+}
+
+func (cf *ComponentFinder) addComponent(key string, comp reportgen.Component) {
+	cf.components[key] = cf.withVisibility(key, comp)
+	cf.fileKeys[key] = true
+}
+
+// withVisibility sets the visibility of a component of the current file by the __all__ list, if any.
+func (cf *ComponentFinder) withVisibility(key string, comp reportgen.Component) reportgen.Component {
+	comp.Visibility = ""
+	if cf.exports != nil {
+		comp.Visibility = reportgen.VisibilityUnexported
+		if cf.exports[componentName(key)] {
+			comp.Visibility = reportgen.VisibilityExported
+		}
+	}
+
+	return comp
+}
+
+// componentKey returns the key of a component of the current file while the files are given,
+// like "app/models/user.py:User". GetComponents replaces the file with the directory and the module.
+func (cf *ComponentFinder) componentKey(name string) string {
+	return cf.filePath + ":" + name
+}
+
+// componentName returns the name of a component from its key while the files are given.
+func componentName(key string) string {
+	return key[strings.LastIndex(key, ":")+1:]
+}
+
+// SetRoot sets the root directory of the repo, which isn't a package even with an __init__.py file.
+func (cf *ComponentFinder) SetRoot(rootPath string) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	cf.modules.rootPath = rootPath
+}
+
+// GetComponents returns the components keyed by their directory and their module, like
+// "app/models:app.models.user.User". Modules of the same directory can define components with the same name.
+func (cf *ComponentFinder) GetComponents() reportgen.ComponentMap {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	// Return a copy of the map to avoid race conditions
+	// when the caller iterates over the map
+	compCopy := make(reportgen.ComponentMap)
+	for k, v := range cf.components {
+		v.Package = cf.modules.moduleName(v.File)
+		compCopy[filepath.Dir(v.File)+":"+v.Package+"."+componentName(k)] = v
+	}
+
+	return compCopy
+}
+
+// Version identifies the components found by the ComponentFinder in the cache.
+func (cf *ComponentFinder) Version() string {
+	if !cf.options.IncludeTests {
+		return cacheVersion + "-notests"
+	}
+
+	return cacheVersion
+}
+
+// FileResult returns the components found in the current file.
+func (cf *ComponentFinder) FileResult() ([]byte, error) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	comps := reportgen.ComponentMap{}
+	for key := range cf.fileKeys {
+		comps[key] = cf.components[key]
+	}
+
+	return json.Marshal(comps)
+}
+
+// RestoreFileResult adds the components found in a file by an earlier run, as if the file was processed again.
+func (cf *ComponentFinder) RestoreFileResult(filePath string, result []byte) error {
+	comps := reportgen.ComponentMap{}
+	if err := json.Unmarshal(result, &comps); err != nil {
+		return err
+	}
+
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+
+	cf.modules.addFile(filePath)
+	for key, comp := range comps {
+		cf.components[key] = comp
+	}
+	cf.fileKeys = map[string]bool{}
+
+	return nil
+}
+
+// signature returns the signature of a function or method, like "get(key: str) -> int [async, cache]".
+// The first parameter of a method is left out, it's the instance or the class.
+func signature(def definition, isMethod bool, decorators []string) string {
+	params := splitParams(def.params)
+	if isMethod && len(params) > 0 && !strings.HasPrefix(params[0], "*") {
+		params = params[1:]
+	}
+
+	sig := def.name + "(" + strings.Join(params, ", ") + ")"
+	if def.result != "" {
+		sig += " -> " + def.result
+	}
+
+	return sig + tags(def.async, decorators)
+}
+
+// tags returns the decorators of a definition by name, like " [async, staticmethod]", if any.
+func tags(async bool, decorators []string) string {
+	names := []string{}
+	if async {
+		names = append(names, "async")
+	}
+	for _, decorator := range decorators {
+		names = append(names, decoratorName(decorator))
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+
+	return " [" + strings.Join(names, ", ") + "]"
+}
+
+// skipDefinition reports whether a definition repeats another one: the typing overloads
+// come before the implementation, and the property setters and deleters after the getter.
+func skipDefinition(def definition, decorators []string) bool {
+	for _, decorator := range decorators {
+		name := decoratorName(decorator)
+		if name == "overload" || name == "typing.overload" ||
+			name == def.name+".setter" || name == def.name+".deleter" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isProperty reports whether the decorators make a method a property, which is shown as a field.
+func isProperty(decorators []string) bool {
+	return hasDecorator(decorators, "property") || hasDecorator(decorators, "cached_property") ||
+		hasDecorator(decorators, "functools.cached_property")
+}
+
+func hasDecorator(decorators []string, name string) bool {
+	for _, decorator := range decorators {
+		if decoratorName(decorator) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decoratorName returns the name of a decorator without its arguments, like "app.get" for `app.get("/")`.
+func decoratorName(decorator string) string {
+	name, _, _ := strings.Cut(decorator, "(")
+	return strings.TrimSpace(name)
+}
+
+// isTestFile reports whether the file is a test module by the naming of pytest.
+func isTestFile(filePath string) bool {
+	name := filepath.Base(filePath)
+	return strings.HasPrefix(name, "test_") || strings.HasSuffix(name, "_test.py") || name == "conftest.py"
+}
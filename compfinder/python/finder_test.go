@@ -0,0 +1,309 @@
+package python
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentFinderFindComponent(t *testing.T) {
+	testCases := []struct {
+		name         string
+		filePath     string
+		fileContent  string
+		expectedComp reportgen.ComponentMap
+	}{
+		{
+			name:     "Class with bases, fields and methods",
+			filePath: "app/user.py",
+			fileContent: `
+from pydantic import BaseModel, Field
+
+
+class User(BaseModel, Generic[T]):
+    """A user of the app.
+
+    Users can log in.
+    """
+
+    name: str = Field(..., description="a: b")
+    email: str | None = None  # optional
+    count = 0
+
+    def __init__(self, name: str, **kwargs) -> None:
+        super().__init__(name=name, **kwargs)
+
+        def helper(x):
+            return x
+
+    def _check(self): pass
+`,
+			expectedComp: reportgen.ComponentMap{
+				"app:user.User": reportgen.Component{
+					File:    "app/user.py",
+					Package: "user",
+					Name:    "User(BaseModel, Generic[T])",
+					Type:    TypeClass,
+					Fields:  []string{"name: str", "email: str | None"},
+					Methods: []string{"__init__(name: str, **kwargs) -> None", "_check()"},
+					Doc:     "A user of the app.\n\nUsers can log in.",
+					Line:    5,
+					EndLine: 21,
+					MethodLocations: map[string]reportgen.Location{
+						"__init__": {File: "app/user.py", Line: 15, EndLine: 19},
+						"_check":   {File: "app/user.py", Line: 21, EndLine: 21},
+					},
+				},
+			},
+		},
+		{
+			name:     "Decorated methods and properties",
+			filePath: "app/user.py",
+			fileContent: `
+@dataclass(frozen=True)
+class User:
+    @property
+    def display_name(self) -> str:
+        return self.name.title()
+
+    @display_name.setter
+    def display_name(self, value: str) -> None:
+        self.name = value
+
+    @staticmethod
+    def parse(text: str) -> "User":
+        return User(text)
+
+    @classmethod
+    def from_dict(
+        cls,
+        data: dict[str, str],
+        *,
+        strict: bool = False,
+    ) -> "User":
+        return cls(**data)
+
+    async def save(self, db) -> None:
+        query = """
+def not_a_method(self):
+    pass
+"""
+        await db.save(self, query)
+`,
+			expectedComp: reportgen.ComponentMap{
+				"app:user.User": reportgen.Component{
+					File:    "app/user.py",
+					Package: "user",
+					Name:    "User [dataclass]",
+					Type:    TypeClass,
+					Fields:  []string{"display_name: str [property]"},
+					Methods: []string{
+						`parse(text: str) -> "User" [staticmethod]`,
+						`from_dict(data: dict[str, str], *, strict: bool = False) -> "User" [classmethod]`,
+						"save(db) -> None [async]",
+					},
+					Line:    3,
+					EndLine: 30,
+					MethodLocations: map[string]reportgen.Location{
+						"display_name": {File: "app/user.py", Line: 5, EndLine: 6},
+						"parse":        {File: "app/user.py", Line: 13, EndLine: 14},
+						"from_dict":    {File: "app/user.py", Line: 17, EndLine: 23},
+						"save":         {File: "app/user.py", Line: 25, EndLine: 30},
+					},
+				},
+			},
+		},
+		{
+			name:     "Module-level functions",
+			filePath: "app/api.py",
+			fileContent: `
+@overload
+def get(key: str) -> str: ...
+@overload
+def get(key: int) -> str: ...
+def get(key):
+    return str(key)
+
+
+@router.get("/users")
+async def _list_users(limit: int = 10) -> list[User]:
+    '''List the users.'''
+    return []
+
+
+if __name__ == "__main__":
+    def main():
+        pass
+`,
+			expectedComp: reportgen.ComponentMap{
+				"app:api.get": reportgen.Component{
+					File:    "app/api.py",
+					Package: "api",
+					Name:    "get(key)",
+					Type:    TypeFunc,
+					Line:    6,
+					EndLine: 7,
+				},
+				"app:api._list_users": reportgen.Component{
+					File:    "app/api.py",
+					Package: "api",
+					Name:    "_list_users(limit: int = 10) -> list[User] [async, router.get]",
+					Type:    TypeFunc,
+					Doc:     "List the users.",
+					Line:    11,
+					EndLine: 13,
+				},
+			},
+		},
+		{
+			name:     "Exports of the __all__ list",
+			filePath: "app/api.py",
+			fileContent: `
+__all__ = [
+    "Client",
+]
+
+
+class Client:
+    pass
+
+
+class Server:
+    pass
+
+
+__all__ += ["serve"]
+
+
+def serve():
+    pass
+`,
+			expectedComp: reportgen.ComponentMap{
+				"app:api.Client": reportgen.Component{
+					File:       "app/api.py",
+					Package:    "api",
+					Name:       "Client",
+					Type:       TypeClass,
+					Line:       7,
+					EndLine:    8,
+					Visibility: reportgen.VisibilityExported,
+				},
+				"app:api.Server": reportgen.Component{
+					File:       "app/api.py",
+					Package:    "api",
+					Name:       "Server",
+					Type:       TypeClass,
+					Line:       11,
+					EndLine:    12,
+					Visibility: reportgen.VisibilityUnexported,
+				},
+				"app:api.serve": reportgen.Component{
+					File:       "app/api.py",
+					Package:    "api",
+					Name:       "serve()",
+					Type:       TypeFunc,
+					Line:       18,
+					EndLine:    19,
+					Visibility: reportgen.VisibilityExported,
+				},
+			},
+		},
+		{
+			name:     "Not a Python file",
+			filePath: "app/main.go",
+			fileContent: `
+class User:
+    pass
+`,
+			expectedComp: reportgen.ComponentMap{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cf := NewComponentFinder()
+			cf.SetFile(tc.filePath)
+			lines := strings.Split(tc.fileContent, "\n")
+			for _, line := range lines {
+				cf.FindComponent(line)
+			}
+
+			assert.Equal(t, tc.expectedComp, cf.GetComponents())
+		})
+	}
+}
+
+func TestComponentFinderOptions(t *testing.T) {
+	content := "def test_get():\n    pass\n"
+
+	cf := NewComponentFinderWithOptions(Options{IncludeTests: false})
+	cf.SetFile("app/test_api.py")
+	for _, line := range strings.Split(content, "\n") {
+		cf.FindComponent(line)
+	}
+
+	assert.Empty(t, cf.GetComponents())
+	assert.NotEqual(t, NewComponentFinder().Version(), cf.Version())
+}
+
+func TestComponentFinderFileResult(t *testing.T) {
+	cf := NewComponentFinder()
+	cf.SetFile("app/api.py")
+	for _, line := range strings.Split("class Client:\n    name: str\n", "\n") {
+		cf.FindComponent(line)
+	}
+
+	result, err := cf.FileResult()
+	assert.NoError(t, err)
+
+	restored := NewComponentFinder()
+	assert.NoError(t, restored.RestoreFileResult("app/api.py", result))
+	assert.Equal(t, cf.GetComponents(), restored.GetComponents())
+}
+
+// finderFactory gives the same finders to every report.
+type finderFactory []reportgen.ComponentFinder
+
+func (ff finderFactory) GetFinders() []reportgen.ComponentFinder {
+	return ff
+}
+
+func TestComponentFinderPackagesOfRepo(t *testing.T) {
+	// The directories above the root directory are packages too, but they aren't part of the repo
+	outerDir := t.TempDir()
+	rootPath := filepath.Join(outerDir, "repo")
+	os.MkdirAll(filepath.Join(rootPath, "pkg"), 0755)
+	os.WriteFile(filepath.Join(outerDir, "__init__.py"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(rootPath, "__init__.py"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(rootPath, "pkg", "mod.py"), []byte("class A:\n    pass\n"), 0644)
+
+	packages := func() []string {
+		rg := reportgen.NewReportGenerator("repo", rootPath, finderFactory{NewComponentFinder()})
+		rg.SetOptions(reportgen.Options{Cache: true})
+		report, err := rg.Analyze(context.Background())
+		assert.NoError(t, err)
+
+		names := []string{}
+		for _, comps := range report.ComponentMap() {
+			for _, comp := range comps {
+				names = append(names, comp.Package+"."+comp.Name)
+			}
+		}
+
+		return names
+	}
+
+	assert.Equal(t, []string{"mod.A"}, packages())
+
+	// Adding a package renames the modules in it, even if the files of the modules are cached
+	os.WriteFile(filepath.Join(rootPath, "pkg", "__init__.py"), []byte(""), 0644)
+	assert.Equal(t, []string{"pkg.mod.A"}, packages())
+
+	os.Remove(filepath.Join(rootPath, "pkg", "__init__.py"))
+	assert.Equal(t, []string{"mod.A"}, packages())
+}
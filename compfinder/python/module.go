@@ -0,0 +1,56 @@
+package python
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// packageInit is the file making a directory a Python package.
+const packageInit = "__init__.py"
+
+// moduleFinder finds the dotted names of the modules, like "app.models.user" for app/models/user.py.
+// The packages are the directories whose __init__.py file has been given, up to the first one without it.
+// The root directory isn't a package, it's where the imports start from like a directory of sys.path.
+type moduleFinder struct {
+	rootPath string
+	packages map[string]bool // packages are the directories with an __init__.py file
+}
+
+func newModuleFinder() *moduleFinder {
+	return &moduleFinder{packages: map[string]bool{}}
+}
+
+// addFile adds a file of the repo, which makes its directory a package if it's an __init__.py file.
+func (mf *moduleFinder) addFile(filePath string) {
+	if filepath.Base(filePath) == packageInit {
+		mf.packages[filepath.Dir(filePath)] = true
+	}
+}
+
+// moduleName returns the dotted name of the module of a file. The module of an __init__.py file
+// is its package, and a file out of any package is a module on its own, like a script.
+func (mf *moduleFinder) moduleName(filePath string) string {
+	names := []string{}
+	if name := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)); name != "__init__" {
+		names = append(names, name)
+	}
+
+	for dir := filepath.Dir(filePath); mf.isPackage(dir); dir = filepath.Dir(dir) {
+		names = append([]string{filepath.Base(dir)}, names...)
+
+		// Stop at the root of the file system
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	if len(names) == 0 {
+		return filepath.Base(filepath.Dir(filePath))
+	}
+
+	return strings.Join(names, ".")
+}
+
+func (mf *moduleFinder) isPackage(dir string) bool {
+	return mf.packages[dir] && dir != mf.rootPath
+}
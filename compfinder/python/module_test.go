@@ -0,0 +1,55 @@
+package python
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleName(t *testing.T) {
+	rootPath := filepath.Join("outer", "repo")
+	mf := newModuleFinder()
+	mf.rootPath = rootPath
+	for _, filePath := range []string{
+		filepath.Join(rootPath, "__init__.py"),
+		filepath.Join(rootPath, "src", "app", "__init__.py"),
+		filepath.Join(rootPath, "src", "app", "models", "__init__.py"),
+		filepath.Join(rootPath, "scripts", "migrate.py"),
+	} {
+		mf.addFile(filePath)
+	}
+
+	testCases := []struct {
+		name      string
+		filePath  string
+		expModule string
+	}{
+		{
+			name:      "Module of a nested package",
+			filePath:  filepath.Join(rootPath, "src", "app", "models", "user.py"),
+			expModule: "app.models.user",
+		},
+		{
+			name:      "__init__.py is the package",
+			filePath:  filepath.Join(rootPath, "src", "app", "models", "__init__.py"),
+			expModule: "app.models",
+		},
+		{
+			name:      "Script out of any package",
+			filePath:  filepath.Join(rootPath, "scripts", "migrate.py"),
+			expModule: "migrate",
+		},
+		{
+			name:      "The root directory isn't a package",
+			filePath:  filepath.Join(rootPath, "setup.py"),
+			expModule: "setup",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expModule, mf.moduleName(tc.filePath))
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package python
+
+import (
+	"github.com/burwei/repoexplainer/compfinder/registry"
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+func init() {
+	registry.Register(registry.Finder{
+		Name:        "python",
+		Language:    "Python",
+		Description: "Classes with their bases, fields, methods and properties, and functions with their type hints and docstrings",
+		Default:     true,
+		Options: []registry.Option{
+			{Name: "tests", Default: "true", Description: "Scan the test_*.py, *_test.py and conftest.py files"},
+		},
+		New: func(options map[string]string) (reportgen.ComponentFinder, error) {
+			tests, err := registry.BoolOption(options, "tests")
+			if err != nil {
+				return nil, err
+			}
+
+			return NewComponentFinderWithOptions(Options{IncludeTests: tests}), nil
+		},
+	})
+}
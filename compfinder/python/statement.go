@@ -0,0 +1,346 @@
+package python
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// stringPrefix matches the prefix and the opening quote of a string literal, like `"""` or `r'`
+	stringPrefix = regexp.MustCompile(`^[rRuUbBfF]{0,2}("""|'''|"|')`)
+	// fieldName matches the name of an annotated assignment, like "name: str = None"
+	fieldName = regexp.MustCompile(`^([A-Za-z_]\w*)\s*:`)
+	// allAssignment matches the assignments of the __all__ list, like "__all__ = [" or "__all__ += ("
+	allAssignment = regexp.MustCompile(`^__all__\s*(?::[^=]*)?(\+?=)`)
+	// allExtension matches the calls adding to the __all__ list, like "__all__.extend(["
+	allExtension = regexp.MustCompile(`^__all__\.(?:extend|append)\(`)
+	// stringName matches a name in a string literal, like "'User'"
+	stringName = regexp.MustCompile(`["']([A-Za-z_]\w*)["']`)
+)
+
+// statementScanner joins the physical lines of a file into statements.
+// A statement goes on while a bracket or a triple-quoted string is open, or the line ends with a backslash.
+type statementScanner struct {
+	quote string // quote is the quote of the string open at the end of the last line, if any
+	depth int    // depth counts the open brackets, parentheses and braces
+}
+
+// scan returns the line without its comment, and keeps track of the open brackets and strings.
+func (ss *statementScanner) scan(line string) string {
+	for i := 0; i < len(line); i++ {
+		if ss.quote != "" {
+			switch {
+			case line[i] == '\\':
+				i++
+			case strings.HasPrefix(line[i:], ss.quote):
+				i += len(ss.quote) - 1
+				ss.quote = ""
+			}
+			continue
+		}
+
+		switch line[i] {
+		case '#':
+			return line[:i]
+		case '"', '\'':
+			ss.quote = line[i : i+1]
+			if triple := strings.Repeat(ss.quote, 3); strings.HasPrefix(line[i:], triple) {
+				ss.quote = triple
+				i += 2
+			}
+		case '(', '[', '{':
+			ss.depth++
+		case ')', ']', '}':
+			ss.depth--
+		}
+	}
+
+	// Only triple-quoted strings span several lines
+	if len(ss.quote) == 1 && !strings.HasSuffix(line, "\\") {
+		ss.quote = ""
+	}
+
+	return line
+}
+
+// continues reports whether the statement goes on after the line returned by scan.
+func (ss *statementScanner) continues(code string) bool {
+	return ss.depth > 0 || len(ss.quote) == 3 || strings.HasSuffix(strings.TrimRight(code, " \t"), "\\")
+}
+
+// reset forgets the open brackets and strings, at the beginning of a file.
+func (ss *statementScanner) reset() {
+	ss.quote = ""
+	ss.depth = 0
+}
+
+// indentation returns the width of the indentation of a line, with the tabs up to the next multiple of 8.
+func indentation(line string) int {
+	width := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			width++
+		case '\t':
+			width += 8 - width%8
+		default:
+			return width
+		}
+	}
+
+	return width
+}
+
+// definition is a function or class definition statement.
+type definition struct {
+	name   string
+	async  bool
+	params string // params are the parameters of a function, or the bases of a class
+	result string // result is the return annotation of a function, if any
+	body   string // body is the code after the colon if the body is on the same line
+}
+
+// parseDef parses a statement like "async def name(params) -> result:".
+func parseDef(stmt string) (definition, bool) {
+	def := definition{}
+	if rest, ok := strings.CutPrefix(stmt, "async "); ok {
+		def.async = true
+		stmt = strings.TrimSpace(rest)
+	}
+
+	rest, ok := strings.CutPrefix(stmt, "def ")
+	if !ok {
+		return def, false
+	}
+
+	open := strings.Index(rest, "(")
+	if open == -1 {
+		return def, false
+	}
+	def.name = strings.TrimSpace(rest[:open])
+	// Skip the type parameters, like "def first[T](items: list[T]) -> T:"
+	if idx := strings.Index(def.name, "["); idx != -1 {
+		def.name = strings.TrimSpace(def.name[:idx])
+	}
+
+	close := matchingBracket(rest, open)
+	if close == -1 || !isIdentifier(def.name) {
+		return def, false
+	}
+	def.params = normalize(rest[open+1 : close])
+
+	after := rest[close+1:]
+	colon := indexTopLevel(after, ':')
+	if colon == -1 {
+		return def, false
+	}
+	def.result = normalize(strings.TrimPrefix(strings.TrimSpace(after[:colon]), "->"))
+	def.body = strings.TrimSpace(after[colon+1:])
+
+	return def, true
+}
+
+// parseClass parses a statement like "class Name(bases):".
+func parseClass(stmt string) (definition, bool) {
+	def := definition{}
+	rest, ok := strings.CutPrefix(stmt, "class ")
+	if !ok {
+		return def, false
+	}
+
+	end := strings.IndexAny(rest, "([:")
+	if end == -1 {
+		return def, false
+	}
+	def.name = strings.TrimSpace(rest[:end])
+	rest = rest[end:]
+
+	// Skip the type parameters, like "class Box[T]:"
+	if strings.HasPrefix(rest, "[") {
+		close := matchingBracket(rest, 0)
+		if close == -1 {
+			return def, false
+		}
+		rest = rest[close+1:]
+	}
+
+	if strings.HasPrefix(rest, "(") {
+		close := matchingBracket(rest, 0)
+		if close == -1 {
+			return def, false
+		}
+		def.params = normalize(rest[1:close])
+		rest = rest[close+1:]
+	}
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, ":") || !isIdentifier(def.name) {
+		return def, false
+	}
+	def.body = strings.TrimSpace(rest[1:])
+
+	return def, true
+}
+
+// parseField parses an annotated assignment like "name: str = None" into "name: str".
+func parseField(stmt string) (string, bool) {
+	match := fieldName.FindStringSubmatch(stmt)
+	if match == nil {
+		return "", false
+	}
+
+	annotation := stmt[len(match[0]):]
+	if idx := indexTopLevel(annotation, '='); idx != -1 {
+		annotation = annotation[:idx]
+	}
+
+	// Keywords followed by a colon, like "else:", have no annotation
+	annotation = normalize(annotation)
+	if annotation == "" {
+		return "", false
+	}
+
+	return match[1] + ": " + annotation, true
+}
+
+// parseAll returns the names of an assignment to the __all__ list, and whether they replace the earlier ones.
+func parseAll(stmt string) ([]string, bool, bool) {
+	replace := false
+	if match := allAssignment.FindStringSubmatch(stmt); match != nil {
+		replace = match[1] == "="
+	} else if !allExtension.MatchString(stmt) {
+		return nil, false, false
+	}
+
+	names := []string{}
+	for _, match := range stringName.FindAllStringSubmatch(stmt, -1) {
+		names = append(names, match[1])
+	}
+
+	return names, replace, true
+}
+
+// parseDocstring returns the text of a statement made of a string literal only, like a docstring.
+func parseDocstring(stmt string) (string, bool) {
+	match := stringPrefix.FindStringSubmatch(stmt)
+	if match == nil {
+		return "", false
+	}
+
+	quote := match[1]
+	text := stmt[len(match[0]):]
+	// The string must end the statement, like in `"""Docstring."""` but not `"a" + "b"`
+	if len(text) < len(quote) || strings.Index(text, quote) != len(text)-len(quote) {
+		return "", false
+	}
+	text = text[:len(text)-len(quote)]
+
+	return cleanDocstring(text), true
+}
+
+// cleanDocstring removes the indentation of a docstring, and its leading and trailing blank lines.
+func cleanDocstring(text string) string {
+	lines := strings.Split(text, "\n")
+
+	// The first line starts right after the quotes, the others are indented like the code
+	margin := -1
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indent := len(line) - len(strings.TrimLeft(line, " \t")); margin == -1 || indent < margin {
+			margin = indent
+		}
+	}
+
+	lines[0] = strings.TrimSpace(lines[0])
+	for i := 1; i < len(lines); i++ {
+		if len(lines[i]) >= margin && margin > 0 {
+			lines[i] = lines[i][margin:]
+		}
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// splitParams splits the parameters of a function at the commas out of brackets and strings.
+func splitParams(params string) []string {
+	result := []string{}
+	for params != "" {
+		idx := indexTopLevel(params, ',')
+		if idx == -1 {
+			idx = len(params)
+		}
+
+		if param := strings.TrimSpace(params[:idx]); param != "" {
+			result = append(result, param)
+		}
+		if idx == len(params) {
+			break
+		}
+		params = params[idx+1:]
+	}
+
+	return result
+}
+
+// indexTopLevel returns the index of the first c out of brackets and strings, or -1.
+func indexTopLevel(s string, c byte) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case depth == 0 && s[i] == c:
+			return i
+		case s[i] == '(' || s[i] == '[' || s[i] == '{':
+			depth++
+		case s[i] == ')' || s[i] == ']' || s[i] == '}':
+			depth--
+		}
+	}
+
+	return -1
+}
+
+// closingBrackets maps the opening brackets to the closing ones.
+var closingBrackets = map[byte]byte{'(': ')', '[': ']', '{': '}'}
+
+// matchingBracket returns the index of the bracket closing the one at open, or -1.
+func matchingBracket(s string, open int) int {
+	close := indexTopLevel(s[open+1:], closingBrackets[s[open]])
+	if close == -1 {
+		return -1
+	}
+
+	return open + 1 + close
+}
+
+// normalize joins the lines of a signature or an annotation into one, without a trailing comma.
+func normalize(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.NewReplacer("( ", "(", " )", ")", "[ ", "[", " ]", "]", ",)", ")", ",]", "]").Replace(s)
+	return strings.TrimSuffix(s, ",")
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, c := range s {
+		if c != '_' && !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(i > 0 && c >= '0' && c <= '9') && c < 0x80 {
+			return false
+		}
+	}
+
+	return true
+}
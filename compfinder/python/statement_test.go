@@ -0,0 +1,160 @@
+package python
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDef(t *testing.T) {
+	testCases := []struct {
+		name   string
+		stmt   string
+		expDef definition
+		expOk  bool
+	}{
+		{
+			name:   "Function with type hints",
+			stmt:   "def get(key: str, default: dict[str, int] = {}) -> int | None:",
+			expDef: definition{name: "get", params: "key: str, default: dict[str, int] = {}", result: "int | None"},
+			expOk:  true,
+		},
+		{
+			name:   "Async function on several lines with its body",
+			stmt:   "async def fetch(\n    url: str,\n) -> bytes: return b''",
+			expDef: definition{name: "fetch", async: true, params: "url: str", result: "bytes", body: "return b''"},
+			expOk:  true,
+		},
+		{
+			name:   "Type parameters",
+			stmt:   "def first[T](items: list[T]) -> T:",
+			expDef: definition{name: "first", params: "items: list[T]", result: "T"},
+			expOk:  true,
+		},
+		{
+			name:  "Not a definition",
+			stmt:  "define = 1",
+			expOk: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			def, ok := parseDef(tc.stmt)
+
+			assert.Equal(t, tc.expOk, ok)
+			if tc.expOk {
+				assert.Equal(t, tc.expDef, def)
+			}
+		})
+	}
+}
+
+func TestParseClass(t *testing.T) {
+	testCases := []struct {
+		name   string
+		stmt   string
+		expDef definition
+		expOk  bool
+	}{
+		{
+			name:   "Class with bases",
+			stmt:   "class User(Base, metaclass=Meta):",
+			expDef: definition{name: "User", params: "Base, metaclass=Meta"},
+			expOk:  true,
+		},
+		{
+			name:   "Class with type parameters and its body",
+			stmt:   "class Box[T]: pass",
+			expDef: definition{name: "Box", body: "pass"},
+			expOk:  true,
+		},
+		{
+			name:  "Not a class",
+			stmt:  "classes = []",
+			expOk: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			def, ok := parseClass(tc.stmt)
+
+			assert.Equal(t, tc.expOk, ok)
+			if tc.expOk {
+				assert.Equal(t, tc.expDef, def)
+			}
+		})
+	}
+}
+
+func TestParseField(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stmt     string
+		expField string
+		expOk    bool
+	}{
+		{
+			name:     "Annotated field with a default",
+			stmt:     `tags: list[str] = Field(default_factory=list, description="a=b")`,
+			expField: "tags: list[str]",
+			expOk:    true,
+		},
+		{
+			name:  "Assignment without annotation",
+			stmt:  "count = 0",
+			expOk: false,
+		},
+		{
+			name:  "Keyword",
+			stmt:  "else:",
+			expOk: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			field, ok := parseField(tc.stmt)
+
+			assert.Equal(t, tc.expOk, ok)
+			assert.Equal(t, tc.expField, field)
+		})
+	}
+}
+
+func TestParseDocstring(t *testing.T) {
+	testCases := []struct {
+		name   string
+		stmt   string
+		expDoc string
+		expOk  bool
+	}{
+		{
+			name:   "Multi-line docstring",
+			stmt:   "\"\"\"Summary.\n\n    Details\n      indented.\n    \"\"\"",
+			expDoc: "Summary.\n\nDetails\n  indented.",
+			expOk:  true,
+		},
+		{
+			name:   "Raw one-line docstring",
+			stmt:   `r'Match \d.'`,
+			expDoc: `Match \d.`,
+			expOk:  true,
+		},
+		{
+			name:  "Expression of strings",
+			stmt:  `"a" + "b"`,
+			expOk: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, ok := parseDocstring(tc.stmt)
+
+			assert.Equal(t, tc.expOk, ok)
+			assert.Equal(t, tc.expDoc, doc)
+		})
+	}
+}
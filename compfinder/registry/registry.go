@@ -0,0 +1,102 @@
+// Package registry keeps the finders that can be enabled by name. The finder packages register
+// their finders when they are imported, like the database/sql drivers.
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/burwei/repoexplainer/reportgen"
+)
+
+// Option is an option of a finder.
+type Option struct {
+	Name        string // Name is the name of the option, e.g. "tests"
+	Default     string // Default is the value of the option if it's not set
+	Description string
+}
+
+// Finder describes a finder that can be created by name.
+type Finder struct {
+	Name        string   // Name identifies the finder, e.g. "go"
+	Language    string   // Language is the language of the files the finder reads, "any" if it reads every file
+	Description string   // Description tells what the finder finds
+	Default     bool     // Default finders are enabled unless they are disabled
+	Options     []Option // Options are the options the finder accepts
+
+	// New creates the finder with the given options. Every option of Options is set,
+	// to its default value if it's not given.
+	New func(options map[string]string) (reportgen.ComponentFinder, error)
+}
+
+var (
+	mu      sync.Mutex
+	finders = map[string]Finder{}
+)
+
+// Register makes a finder available by its name. It panics if a finder with the same name is already registered.
+func Register(finder Finder) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := finders[finder.Name]; ok {
+		panic(fmt.Sprintf("registry: finder %q registered twice", finder.Name))
+	}
+
+	finders[finder.Name] = finder
+}
+
+// Lookup returns the registered finder with the name.
+func Lookup(name string) (Finder, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	finder, ok := finders[name]
+	return finder, ok
+}
+
+// All returns the registered finders sorted by name.
+func All() []Finder {
+	mu.Lock()
+	defer mu.Unlock()
+
+	all := make([]Finder, 0, len(finders))
+	for _, finder := range finders {
+		all = append(all, finder)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+// Create creates the finder with the given options. The options that aren't given get their default values,
+// and unknown options are rejected.
+func (f Finder) Create(options map[string]string) (reportgen.ComponentFinder, error) {
+	values := map[string]string{}
+	for _, option := range f.Options {
+		values[option.Name] = option.Default
+	}
+
+	for name, value := range options {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("finder %s has no option %q", f.Name, name)
+		}
+		values[name] = value
+	}
+
+	return f.New(values)
+}
+
+// BoolOption parses the value of a boolean option.
+func BoolOption(options map[string]string, name string) (bool, error) {
+	value, err := strconv.ParseBool(options[name])
+	if err != nil {
+		return false, fmt.Errorf("option %s must be true or false, not %q", name, options[name])
+	}
+
+	return value, nil
+}
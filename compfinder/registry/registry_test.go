@@ -0,0 +1,82 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFinderCreate(t *testing.T) {
+	var created map[string]string
+	finder := Finder{
+		Name:    "fake",
+		Options: []Option{{Name: "tests", Default: "true"}, {Name: "depth", Default: "1"}},
+		New: func(options map[string]string) (reportgen.ComponentFinder, error) {
+			created = options
+			return nil, nil
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		options    map[string]string
+		expOptions map[string]string
+		expErr     bool
+	}{
+		{
+			name:       "Default options",
+			expOptions: map[string]string{"tests": "true", "depth": "1"},
+		},
+		{
+			name:       "Given options override the defaults",
+			options:    map[string]string{"tests": "false"},
+			expOptions: map[string]string{"tests": "false", "depth": "1"},
+		},
+		{
+			name:    "Unknown option",
+			options: map[string]string{"colors": "true"},
+			expErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			created = nil
+			_, err := finder.Create(tc.options)
+
+			if tc.expErr {
+				assert.Error(t, err)
+				assert.Nil(t, created)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expOptions, created)
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register(Finder{Name: "zz-test", Language: "Test"})
+
+	finder, ok := Lookup("zz-test")
+	assert.True(t, ok)
+	assert.Equal(t, "Test", finder.Language)
+
+	all := All()
+	assert.Equal(t, "zz-test", all[len(all)-1].Name)
+
+	assert.Panics(t, func() { Register(Finder{Name: "zz-test"}) })
+
+	_, ok = Lookup("missing")
+	assert.False(t, ok)
+}
+
+func TestBoolOption(t *testing.T) {
+	value, err := BoolOption(map[string]string{"tests": "false"}, "tests")
+	assert.NoError(t, err)
+	assert.False(t, value)
+
+	_, err = BoolOption(map[string]string{"tests": "maybe"}, "tests")
+	assert.Error(t, err)
+}
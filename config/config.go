@@ -0,0 +1,354 @@
+// Package config loads the configuration of repoexplainer from YAML files: the user config in the XDG config
+// directory and the .repoexplainer.yaml of the repo, which overrides it.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/burwei/repoexplainer/compfinder"
+	"github.com/burwei/repoexplainer/compfinder/plugin"
+	"github.com/burwei/repoexplainer/reportgen"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FileName     = ".repoexplainer.yaml" // FileName is the name of the config file of a repo
+	UserFileName = "config.yaml"         // UserFileName is the name of the user config file in the repoexplainer config directory
+)
+
+// Config is the configuration of a run. Every field can be set in the config files.
+type Config struct {
+	Format     string `yaml:"format"`     // Format is the output format, see reportgen.Options.Format
+	Visibility string `yaml:"visibility"` // Visibility selects the components shown, see reportgen.Options.Visibility
+
+	// Template is the path to a text/template file the report is wrapped in, see app.WithTemplate.
+	// A relative path is relative to the directory of the config file.
+	Template string `yaml:"template"`
+
+	Filters  Filters  `yaml:"filters"`
+	Finders  Finders  `yaml:"finders"`
+	Budgets  Budgets  `yaml:"budgets"`
+	Sections Sections `yaml:"sections"`
+
+	Cache   bool `yaml:"cache"`   // Cache keeps the findings of every file, see reportgen.Options.Cache
+	Workers int  `yaml:"workers"` // Workers is the number of files scanned in parallel, 0 means the number of CPUs
+
+	// Sources are the config files the configuration has been loaded from, in the order they were loaded.
+	Sources []string `yaml:"-"`
+}
+
+// Filters select the files and the source code shown in the report.
+type Filters struct {
+	Exclude            []string `yaml:"exclude"`              // Exclude are glob patterns of the files and directories left out
+	IncludeFiles       []string `yaml:"include-files"`        // IncludeFiles are glob patterns of the files shown as a whole
+	IncludeBodies      []string `yaml:"include-bodies"`       // IncludeBodies are the symbols whose source code is shown
+	IncludeBodiesUnder int      `yaml:"include-bodies-under"` // IncludeBodiesUnder shows the funcs shorter than this number of lines
+}
+
+// Finders select the finders and their options.
+type Finders struct {
+	Enable  []string                     `yaml:"enable"`  // Enable are the finders enabled besides the default ones
+	Disable []string                     `yaml:"disable"` // Disable are the finders left out
+	Options map[string]map[string]string `yaml:"options"` // Options are the options of the finders by finder name
+	Plugins []string                     `yaml:"plugins"` // Plugins are declared like the --plugin flag, e.g. "*.dsl=dsl-finder"
+}
+
+// Budgets limit the size of the report.
+type Budgets struct {
+	MaxTokens   int `yaml:"max-tokens"`   // MaxTokens is the approximate number of tokens the report fits into, 0 means no limit
+	ChunkTokens int `yaml:"chunk-tokens"` // ChunkTokens splits the report into parts of about this number of tokens
+}
+
+// Sections add sections and annotations to the report.
+type Sections struct {
+	Mermaid     bool `yaml:"mermaid"`      // Mermaid adds a class diagram
+	Tests       bool `yaml:"tests"`        // Tests adds a tests section
+	Markers     bool `yaml:"markers"`      // Markers adds a markers section
+	Stats       bool `yaml:"stats"`        // Stats adds a statistics section
+	History     bool `yaml:"history"`      // History annotates the files and packages with their git history
+	HistoryDays int  `yaml:"history-days"` // HistoryDays is the window the commits are counted over
+	Owners      bool `yaml:"owners"`       // Owners annotates the directories and components with their code owners
+
+	// Coverage is the path to a go test -coverprofile file. A relative path is relative to the directory of the config file.
+	Coverage string `yaml:"coverage"`
+}
+
+// Default returns the configuration used when no config file sets anything.
+func Default() *Config {
+	return &Config{
+		Format:     reportgen.FormatMarkdown,
+		Visibility: reportgen.VisibilityAll,
+		Filters: Filters{
+			Exclude:       []string{},
+			IncludeFiles:  []string{},
+			IncludeBodies: []string{},
+		},
+		Finders: Finders{
+			Enable:  []string{},
+			Disable: []string{},
+			Options: map[string]map[string]string{},
+			Plugins: []string{},
+		},
+		Sections: Sections{HistoryDays: reportgen.DefaultHistoryDays},
+	}
+}
+
+// UserPath returns the path to the user config file: repoexplainer/config.yaml in $XDG_CONFIG_HOME,
+// or in ~/.config if it's not set. It returns an empty string if the home directory is unknown.
+func UserPath() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configDir, "repoexplainer", UserFileName)
+}
+
+// FindRepoFile looks for the .repoexplainer.yaml of the repo in rootPath and its parent directories,
+// up to the root of the git repository. It returns an empty string if there's none.
+func FindRepoFile(rootPath string) string {
+	dir := filepath.Clean(rootPath)
+	for {
+		filePath := filepath.Join(dir, FileName)
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath
+		}
+
+		// Don't look beyond the root of the git repository
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load returns the configuration of the repo in rootPath: the defaults, overridden by the user config,
+// overridden by the config of the repo. The config files that don't exist are skipped.
+// The config of the repo is loaded with LoadRepoFile, since the repo might not be trusted.
+func Load(rootPath string) (*Config, error) {
+	config := Default()
+
+	loaders := []struct {
+		filePath string
+		load     func(filePath string) error
+	}{
+		{filePath: UserPath(), load: config.LoadFile},
+		{filePath: FindRepoFile(rootPath), load: config.LoadRepoFile},
+	}
+	for _, loader := range loaders {
+		if loader.filePath == "" {
+			continue
+		}
+
+		err := loader.load(loader.filePath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// LoadFile overrides the configuration with the values set in a config file.
+// The finder options are overridden option by option, the other lists are replaced as a whole.
+func (c *Config) LoadFile(filePath string) error {
+	return c.loadFile(filePath, true)
+}
+
+// LoadRepoFile overrides the configuration with the values set in the config file of a repo, which
+// might not be trusted: it can't declare plugins, which run commands, and its template and coverage
+// paths must be in the directory of the file, so it can't put other files of the machine into the report.
+func (c *Config) LoadRepoFile(filePath string) error {
+	return c.loadFile(filePath, false)
+}
+
+func (c *Config) loadFile(filePath string, trusted bool) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	template := c.Template
+	coverage := c.Sections.Coverage
+	plugins := c.Finders.Plugins
+	c.Finders.Plugins = nil
+	finderOptions := map[string]map[string]string{}
+	for name, options := range c.Finders.Options {
+		finderOptions[name] = options
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err = decoder.Decode(c)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("reading config file %s: %s", filePath, err)
+	}
+
+	if c.Finders.Plugins == nil {
+		c.Finders.Plugins = plugins
+	} else if !trusted {
+		return fmt.Errorf("reading config file %s: plugins can only be declared in the user config file %s or with --plugin", filePath, UserPath())
+	}
+
+	// The paths set in the file are relative to the file
+	dir := filepath.Dir(filePath)
+	if c.Template != template && c.Template != "" {
+		c.Template, err = resolvePath(dir, c.Template, trusted)
+		if err != nil {
+			return fmt.Errorf("reading config file %s: template: %s", filePath, err)
+		}
+	}
+	if c.Sections.Coverage != coverage && c.Sections.Coverage != "" {
+		c.Sections.Coverage, err = resolvePath(dir, c.Sections.Coverage, trusted)
+		if err != nil {
+			return fmt.Errorf("reading config file %s: coverage: %s", filePath, err)
+		}
+	}
+
+	if c.Finders.Options == nil {
+		c.Finders.Options = map[string]map[string]string{}
+	}
+	for name, options := range finderOptions {
+		for key, value := range options {
+			if _, ok := c.Finders.Options[name][key]; ok {
+				continue
+			}
+			if c.Finders.Options[name] == nil {
+				c.Finders.Options[name] = map[string]string{}
+			}
+			c.Finders.Options[name][key] = value
+		}
+	}
+
+	c.Sources = append(c.Sources, filePath)
+
+	return nil
+}
+
+// resolvePath returns the path set in a config file in dir, relative to dir if it's not absolute.
+// The path of an untrusted file must stay in dir once the symbolic links are followed.
+func resolvePath(dir, path string, trusted bool) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	if trusted {
+		return path, nil
+	}
+
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// A file that doesn't exist yet can't lead out of dir, but its path can
+	resolved, err := filepath.EvalSymlinks(path)
+	if errors.Is(err, os.ErrNotExist) {
+		resolved, err = filepath.Abs(path)
+		resolvedDir = dir
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(resolvedDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is out of the directory of the config file", path)
+	}
+
+	return resolved, nil
+}
+
+// Options returns the options of the report.
+func (c *Config) Options() reportgen.Options {
+	return reportgen.Options{
+		MaxTokens:   c.Budgets.MaxTokens,
+		ChunkTokens: c.Budgets.ChunkTokens,
+		Visibility:  c.Visibility,
+		Mermaid:     c.Sections.Mermaid,
+		Format:      c.Format,
+
+		IncludeBodies:      c.Filters.IncludeBodies,
+		IncludeBodiesUnder: c.Filters.IncludeBodiesUnder,
+		IncludeFiles:       c.Filters.IncludeFiles,
+		Exclude:            c.Filters.Exclude,
+		Tests:              c.Sections.Tests,
+		Markers:            c.Sections.Markers,
+		History:            c.Sections.History,
+		HistoryDays:        c.Sections.HistoryDays,
+		Owners:             c.Sections.Owners,
+		Cache:              c.Cache,
+		Workers:            c.Workers,
+		CoverageProfile:    c.Sections.Coverage,
+		Stats:              c.Sections.Stats,
+	}
+}
+
+// FinderConfig returns the config of the finder factory.
+func (c *Config) FinderConfig() (compfinder.Config, error) {
+	finderConfig := compfinder.Config{
+		Enable:  c.Finders.Enable,
+		Disable: c.Finders.Disable,
+		Options: c.Finders.Options,
+	}
+
+	for _, value := range c.Finders.Plugins {
+		pluginConfig, err := plugin.ParseFlag(value)
+		if err != nil {
+			return compfinder.Config{}, err
+		}
+		finderConfig.Plugins = append(finderConfig.Plugins, pluginConfig)
+	}
+
+	return finderConfig, nil
+}
+
+// ReadTemplate returns the content of the template file, or an empty string if there's no template.
+func (c *Config) ReadTemplate() (string, error) {
+	if c.Template == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(c.Template)
+	if err != nil {
+		return "", fmt.Errorf("reading template: %s", err)
+	}
+
+	return string(data), nil
+}
+
+// Show writes the configuration as YAML, after a comment listing the files it has been loaded from.
+func (c *Config) Show(w io.Writer) error {
+	if len(c.Sources) == 0 {
+		fmt.Fprintln(w, "# No config files, the defaults are used")
+	}
+	for _, source := range c.Sources {
+		fmt.Fprintf(w, "# Loaded from %s\n", source)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	err := encoder.Encode(c)
+	if err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/burwei/repoexplainer/reportgen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	testCases := []struct {
+		name       string
+		userConfig string               // userConfig is the content of the user config file, none if empty
+		repoConfig string               // repoConfig is the content of the .repoexplainer.yaml of the repo, none if empty
+		setup      func(repoDir string) // setup adds files to the repo, if not nil
+		check      func(t *testing.T, repoDir string, config *Config)
+		expErr     bool
+	}{
+		{
+			name: "Defaults without config files",
+			check: func(t *testing.T, repoDir string, config *Config) {
+				assert.Equal(t, reportgen.FormatMarkdown, config.Format)
+				assert.Equal(t, reportgen.DefaultHistoryDays, config.Sections.HistoryDays)
+				assert.Empty(t, config.Sources)
+			},
+		},
+		{
+			name:       "The repo config overrides the user config",
+			userConfig: "format: html\nsections:\n  stats: true\n  markers: true\nfilters:\n  exclude: [vendor]\n",
+			repoConfig: "format: markdown\nsections:\n  markers: false\nbudgets:\n  max-tokens: 8000\n",
+			check: func(t *testing.T, repoDir string, config *Config) {
+				assert.Equal(t, reportgen.FormatMarkdown, config.Format)
+				assert.True(t, config.Sections.Stats)
+				assert.False(t, config.Sections.Markers)
+				assert.Equal(t, []string{"vendor"}, config.Filters.Exclude)
+				assert.Equal(t, 8000, config.Budgets.MaxTokens)
+				assert.Len(t, config.Sources, 2)
+				assert.Equal(t, filepath.Join(repoDir, FileName), config.Sources[1])
+			},
+		},
+		{
+			name:       "Finder options are merged option by option",
+			userConfig: "finders:\n  options:\n    go:\n      tests: false\n      unexported: false\n",
+			repoConfig: "finders:\n  disable: [markers]\n  options:\n    go:\n      unexported: true\n",
+			check: func(t *testing.T, repoDir string, config *Config) {
+				assert.Equal(t, map[string]map[string]string{"go": {"tests": "false", "unexported": "true"}}, config.Finders.Options)
+				assert.Equal(t, []string{"markers"}, config.Finders.Disable)
+			},
+		},
+		{
+			name:       "Paths are relative to the config file",
+			repoConfig: "template: prompt.tmpl\nsections:\n  coverage: coverage.out\n",
+			check: func(t *testing.T, repoDir string, config *Config) {
+				assert.Equal(t, filepath.Join(repoDir, "prompt.tmpl"), config.Template)
+				assert.Equal(t, filepath.Join(repoDir, "coverage.out"), config.Sections.Coverage)
+			},
+		},
+		{
+			name:       "Plugins of the user config",
+			userConfig: "finders:\n  plugins: ['*.dsl=dsl-finder']\n",
+			repoConfig: "format: html\n",
+			check: func(t *testing.T, repoDir string, config *Config) {
+				assert.Equal(t, []string{"*.dsl=dsl-finder"}, config.Finders.Plugins)
+			},
+		},
+		{
+			name:       "Plugins of the repo config are rejected",
+			repoConfig: "finders:\n  plugins: ['*.go=sh -c touch${IFS}/tmp/PWNED']\n",
+			expErr:     true,
+		},
+		{
+			name:       "Template out of the repo is rejected",
+			repoConfig: "template: ../../etc/passwd\n",
+			expErr:     true,
+		},
+		{
+			name:       "Absolute coverage path out of the repo is rejected",
+			repoConfig: "sections:\n  coverage: /etc/passwd\n",
+			expErr:     true,
+		},
+		{
+			name:       "Template linked out of the repo is rejected",
+			repoConfig: "template: link.tmpl\n",
+			setup: func(repoDir string) {
+				os.Symlink("/etc/passwd", filepath.Join(repoDir, "link.tmpl"))
+			},
+			expErr: true,
+		},
+		{
+			name:       "Template out of the repo set by the user config",
+			userConfig: "template: /etc/passwd\n",
+			check: func(t *testing.T, repoDir string, config *Config) {
+				assert.Equal(t, "/etc/passwd", config.Template)
+			},
+		},
+		{
+			name:       "Unknown keys are rejected",
+			repoConfig: "fromat: html\n",
+			expErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userDir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", userDir)
+			if tc.userConfig != "" {
+				os.MkdirAll(filepath.Join(userDir, "repoexplainer"), 0755)
+				os.WriteFile(filepath.Join(userDir, "repoexplainer", UserFileName), []byte(tc.userConfig), 0644)
+			}
+
+			repoDir := t.TempDir()
+			os.Mkdir(filepath.Join(repoDir, ".git"), 0755)
+			if tc.repoConfig != "" {
+				os.WriteFile(filepath.Join(repoDir, FileName), []byte(tc.repoConfig), 0644)
+			}
+			if tc.setup != nil {
+				tc.setup(repoDir)
+			}
+
+			config, err := Load(repoDir)
+
+			if tc.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tc.check(t, repoDir, config)
+		})
+	}
+}
+
+func TestFindRepoFile(t *testing.T) {
+	repoDir := t.TempDir()
+	subDir := filepath.Join(repoDir, "cmd", "tool")
+	os.MkdirAll(subDir, 0755)
+	os.Mkdir(filepath.Join(repoDir, ".git"), 0755)
+
+	assert.Empty(t, FindRepoFile(subDir))
+
+	os.WriteFile(filepath.Join(repoDir, FileName), []byte("format: html\n"), 0644)
+	assert.Equal(t, filepath.Join(repoDir, FileName), FindRepoFile(subDir))
+}
+
+func TestConfigShow(t *testing.T) {
+	config := Default()
+	config.Finders.Plugins = []string{"*.dsl=dsl-finder"}
+	config.Sources = []string{"/repo/.repoexplainer.yaml"}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, config.Show(&buffer))
+
+	assert.Contains(t, buffer.String(), "# Loaded from /repo/.repoexplainer.yaml\n")
+	assert.Contains(t, buffer.String(), "format: markdown\n")
+	assert.Contains(t, buffer.String(), "history-days: 90\n")
+	assert.NotContains(t, buffer.String(), "sources")
+
+	finderConfig, err := config.FinderConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dsl-finder"}, finderConfig.Plugins[0].Command)
+	assert.NoError(t, config.Options().Validate())
+}
@@ -0,0 +1,139 @@
+package reportgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// budgetSteps are the ways to shrink the report, from the least to the most lossy.
+// They are applied one after another until the report fits into the token budget.
+var budgetSteps = []func(level *detailLevel){
+	func(level *detailLevel) { level.dropUnexported = true },
+	func(level *detailLevel) { level.collapseFields = true },
+	func(level *detailLevel) { level.dropExcerpts = true },
+	func(level *detailLevel) { level.treeDepth = 3 },
+	func(level *detailLevel) { level.treeDepth = 2 },
+	func(level *detailLevel) { level.treeDepth = 1 },
+	func(level *detailLevel) { level.dropTests = true },
+}
+
+// EstimateTokens approximates the number of tokens a language model tokenizer
+// produces for the text. It follows the usual behavior of BPE tokenizers:
+// short words are one token, long words are split into chunks of about 4 characters,
+// punctuation marks are tokens of their own and a single space sticks to the next word.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordLen := 0
+	spaces := 0
+
+	flushWord := func() {
+		if wordLen > 0 {
+			tokens += (wordLen + 3) / 4
+			wordLen = 0
+		}
+	}
+
+	flushSpaces := func() {
+		if spaces > 1 {
+			tokens++
+		}
+		spaces = 0
+	}
+
+	for _, r := range text {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushSpaces()
+			wordLen++
+		case r == ' ':
+			flushWord()
+			spaces++
+		case unicode.IsSpace(r):
+			// Newlines and tabs are merged with the surrounding whitespace
+			flushWord()
+			spaces += 2
+		default:
+			// Punctuation and non-ASCII characters
+			flushWord()
+			flushSpaces()
+			tokens++
+		}
+	}
+
+	flushWord()
+	flushSpaces()
+
+	return tokens
+}
+
+// renderWithinBudget renders the report with as many details as fit into maxTokens.
+// The details that have been left out are listed at the end of the report.
+func (rg *ReportGenerator) renderWithinBudget(outputCompMap OutputComponentMap, maxTokens int) (string, error) {
+	level := detailLevel{}
+	report, err := rg.renderBudgetedMarkdown(outputCompMap, level, maxTokens)
+	if err != nil {
+		return "", err
+	}
+
+	for _, step := range budgetSteps {
+		if EstimateTokens(report) <= maxTokens {
+			break
+		}
+
+		step(&level)
+		report, err = rg.renderBudgetedMarkdown(outputCompMap, level, maxTokens)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if tokens := EstimateTokens(report); tokens > maxTokens {
+		report += fmt.Sprintf("\nThe report is still about %d tokens long, which exceeds the budget of %d tokens.\n", tokens, maxTokens)
+	}
+
+	return report, nil
+}
+
+func (rg *ReportGenerator) renderBudgetedMarkdown(outputCompMap OutputComponentMap, level detailLevel, maxTokens int) (string, error) {
+	report, omitted, err := rg.renderMarkdown(outputCompMap, level)
+	if err != nil {
+		return "", err
+	}
+
+	if !omitted.any() {
+		return report, nil
+	}
+
+	return report + omittedSection(omitted, maxTokens), nil
+}
+
+func omittedSection(omitted omissions, maxTokens int) string {
+	var builder strings.Builder
+	builder.WriteString("\n\n## Omitted\n")
+	builder.WriteString(fmt.Sprintf("To fit into about %d tokens, the following details are left out:\n", maxTokens))
+
+	if omitted.unexportedComps > 0 {
+		builder.WriteString(fmt.Sprintf(" - %d unexported and internal components\n", omitted.unexportedComps))
+	}
+	if omitted.unexportedMembers > 0 {
+		builder.WriteString(fmt.Sprintf(" - %d unexported fields and methods\n", omitted.unexportedMembers))
+	}
+	if omitted.collapsedFields > 0 {
+		builder.WriteString(fmt.Sprintf(" - %d fields, only the number of fields is shown\n", omitted.collapsedFields))
+	}
+	if omitted.excerpts > 0 {
+		builder.WriteString(fmt.Sprintf(" - %d source excerpts\n", omitted.excerpts))
+	}
+	if omitted.summarizedDirs > 0 {
+		builder.WriteString(fmt.Sprintf(" - the content of %d deep directories, only the number of files is shown\n", omitted.summarizedDirs))
+	}
+	if omitted.testFiles > 0 {
+		builder.WriteString(fmt.Sprintf(" - %d test files\n", omitted.testFiles))
+	}
+	if omitted.testComps > 0 {
+		builder.WriteString(fmt.Sprintf(" - %d components defined in test files\n", omitted.testComps))
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,150 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeFinder is a ComponentFinder that returns a fixed set of components.
+type fakeFinder struct {
+	components ComponentMap
+}
+
+func (f *fakeFinder) SetFile(filePath string)     {}
+func (f *fakeFinder) FindComponent(line string)   {}
+func (f *fakeFinder) GetComponents() ComponentMap { return f.components }
+
+type fakeFinderFactory struct {
+	finders []ComponentFinder
+}
+
+func (ff *fakeFinderFactory) GetFinders() []ComponentFinder { return ff.finders }
+
+func TestEstimateTokens(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{
+			name:     "Empty text",
+			text:     "",
+			expected: 0,
+		},
+		{
+			name:     "Short words separated by single spaces",
+			text:     "the big fox",
+			expected: 3,
+		},
+		{
+			name:     "Long word is split into chunks",
+			text:     "findCodeStructuresInFiles",
+			expected: 7,
+		},
+		{
+			name:     "Punctuation and indentation",
+			text:     "\t- file: a.go\n",
+			expected: 8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, EstimateTokens(tc.text))
+		})
+	}
+}
+
+func TestGenerateReportWithinBudget(t *testing.T) {
+	testCases := []struct {
+		name        string
+		maxTokens   int
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:      "Budget large enough for the full report",
+			maxTokens: 10000,
+			contains:  []string{"helper() error", "secret string", "- server_test.go", "TestServer(t *testing.T)"},
+			notContains: []string{
+				"## Omitted",
+			},
+		},
+		{
+			name:      "Unexported members are dropped first",
+			maxTokens: 230,
+			contains: []string{
+				"Start() error",
+				"Addr string",
+				"## Omitted",
+				"1 unexported and internal components",
+				"2 unexported fields and methods",
+			},
+			notContains: []string{"helper() error", "secret string"},
+		},
+		{
+			name:      "Tiny budget drops everything that can be dropped",
+			maxTokens: 10,
+			contains: []string{
+				"/b (2 files)",
+				"1 test files",
+				"1 components defined in test files",
+				"exceeds the budget of 10 tokens",
+			},
+			notContains: []string{"TestServer(t *testing.T)", "- server_test.go"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			deepDir := filepath.Join(tmpDir, "a", "b", "c", "deep")
+			os.MkdirAll(deepDir, 0755)
+			os.WriteFile(filepath.Join(tmpDir, "server.go"), []byte("package server"), 0644)
+			os.WriteFile(filepath.Join(tmpDir, "server_test.go"), []byte("package server"), 0644)
+			os.WriteFile(filepath.Join(deepDir, "x.go"), []byte("package deep"), 0644)
+			os.WriteFile(filepath.Join(deepDir, "y.go"), []byte("package deep"), 0644)
+
+			finder := &fakeFinder{components: ComponentMap{
+				tmpDir + ":Server": Component{
+					File:    filepath.Join(tmpDir, "server.go"),
+					Package: "server",
+					Name:    "Server",
+					Type:    "struct",
+					Fields:  []string{"Addr string", "secret string"},
+					Methods: []string{"Start() error", "helper() error"},
+				},
+				tmpDir + ":newServer": Component{
+					File:    filepath.Join(tmpDir, "server.go"),
+					Package: "server",
+					Name:    "newServer() *Server",
+					Type:    "func",
+				},
+				tmpDir + ":TestServer": Component{
+					File:    filepath.Join(tmpDir, "server_test.go"),
+					Package: "server",
+					Name:    "TestServer(t *testing.T)",
+					Type:    "func",
+				},
+			}}
+
+			rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+			rg.SetOptions(Options{MaxTokens: tc.maxTokens})
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, buffer.String(), s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, buffer.String(), s)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package reportgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// CacheDir is the directory of the cache relative to the root directory.
+// It's hidden, so the cache itself is never scanned.
+const CacheDir = ".repoexplainer/cache"
+
+// fileCache stores the findings of the finders file by file, keyed by the path and the content
+// of the file and the versions of the finders. Every entry is a JSON file in the cache directory.
+type fileCache struct {
+	dir     string
+	finders []FileResultFinder
+	version string // version combines the versions of the finders
+	mu      sync.Mutex
+	used    map[string]bool // used are the names of the entries read or written by this run
+}
+
+// cacheEntry is what's cached for a file.
+type cacheEntry struct {
+	Lines    int               `json:"lines"`
+	Code     int               `json:"code"`
+	Comments int               `json:"comments"`
+	Blank    int               `json:"blank"`
+	Results  []json.RawMessage `json:"results"` // Results are the findings of every finder, in the order of the finders
+}
+
+// newFileCache opens the cache in the root directory. Every finder must be a FileResultFinder,
+// since a file is only skipped if the findings of all the finders are cached.
+func newFileCache(rootPath string, finders []ComponentFinder) (*fileCache, error) {
+	cache := &fileCache{
+		dir:  filepath.Join(rootPath, filepath.FromSlash(CacheDir)),
+		used: map[string]bool{},
+	}
+
+	versions := make([]string, 0, len(finders))
+	for _, finder := range finders {
+		resultFinder, ok := finder.(FileResultFinder)
+		if !ok {
+			return nil, fmt.Errorf("finder %T doesn't support caching", finder)
+		}
+
+		cache.finders = append(cache.finders, resultFinder)
+		versions = append(versions, resultFinder.Version())
+	}
+	cache.version = strings.Join(versions, ",")
+
+	if err := os.MkdirAll(cache.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+// entryName returns the name of the entry of a file with the given content.
+func (fc *fileCache) entryName(filePath string, content []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(fc.version + "\x00" + filePath + "\x00"))
+	hash.Write(content)
+
+	return hex.EncodeToString(hash.Sum(nil)) + ".json"
+}
+
+// load returns the entry of a file with the given content, ok is false if there's no valid entry.
+func (fc *fileCache) load(filePath string, content []byte) (entry cacheEntry, ok bool) {
+	name := fc.entryName(filePath, content)
+	data, err := os.ReadFile(filepath.Join(fc.dir, name))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	if err := json.Unmarshal(data, &entry); err != nil || len(entry.Results) != len(fc.finders) {
+		return cacheEntry{}, false
+	}
+	fc.markUsed(name)
+
+	return entry, true
+}
+
+// restore restores the findings of a file from its entry into the finders of the cache and returns
+// its line counts. ok is false if there's no valid entry, in which case the file must be scanned.
+func (fc *fileCache) restore(filePath string, content []byte) (stats FileStats, ok bool) {
+	entry, ok := fc.load(filePath, content)
+	if !ok {
+		return FileStats{}, false
+	}
+
+	for i, finder := range fc.finders {
+		if err := finder.RestoreFileResult(filePath, entry.Results[i]); err != nil {
+			return FileStats{}, false
+		}
+	}
+
+	return entry.stats(filePath), true
+}
+
+// store writes the entry of a file with the findings of every finder, in the order of the finders.
+func (fc *fileCache) store(filePath string, content []byte, stats FileStats, results []json.RawMessage) error {
+	data, err := json.Marshal(cacheEntry{
+		Lines:    stats.Lines,
+		Code:     stats.Code,
+		Comments: stats.Comments,
+		Blank:    stats.Blank,
+		Results:  results,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so an interrupted run never leaves a partial entry
+	name := fc.entryName(filePath, content)
+	tmp, err := os.CreateTemp(fc.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	fc.markUsed(name)
+
+	return os.Rename(tmp.Name(), filepath.Join(fc.dir, name))
+}
+
+// markUsed keeps an entry from being pruned. Workers scanning in parallel share the cache.
+func (fc *fileCache) markUsed(name string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.used[name] = true
+}
+
+func (entry cacheEntry) stats(filePath string) FileStats {
+	return FileStats{
+		Path:     filePath,
+		Lines:    entry.Lines,
+		Code:     entry.Code,
+		Comments: entry.Comments,
+		Blank:    entry.Blank,
+	}
+}
+
+// fileResults returns the findings of the current file of every finder,
+// and whether they are complete, so they can be cached.
+func fileResults(finders []ComponentFinder) ([]json.RawMessage, bool, error) {
+	results := make([]json.RawMessage, 0, len(finders))
+	complete := true
+	for _, finder := range finders {
+		resultFinder, ok := finder.(FileResultFinder)
+		if !ok {
+			return nil, false, fmt.Errorf("finder %T doesn't support file results", finder)
+		}
+
+		result, err := resultFinder.FileResult()
+		if errors.Is(err, ErrIncompleteResult) {
+			complete = false
+		} else if err != nil {
+			return nil, false, err
+		}
+		results = append(results, result)
+	}
+
+	return results, complete, nil
+}
+
+// prune removes the entries not used by this run, i.e. of the files that have changed or are gone.
+func (fc *fileCache) prune() error {
+	entries, err := os.ReadDir(fc.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || fc.used[entry.Name()] {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(fc.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package reportgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResultFinder is a FileResultFinder that finds a func per "func" line and counts the lines it's given.
+// It fails on the files with a "broken" line.
+type fakeResultFinder struct {
+	components ComponentMap
+	filePath   string
+	fileKeys   []string
+	lines      int
+	failed     bool
+}
+
+func newFakeResultFinder() *fakeResultFinder {
+	return &fakeResultFinder{components: ComponentMap{}}
+}
+
+func (f *fakeResultFinder) SetFile(filePath string) {
+	f.filePath = filePath
+	f.fileKeys = nil
+	f.failed = false
+}
+
+func (f *fakeResultFinder) FindComponent(line string) {
+	f.lines++
+	if line == "broken" {
+		f.failed = true
+	}
+	if name, ok := strings.CutPrefix(line, "func "); ok {
+		key := filepath.Dir(f.filePath) + ":" + name
+		f.components[key] = Component{File: f.filePath, Package: "server", Name: name, Type: ComponentTypeFunc}
+		f.fileKeys = append(f.fileKeys, key)
+	}
+}
+
+func (f *fakeResultFinder) GetComponents() ComponentMap { return f.components }
+
+func (f *fakeResultFinder) Version() string { return "fake/1" }
+
+func (f *fakeResultFinder) FileResult() ([]byte, error) {
+	comps := ComponentMap{}
+	for _, key := range f.fileKeys {
+		comps[key] = f.components[key]
+	}
+
+	result, err := json.Marshal(comps)
+	if err == nil && f.failed {
+		err = ErrIncompleteResult
+	}
+
+	return result, err
+}
+
+func (f *fakeResultFinder) RestoreFileResult(filePath string, result []byte) error {
+	comps := ComponentMap{}
+	if err := json.Unmarshal(result, &comps); err != nil {
+		return err
+	}
+
+	for key, comp := range comps {
+		f.components[key] = comp
+	}
+
+	return nil
+}
+
+func TestGenerateReportWithCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte("package server\nfunc A()\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "b.go"), []byte("package server\nfunc B()\n"), 0644)
+
+	generate := func() (string, *fakeResultFinder) {
+		finder := newFakeResultFinder()
+		rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+		rg.SetOptions(Options{Cache: true, Stats: true})
+
+		var buffer bytes.Buffer
+		assert.NoError(t, rg.GenerateReport(&buffer))
+
+		return buffer.String(), finder
+	}
+
+	// The first run scans every file and fills the cache
+	first, finder := generate()
+	assert.Equal(t, 4, finder.lines)
+	entries, _ := os.ReadDir(filepath.Join(tmpDir, CacheDir))
+	assert.Len(t, entries, 2)
+
+	// The second run restores everything from the cache
+	second, finder := generate()
+	assert.Equal(t, 0, finder.lines)
+	assert.Equal(t, first, second)
+	assert.Contains(t, second, "     - A()\n")
+	assert.Contains(t, second, "     - B()\n")
+	assert.Contains(t, second, "| server | /server | 2 | 4 | 0 | 0 | 0 | 0 | 2 | 0 | 0 | 0 |\n")
+
+	// Only the changed file is scanned again, and its old entry is removed
+	os.WriteFile(filepath.Join(tmpDir, "b.go"), []byte("package server\nfunc C()\n\n"), 0644)
+	third, finder := generate()
+	assert.Equal(t, 3, finder.lines)
+	assert.Contains(t, third, "     - A()\n")
+	assert.Contains(t, third, "     - C()\n")
+	entries, _ = os.ReadDir(filepath.Join(tmpDir, CacheDir))
+	assert.Len(t, entries, 2)
+}
+
+func TestGenerateReportWithCacheIncompleteResult(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			tmpDir := t.TempDir()
+			os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte("package server\nfunc A()\n"), 0644)
+			os.WriteFile(filepath.Join(tmpDir, "b.go"), []byte("package server\nfunc B()\nbroken\n"), 0644)
+
+			factory := &fakeCreatorFactory{finder: newFakeResultFinder()}
+			rg := NewReportGenerator("server", tmpDir, factory)
+			rg.SetOptions(Options{Workers: workers, Cache: true})
+
+			var buffer bytes.Buffer
+			assert.NoError(t, rg.GenerateReport(&buffer))
+
+			// The findings of the file the finder failed on are reported, but not cached
+			assert.Contains(t, buffer.String(), "     - B()\n")
+			entries, _ := os.ReadDir(filepath.Join(tmpDir, CacheDir))
+			assert.Len(t, entries, 1)
+		})
+	}
+}
+
+func TestGenerateReportWithCacheUnsupportedFinder(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte("package server\n"), 0644)
+
+	finders := []ComponentFinder{newFakeResultFinder(), &fakeFinder{components: ComponentMap{}}}
+	rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: finders})
+	rg.SetOptions(Options{Cache: true})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.ErrorContains(t, err, "doesn't support caching")
+}
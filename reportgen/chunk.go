@@ -0,0 +1,201 @@
+package reportgen
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// chunk is a part of the report before the title and the part header are added.
+type chunk struct {
+	body      strings.Builder
+	firstDir  *markdownSection // firstDir is the directory the part starts with
+	continued bool             // continued is true if the part starts in the middle of a directory
+	dirComps  int              // dirComps counts the components of the last added directory
+}
+
+// GenerateChunks generates the report split into parts of about Options.ChunkTokens tokens each,
+// so a report too large for one message can be pasted in several.
+// Parts are split at directory (package) boundaries, and only a directory that doesn't fit into
+// one part is split between its components. Every part repeats the report title and the
+// directory heading, and tells which part it is, so each one can be read on its own.
+func (rg *ReportGenerator) GenerateChunks() ([]string, error) {
+	return rg.GenerateChunksContext(context.Background())
+}
+
+// GenerateChunksContext is GenerateChunks stopping the traversal and the scanning of the files
+// as soon as the context is canceled.
+func (rg *ReportGenerator) GenerateChunksContext(ctx context.Context) ([]string, error) {
+	report, err := rg.Analyze(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rg.RenderChunks(report)
+}
+
+// RenderChunks renders a report made by Analyze split into parts like GenerateChunks.
+func (rg *ReportGenerator) RenderChunks(report *Report) ([]string, error) {
+	err := rg.options.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("validating options: %s", err)
+	}
+
+	rg.useReport(report)
+
+	dirStructure, _, err := rg.fileTraverser.printTree(treeOptions{historyDays: rg.treeHistoryDays()})
+	if err != nil {
+		return nil, fmt.Errorf("printing directory structure: %s", err)
+	}
+
+	outputCompMap := report.ComponentMap()
+	sections := rg.renderSections(outputCompMap, detailLevel{}, &omissions{})
+
+	var excerpts []string
+	if rg.options.hasExcerpts() {
+		excerpts, err = rg.renderExcerpts(outputCompMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	chunks := rg.splitIntoChunks(dirStructure, outputCompMap, sections, excerpts, rg.options.ChunkTokens)
+
+	return rg.assembleChunks(chunks), nil
+}
+
+// splitIntoChunks packs the directory sections into chunks of about maxTokens tokens.
+// The tests, the markers, the coverage, the history, the statistics and the source excerpts follow the sections, split between the blocks of each.
+// The first chunk always contains the directory structure. 0 means no limit.
+func (rg *ReportGenerator) splitIntoChunks(dirStructure string, outputCompMap OutputComponentMap, sections []markdownSection, excerpts []string, maxTokens int) []*chunk {
+	// Leave some room for the title, the part header and the footer
+	budget := maxTokens - EstimateTokens(rg.renderTitle()+partHeader(99, 99, &markdownSection{dirPath: rg.rootDirName}, true)+partFooter(99, 99))
+
+	current := &chunk{}
+	current.body.WriteString(renderDirStructure(dirStructure))
+	chunks := []*chunk{current}
+
+	fits := func(text string) bool {
+		return maxTokens <= 0 || EstimateTokens(current.body.String()+text) <= budget
+	}
+
+	for i := range sections {
+		section := &sections[i]
+		whole := section.dirLine() + strings.Join(section.comps, "")
+
+		// Start a new part at the package boundary if the whole package doesn't fit
+		if !fits(whole) && current.firstDir != nil {
+			current = &chunk{}
+			chunks = append(chunks, current)
+		}
+
+		current.addDir(section)
+		for _, comp := range section.comps {
+			// The package doesn't fit into one part, split it between its components
+			if !fits(comp) && current.dirComps > 0 {
+				current = &chunk{continued: true}
+				chunks = append(chunks, current)
+				current.addDir(section)
+			}
+
+			current.body.WriteString(comp)
+			current.dirComps++
+		}
+	}
+
+	// addBlocks adds the blocks of a section, repeating the section heading in every new part
+	addBlocks := func(heading string, blocks []string) {
+		for i, block := range blocks {
+			text := block
+			if i == 0 {
+				text = heading + block
+			}
+
+			if !fits(text) {
+				current = &chunk{}
+				chunks = append(chunks, current)
+				text = strings.TrimLeft(heading+block, "\n")
+			}
+			current.body.WriteString(text)
+		}
+	}
+
+	if rg.options.Tests {
+		addBlocks(testsHeading, rg.renderTests(findTests(outputCompMap)))
+	}
+
+	if rg.options.Markers {
+		if markers := rg.getMarkers(outputCompMap); len(markers) > 0 {
+			addBlocks("", []string{rg.renderMarkers(markers)})
+		}
+	}
+
+	if rg.coverage != nil {
+		addBlocks("", []string{rg.renderCoverage(outputCompMap)})
+	}
+
+	if rg.showsHistory() {
+		addBlocks("", []string{rg.renderHistory(sections)})
+	}
+
+	if rg.options.Stats {
+		addBlocks("", []string{rg.renderStats(outputCompMap)})
+	}
+
+	addBlocks(excerptsHeading, excerpts)
+
+	if rg.options.Mermaid {
+		addBlocks("", []string{rg.renderDiagramSection(sections)})
+	}
+
+	return chunks
+}
+
+func (c *chunk) addDir(section *markdownSection) {
+	if c.firstDir == nil {
+		c.firstDir = section
+		if c.body.Len() == 0 {
+			c.body.WriteString(strings.TrimLeft(componentsHeading, "\n"))
+		} else {
+			c.body.WriteString(componentsHeading)
+		}
+	}
+
+	c.body.WriteString(section.dirLine())
+	c.dirComps = 0
+}
+
+// assembleChunks adds the title and the part headers to the chunks.
+func (rg *ReportGenerator) assembleChunks(chunks []*chunk) []string {
+	parts := make([]string, 0, len(chunks))
+	for i, c := range chunks {
+		var builder strings.Builder
+		builder.WriteString(rg.renderTitle())
+		if len(chunks) > 1 {
+			builder.WriteString(partHeader(i+1, len(chunks), c.firstDir, c.continued))
+		}
+		builder.WriteString(c.body.String())
+		if i < len(chunks)-1 {
+			builder.WriteString(partFooter(i+2, len(chunks)))
+		}
+
+		parts = append(parts, builder.String())
+	}
+
+	return parts
+}
+
+func partHeader(part, total int, firstDir *markdownSection, continued bool) string {
+	switch {
+	case part == 1 || firstDir == nil:
+		return fmt.Sprintf("Part %d/%d\n\n", part, total)
+	case continued:
+		return fmt.Sprintf("Part %d/%d, continues package %s (%s)\n\n", part, total, firstDir.pkg, firstDir.dirPath)
+	default:
+		return fmt.Sprintf("Part %d/%d, starts with package %s (%s)\n\n", part, total, firstDir.pkg, firstDir.dirPath)
+	}
+}
+
+func partFooter(nextPart, total int) string {
+	return fmt.Sprintf("\n\n(Continued in part %d/%d)\n", nextPart, total)
+}
@@ -0,0 +1,87 @@
+package reportgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateChunks(t *testing.T) {
+	testCases := []struct {
+		name        string
+		chunkTokens int
+		expHeaders  []string
+	}{
+		{
+			name:        "No limit generates a single part",
+			chunkTokens: 0,
+			expHeaders:  []string{""},
+		},
+		{
+			name:        "Packages fitting into one part each are split at package boundaries",
+			chunkTokens: 400,
+			expHeaders: []string{
+				"Part 1/2",
+				"Part 2/2, starts with package beta (/repo/beta)",
+			},
+		},
+		{
+			name:        "Large packages are split between components",
+			chunkTokens: 200,
+			expHeaders: []string{
+				"Part 1/5",
+				"Part 2/5, continues package alpha (/repo/alpha)",
+				"Part 3/5, continues package alpha (/repo/alpha)",
+				"Part 4/5, starts with package beta (/repo/beta)",
+				"Part 5/5, continues package beta (/repo/beta)",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			components := ComponentMap{}
+			for _, pkg := range []string{"alpha", "beta"} {
+				os.MkdirAll(filepath.Join(tmpDir, pkg), 0755)
+				filePath := filepath.Join(tmpDir, pkg, pkg+".go")
+				os.WriteFile(filePath, []byte("package "+pkg), 0644)
+
+				for i := 0; i < 4; i++ {
+					name := fmt.Sprintf("Struct%d", i)
+					components[filepath.Dir(filePath)+":"+name] = Component{
+						File:    filePath,
+						Package: pkg,
+						Name:    name,
+						Type:    "struct",
+						Fields:  []string{"Name string", "Value int", "Children []*Node"},
+						Methods: []string{"String() string"},
+					}
+				}
+			}
+
+			rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{&fakeFinder{components: components}}})
+			rg.SetOptions(Options{ChunkTokens: tc.chunkTokens})
+
+			parts, err := rg.GenerateChunks()
+
+			assert.NoError(t, err)
+			assert.Len(t, parts, len(tc.expHeaders))
+			for i, part := range parts {
+				assert.True(t, strings.HasPrefix(part, "# repo\n\n"+tc.expHeaders[i]), "unexpected beginning of part %d: %q", i+1, part)
+				if tc.chunkTokens > 0 {
+					assert.LessOrEqual(t, EstimateTokens(part), tc.chunkTokens)
+				}
+			}
+			assert.Contains(t, parts[0], "## Directory structure")
+
+			// Every component shows up exactly once
+			all := strings.Join(parts, "")
+			assert.Equal(t, 8, strings.Count(all, "     - Struct"))
+		})
+	}
+}
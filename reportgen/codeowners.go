@@ -0,0 +1,201 @@
+package reportgen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeOwnersPaths are the locations of the CODEOWNERS file relative to the root directory,
+// in the order GitHub looks for it. Only the first one found is used.
+var codeOwnersPaths = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// ownerRule is a line of a CODEOWNERS file.
+type ownerRule struct {
+	pattern *regexp.Regexp
+	dirOnly bool     // dirOnly is true if the pattern ends with a slash and only matches directories and their content
+	owners  []string // owners are empty if the rule leaves the matched paths without owners
+}
+
+// codeOwners holds the rules of a CODEOWNERS file in the order they are written.
+type codeOwners struct {
+	rules []ownerRule
+}
+
+// findCodeOwners reads the first CODEOWNERS file found in the root directory.
+// It returns nil without an error if there's none.
+func findCodeOwners(rootPath string) (*codeOwners, error) {
+	for _, ownersPath := range codeOwnersPaths {
+		file, err := os.Open(filepath.Join(rootPath, filepath.FromSlash(ownersPath)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		owners, err := parseCodeOwners(file)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %s", ownersPath, err)
+		}
+
+		return owners, nil
+	}
+
+	return nil, nil
+}
+
+// parseCodeOwners parses the lines of a CODEOWNERS file like "/docs/ @org/docs-team @alice".
+func parseCodeOwners(r io.Reader) (*codeOwners, error) {
+	owners := &codeOwners{}
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Comments can also follow the owners
+		if i := strings.Index(line, " #"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		pattern := strings.ReplaceAll(fields[0], `\#`, "#")
+		regex, err := compileOwnerPattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %s", lineNum, pattern, err)
+		}
+
+		owners.rules = append(owners.rules, ownerRule{
+			pattern: regex,
+			dirOnly: strings.HasSuffix(pattern, "/"),
+			owners:  fields[1:],
+		})
+	}
+
+	return owners, scanner.Err()
+}
+
+// compileOwnerPattern turns a CODEOWNERS pattern into a regular expression matching the slash separated
+// paths relative to the root directory. It follows the gitignore rules GitHub uses, except that
+// a pattern ending with a single "*" like "docs/*" doesn't match the content of the subdirectories.
+func compileOwnerPattern(pattern string) (*regexp.Regexp, error) {
+	body := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(body, "/")
+	body = strings.TrimPrefix(body, "/")
+
+	var builder strings.Builder
+	builder.WriteString("^")
+	if !anchored {
+		// Patterns without a slash match at any depth
+		builder.WriteString("(.*/)?")
+	}
+
+	for i := 0; i < len(body); i++ {
+		switch {
+		case strings.HasPrefix(body[i:], "**/"):
+			builder.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(body[i:], "**"):
+			builder.WriteString(".*")
+			i++
+		case body[i] == '*':
+			builder.WriteString("[^/]*")
+		case body[i] == '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(body[i : i+1]))
+		}
+	}
+
+	// A matched directory owns its content
+	if !strings.HasSuffix(body, "*") || strings.HasSuffix(body, "**") {
+		builder.WriteString("(/.*)?")
+	}
+	builder.WriteString("$")
+
+	return regexp.Compile(builder.String())
+}
+
+// match returns the owners of a path relative to the root directory. The last matching rule wins.
+func (co *codeOwners) match(relPath string, isDir bool) []string {
+	var owners []string
+	for _, rule := range co.rules {
+		target := relPath
+		if rule.dirOnly && !isDir {
+			// A directory-only pattern matches a file through one of its parent directories
+			target = path.Dir(relPath)
+			if target == "." {
+				continue
+			}
+		}
+
+		if rule.pattern.MatchString(target) {
+			owners = rule.owners
+		}
+	}
+
+	return owners
+}
+
+// ownersAnnotation renders the owners like "(owners: @org/team, @alice)".
+func ownersAnnotation(owners []string) string {
+	return "(owners: " + strings.Join(owners, ", ") + ")"
+}
+
+// loadCodeOwners reads the CODEOWNERS file if asked for, and annotates the traversed files and directories
+// with their owners. Without a CODEOWNERS file, the owners are left out with a warning.
+func (rg *ReportGenerator) loadCodeOwners() error {
+	files := rg.fileTraverser.Files
+	for i := range files {
+		files[i].Owners = nil
+	}
+
+	if !rg.options.Owners {
+		return nil
+	}
+
+	owners, err := findCodeOwners(rg.rootPath)
+	if err != nil {
+		return fmt.Errorf("reading CODEOWNERS: %s", err)
+	}
+	if owners == nil {
+		rg.warnings = append(rg.warnings, Warning{
+			Op:  "reading code owners",
+			Err: fmt.Errorf("no file found at %s", strings.Join(codeOwnersPaths, ", ")),
+		})
+		return nil
+	}
+	for i := range files {
+		files[i].Owners = rg.ownersOf(owners, files[i].Path, files[i].Type == TypeDir)
+	}
+
+	return nil
+}
+
+// ownersOf returns the owners of a file or a directory of the repo.
+func (rg *ReportGenerator) ownersOf(owners *codeOwners, filePath string, isDir bool) []string {
+	rel, err := filepath.Rel(rg.rootPath, filePath)
+	if err != nil {
+		return nil
+	}
+
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+
+	return owners.match(rel, isDir)
+}
@@ -0,0 +1,128 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwnersMatch(t *testing.T) {
+	owners, err := parseCodeOwners(strings.NewReader(`# Default owners
+*       @org/everyone
+
+*.js    @alice # frontend
+**/logs @erin
+/build/logs/ @bob
+docs/*  @carol
+apps/   @dave
+/internal/payments @org/payments
+/internal/payments/legacy
+\#notes @frank
+`))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		path     string
+		isDir    bool
+		expected []string
+	}{
+		{name: "Root directory", path: "", isDir: true, expected: []string{"@org/everyone"}},
+		{name: "Default owners", path: "main.go", expected: []string{"@org/everyone"}},
+		{name: "Extension at any depth", path: "web/src/app.js", expected: []string{"@alice"}},
+		{name: "Anchored directory and its content", path: "build/logs/2024/app.log", expected: []string{"@bob"}},
+		{name: "Anchored directory not at the root", path: "src/build/logs/app.log", expected: []string{"@erin"}},
+		{name: "Direct content of a directory", path: "docs/intro.md", expected: []string{"@carol"}},
+		{name: "Nested content is not matched by a single star", path: "docs/guide/intro.md", expected: []string{"@org/everyone"}},
+		{name: "Directory pattern at any depth", path: "src/apps/web/main.go", expected: []string{"@dave"}},
+		{name: "Directory pattern doesn't match a file", path: "src/apps", isDir: false, expected: []string{"@org/everyone"}},
+		{name: "Directory pattern matches the directory", path: "src/apps", isDir: true, expected: []string{"@dave"}},
+		{name: "Last match wins", path: "internal/payments/stripe/client.go", expected: []string{"@org/payments"}},
+		{name: "Pattern without owners leaves the path unowned", path: "internal/payments/legacy/old.go", expected: []string{}},
+		{name: "Escaped hash", path: "#notes", expected: []string{"@frank"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, owners.match(tc.path, tc.isDir))
+		})
+	}
+}
+
+func TestGenerateReportWithOwners(t *testing.T) {
+	testCases := []struct {
+		name          string
+		ownersPath    string
+		ownersContent string
+		contains      []string
+		notContains   []string
+		expWarnings   []string // expWarnings are the ops of the warnings
+	}{
+		{
+			name:          "CODEOWNERS in the .github directory",
+			ownersPath:    ".github/CODEOWNERS",
+			ownersContent: "* @org/everyone\n/payments/ @org/payments @alice\n",
+			contains: []string{
+				" (owners: @org/everyone)\n\t/payments",
+				"\t/payments (owners: @org/payments, @alice)\n\t\t- payments.go\n",
+				"     - Charge()\n         - file: /server/payments/payments.go\n         - package: payments\n         - type: func\n         - owners: @org/payments, @alice\n",
+			},
+		},
+		{
+			name:          "CODEOWNERS in the docs directory",
+			ownersPath:    "docs/CODEOWNERS",
+			ownersContent: "payments.go @bob\n",
+			contains: []string{
+				"\t/payments\n\t\t- payments.go\n",
+				"         - type: func\n         - owners: @bob\n",
+			},
+		},
+		{
+			name:        "No CODEOWNERS",
+			contains:    []string{"\t/payments\n\t\t- payments.go\n", "         - type: func\n"},
+			notContains: []string{"owners"},
+			expWarnings: []string{"reading code owners"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			filePath := filepath.Join(tmpDir, "payments", "payments.go")
+			os.MkdirAll(filepath.Dir(filePath), 0755)
+			os.WriteFile(filePath, []byte("package payments"), 0644)
+			if tc.ownersPath != "" {
+				ownersPath := filepath.Join(tmpDir, tc.ownersPath)
+				os.MkdirAll(filepath.Dir(ownersPath), 0755)
+				os.WriteFile(ownersPath, []byte(tc.ownersContent), 0644)
+			}
+
+			finder := &fakeFinder{components: ComponentMap{
+				filepath.Dir(filePath) + ":Charge()": Component{File: filePath, Package: "payments", Name: "Charge()", Type: "func"},
+			}}
+			rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+			rg.SetOptions(Options{Owners: true})
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			for _, expected := range tc.contains {
+				assert.Contains(t, buffer.String(), expected)
+			}
+			for _, unexpected := range tc.notContains {
+				assert.NotContains(t, buffer.String(), unexpected)
+			}
+
+			ops := []string{}
+			for _, warning := range rg.Warnings() {
+				ops = append(ops, warning.Op)
+			}
+			assert.ElementsMatch(t, tc.expWarnings, ops)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package reportgen
+
+import "path/filepath"
+
+// CommentSyntax is how a language writes line comments and block comments.
+// Empty strings mean the language has no such comments.
+type CommentSyntax struct {
+	Line       string // Line starts a comment that ends at the end of the line, e.g. "//"
+	BlockStart string // BlockStart starts a comment that ends with BlockEnd, e.g. "/*"
+	BlockEnd   string
+}
+
+var (
+	cStyleComments    = CommentSyntax{Line: "//", BlockStart: "/*", BlockEnd: "*/"}
+	scriptingComments = CommentSyntax{Line: "#"}
+)
+
+// commentSyntaxes maps the file extensions to the comment syntax of the language.
+var commentSyntaxes = map[string]CommentSyntax{
+	".go":    cStyleComments,
+	".c":     cStyleComments,
+	".h":     cStyleComments,
+	".cpp":   cStyleComments,
+	".java":  cStyleComments,
+	".js":    cStyleComments,
+	".ts":    cStyleComments,
+	".rs":    cStyleComments,
+	".kt":    cStyleComments,
+	".swift": cStyleComments,
+	".cs":    cStyleComments,
+	".proto": cStyleComments,
+	".py":    scriptingComments,
+	".rb":    scriptingComments,
+	".sh":    scriptingComments,
+	".yaml":  scriptingComments,
+	".yml":   scriptingComments,
+	".toml":  scriptingComments,
+}
+
+// CommentSyntaxOf returns the comment syntax of the language of a file by its extension.
+// Files of other languages have no comments.
+func CommentSyntaxOf(filePath string) CommentSyntax {
+	return commentSyntaxes[filepath.Ext(filePath)]
+}
@@ -0,0 +1,294 @@
+package reportgen
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// coverBlock is a block of statements of a coverage profile.
+type coverBlock struct {
+	startLine int
+	endLine   int
+	stmts     int
+	count     int
+}
+
+// coverageProfile holds the blocks of a coverage profile produced by "go test -coverprofile",
+// by the paths of the files in the repo.
+type coverageProfile struct {
+	blocks map[string][]coverBlock
+}
+
+// coverage is the number of covered statements out of all the statements.
+type coverage struct {
+	covered int
+	total   int
+}
+
+func (c *coverage) add(other coverage) {
+	c.covered += other.covered
+	c.total += other.total
+}
+
+func (c coverage) String() string {
+	if c.total == 0 {
+		return "no statements"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(c.covered)*100/float64(c.total))
+}
+
+// loadCoverageProfile reads a coverage profile and maps its files to the files of the repo.
+// The profile names the files by import path, e.g. "github.com/burwei/repoexplainer/reportgen/model.go",
+// so a file of the repo matches if its path relative to the root directory is a suffix of the name.
+// Files that don't match any file of the repo are ignored.
+func loadCoverageProfile(profilePath string, rootPath string, files []File) (*coverageProfile, error) {
+	file, err := os.Open(profilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	relPaths := map[string]string{}
+	for _, f := range files {
+		if rel, err := filepath.Rel(rootPath, f.Path); err == nil && f.Type == TypeFile {
+			relPaths[f.Path] = filepath.ToSlash(rel)
+		}
+	}
+
+	// The same block is listed once per test binary with -coverpkg, a single run covering it is enough
+	type blockKey struct {
+		file      string
+		position  string
+		stmtCount int
+	}
+	counts := map[blockKey]int{}
+	resolved := map[string]string{}
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// Lines look like "path/to/file.go:12.34,15.2 3 1"
+		fields := strings.Fields(line)
+		colon := strings.LastIndex(line, ":")
+		if len(fields) != 3 || colon == -1 {
+			return nil, fmt.Errorf("line %d: invalid coverage block %q", lineNum, line)
+		}
+
+		name := line[:colon]
+		stmts, err1 := strconv.Atoi(fields[1])
+		count, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("line %d: invalid coverage block %q", lineNum, line)
+		}
+
+		localPath, ok := resolved[name]
+		if !ok {
+			localPath = matchProfileFile(name, relPaths)
+			resolved[name] = localPath
+		}
+		if localPath == "" {
+			continue
+		}
+
+		key := blockKey{file: localPath, position: strings.Fields(line[colon+1:])[0], stmtCount: stmts}
+		if prev, ok := counts[key]; !ok || count > prev {
+			counts[key] = count
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	profile := &coverageProfile{blocks: map[string][]coverBlock{}}
+	for key, count := range counts {
+		startLine, endLine, err := parseBlockPosition(key.position)
+		if err != nil {
+			return nil, err
+		}
+
+		profile.blocks[key.file] = append(profile.blocks[key.file], coverBlock{
+			startLine: startLine,
+			endLine:   endLine,
+			stmts:     key.stmtCount,
+			count:     count,
+		})
+	}
+
+	return profile, nil
+}
+
+// matchProfileFile returns the path of the repo file with the longest relative path
+// that is a suffix of the file name in the profile, or an empty string if there's none.
+func matchProfileFile(name string, relPaths map[string]string) string {
+	best, bestRel := "", ""
+	for path, rel := range relPaths {
+		if (name == rel || strings.HasSuffix(name, "/"+rel)) && len(rel) > len(bestRel) {
+			best, bestRel = path, rel
+		}
+	}
+
+	return best
+}
+
+// parseBlockPosition parses the position of a block like "12.34,15.2" into its first and last line.
+func parseBlockPosition(position string) (int, int, error) {
+	start, end, ok := strings.Cut(position, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid block position %q", position)
+	}
+
+	startLine, err := strconv.Atoi(identifierBefore(start, "."))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid block position %q", position)
+	}
+
+	endLine, err := strconv.Atoi(identifierBefore(end, "."))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid block position %q", position)
+	}
+
+	return startLine, endLine, nil
+}
+
+// lineRange returns the coverage of the blocks starting within the lines of a file.
+func (cp *coverageProfile) lineRange(filePath string, line, endLine int) coverage {
+	cov := coverage{}
+	for _, block := range cp.blocks[filePath] {
+		if block.startLine >= line && block.startLine <= endLine {
+			cov.total += block.stmts
+			if block.count > 0 {
+				cov.covered += block.stmts
+			}
+		}
+	}
+
+	return cov
+}
+
+// file returns the coverage of all the blocks of a file.
+func (cp *coverageProfile) file(filePath string) coverage {
+	cov := coverage{}
+	for _, block := range cp.blocks[filePath] {
+		cov.total += block.stmts
+		if block.count > 0 {
+			cov.covered += block.stmts
+		}
+	}
+
+	return cov
+}
+
+// funcCoverage returns the coverage of a func component, ok is false if its lines are unknown.
+func (cp *coverageProfile) funcCoverage(comp Component) (coverage, bool) {
+	if comp.Type != ComponentTypeFunc || comp.Line == 0 {
+		return coverage{}, false
+	}
+
+	return cp.lineRange(comp.File, comp.Line, comp.EndLine), true
+}
+
+// methodCoverage returns the coverage of a method of a component, ok is false if its lines are unknown.
+func (cp *coverageProfile) methodCoverage(comp Component, method string) (coverage, bool) {
+	location, ok := comp.MethodLocations[memberName(method)]
+	if !ok {
+		return coverage{}, false
+	}
+
+	return cp.lineRange(location.File, location.Line, location.EndLine), true
+}
+
+// loadCoverage loads the coverage profile of the options, if any.
+func (rg *ReportGenerator) loadCoverage() error {
+	rg.coverage = nil
+	if rg.options.CoverageProfile == "" {
+		return nil
+	}
+
+	profile, err := loadCoverageProfile(rg.options.CoverageProfile, rg.rootPath, rg.fileTraverser.Files)
+	if err != nil {
+		return fmt.Errorf("reading coverage profile: %s", err)
+	}
+	rg.coverage = profile
+
+	return nil
+}
+
+// renderCoverage renders the coverage section: the statement coverage of every package
+// and the exported funcs and methods that no test runs.
+func (rg *ReportGenerator) renderCoverage(outputCompMap OutputComponentMap) string {
+	byDir := map[string]coverage{}
+	for filePath := range rg.coverage.blocks {
+		dirPath := rg.outputDirPath(filepath.Dir(filePath))
+		cov := byDir[dirPath]
+		cov.add(rg.coverage.file(filePath))
+		byDir[dirPath] = cov
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for dirPath := range byDir {
+		dirs = append(dirs, dirPath)
+	}
+	sort.Strings(dirs)
+
+	var builder strings.Builder
+	builder.WriteString("\n\n## Coverage\n\n")
+	builder.WriteString("| Dir | Statements | Coverage |\n")
+	builder.WriteString("|---|---|---|\n")
+
+	total := coverage{}
+	for _, dirPath := range dirs {
+		builder.WriteString(fmt.Sprintf("| %s | %d | %s |\n", dirPath, byDir[dirPath].total, byDir[dirPath]))
+		total.add(byDir[dirPath])
+	}
+	builder.WriteString(fmt.Sprintf("| **Total** | %d | %s |\n", total.total, total))
+
+	untested := rg.untestedExportedAPIs(outputCompMap)
+	if len(untested) > 0 {
+		builder.WriteString("\nUntested exported funcs and methods:\n")
+		for _, name := range untested {
+			builder.WriteString(fmt.Sprintf(" - %s\n", name))
+		}
+	}
+
+	return builder.String()
+}
+
+// untestedExportedAPIs returns the exported funcs and the exported methods of exported types
+// whose statements are all uncovered, sorted by name. Package main can't be imported, so it has no API.
+func (rg *ReportGenerator) untestedExportedAPIs(outputCompMap OutputComponentMap) []string {
+	untested := []string{}
+	for _, comps := range outputCompMap {
+		for _, comp := range comps {
+			if isTestFile(comp.File) || comp.Package == "main" || !IsExported(componentName(comp)) {
+				continue
+			}
+
+			if cov, ok := rg.coverage.funcCoverage(comp); ok && cov.total > 0 && cov.covered == 0 {
+				untested = append(untested, comp.Package+"."+componentName(comp))
+			}
+
+			for _, method := range comp.Methods {
+				if !IsExported(memberName(method)) {
+					continue
+				}
+
+				if cov, ok := rg.coverage.methodCoverage(comp, method); ok && cov.total > 0 && cov.covered == 0 {
+					untested = append(untested, comp.Package+"."+comp.Name+"."+memberName(method))
+				}
+			}
+		}
+	}
+	sort.Strings(untested)
+
+	return untested
+}
@@ -0,0 +1,104 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateReportWithCoverage(t *testing.T) {
+	testCases := []struct {
+		name        string
+		profile     string
+		contains    []string
+		notContains []string
+		expectedErr bool
+	}{
+		{
+			name: "Funcs and methods are annotated",
+			profile: "mode: set\n" +
+				"example.com/server/server.go:7.32,9.2 1 1\n" +
+				"example.com/server/server.go:11.20,13.16 2 0\n" +
+				"example.com/server/server.go:13.16,15.3 1 1\n" +
+				"example.com/server/server.go:16.2,16.12 1 0\n" +
+				"example.com/other/other.go:1.1,2.2 5 1\n",
+			contains: []string{
+				"             - Start() error (coverage: 100.0%)\n",
+				"     - Run() error\n         - file: /server/server.go\n         - package: server\n         - type: func\n         - coverage: 25.0%\n",
+				"| /server | 5 | 40.0% |\n",
+				"| **Total** | 5 | 40.0% |\n",
+			},
+			notContains: []string{"Untested exported funcs and methods"},
+		},
+		{
+			name: "Untested exported APIs are listed",
+			profile: "mode: count\n" +
+				"example.com/server/server.go:7.32,9.2 1 0\n" +
+				"example.com/server/server.go:11.20,13.16 2 0\n" +
+				"example.com/server/server.go:11.20,13.16 2 3\n",
+			contains: []string{
+				"Untested exported funcs and methods:\n - server.Server.Start\n",
+				"         - coverage: 100.0%\n",
+			},
+			notContains: []string{" - server.Run\n"},
+		},
+		{
+			name:        "Invalid profile",
+			profile:     "mode: set\nserver.go 1 1\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			filePath := filepath.Join(tmpDir, "server.go")
+			os.WriteFile(filePath, []byte("package server"), 0644)
+			profilePath := filepath.Join(t.TempDir(), "coverage.out")
+			os.WriteFile(profilePath, []byte(tc.profile), 0644)
+
+			finder := &fakeFinder{components: ComponentMap{
+				tmpDir + ":Server": Component{
+					File:            filePath,
+					Package:         "server",
+					Name:            "Server",
+					Type:            "struct",
+					Methods:         []string{"Start() error"},
+					Line:            3,
+					EndLine:         5,
+					MethodLocations: map[string]Location{"Start": {File: filePath, Line: 7, EndLine: 9}},
+				},
+				tmpDir + ":Run": Component{
+					File:    filePath,
+					Package: "server",
+					Name:    "Run() error",
+					Type:    "func",
+					Line:    11,
+					EndLine: 17,
+				},
+			}}
+
+			rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+			rg.SetOptions(Options{CoverageProfile: profilePath})
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, buffer.String(), s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, buffer.String(), s)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package reportgen
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -15,32 +18,116 @@ const (
 )
 
 type File struct {
-	Type string
-	Path string
+	Type    string       `json:"type"`
+	Path    string       `json:"path"`
+	History *FileHistory `json:"history,omitempty"` // History is the git history of the file or the directory, nil if not loaded
+	Owners  []string     `json:"owners,omitempty"`  // Owners are the code owners of the file or the directory, nil if not loaded or unowned
+}
+
+// Warning is a problem that didn't stop the report from being generated,
+// like a directory or a file that couldn't be read and has been left out.
+type Warning struct {
+	Path string // Path is the path to the file or the directory, empty if the problem isn't about one
+	Op   string // Op is what failed, e.g. "reading directory" or "reading file"
+	Err  error
+}
+
+func (w Warning) String() string {
+	if w.Path == "" {
+		return fmt.Sprintf("%s: %s", w.Op, w.Err)
+	}
+
+	return fmt.Sprintf("%s %s: %s", w.Op, w.Path, w.Err)
+}
+
+// warningJSON is a Warning with the message of its error.
+type warningJSON struct {
+	Path  string `json:"path"`
+	Op    string `json:"op"`
+	Error string `json:"error"`
+}
+
+// MarshalJSON marshals the error of the warning as its message.
+func (w Warning) MarshalJSON() ([]byte, error) {
+	return json.Marshal(warningJSON{Path: w.Path, Op: w.Op, Error: w.Err.Error()})
+}
+
+// UnmarshalJSON unmarshals a warning marshaled by MarshalJSON. Only the message of the error is kept.
+func (w *Warning) UnmarshalJSON(data []byte) error {
+	var v warningJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*w = Warning{Path: v.Path, Op: v.Op, Err: errors.New(v.Error)}
+	return nil
 }
 
 // FileTraverser traverses the files in a directory tree starting from a root directory.
 type FileTraverser struct {
-	RootPath    string // RootPath is the starting point for the traversal
-	Files       []File // Files stores the paths of files found during traversal
-	currentFile int    // currentFile tracks the current index in the Files slice
+	RootPath    string    // RootPath is the starting point for the traversal
+	Files       []File    // Files stores the paths of files found during traversal
+	Warnings    []Warning // Warnings are the files and directories skipped because they couldn't be read
+	Exclude     []string  // Exclude are glob patterns of the files and directories left out, like Options.Exclude
+	currentFile int       // currentFile tracks the current index in the Files slice
+	err         error     // err is the error that stopped the walk, if any
 }
 
-// NewFileTraverser creates a new FileTraverser for a given root directory.
+// NewFileTraverser creates a new FileTraverser for a given root directory and walks the whole tree.
 func NewFileTraverser(rootPath string) *FileTraverser {
-	ft := &FileTraverser{
+	ft := newFileTraverser(rootPath)
+	for range ft.Walk(context.Background()) {
+	}
+	return ft
+}
+
+// newFileTraverser creates a new FileTraverser for a given root directory without walking it.
+func newFileTraverser(rootPath string) *FileTraverser {
+	return &FileTraverser{
 		RootPath:    rootPath,
 		currentFile: -1, // Start before the first element
 	}
-	ft.populateFiles()
-	return ft
 }
 
-// populateFiles fills the Files slice with all file paths starting from RootPath.
-func (ft *FileTraverser) populateFiles() {
-	filepath.WalkDir(ft.RootPath, func(path string, d fs.DirEntry, err error) error {
+// Walk walks the directory tree in the background and sends every file and directory to the returned
+// channel as soon as it's found, so they can be processed while the walk is still in progress.
+// They are appended to Files as well. The channel is closed when the walk is over or the context
+// is canceled; Files, Warnings and Err must not be read before that.
+func (ft *FileTraverser) Walk(ctx context.Context) <-chan File {
+	files := make(chan File)
+	go func() {
+		defer close(files)
+		ft.err = ft.walk(ctx, files)
+	}()
+
+	return files
+}
+
+// Err returns the error that stopped the last walk: the error of the context if it has been canceled,
+// or the error of the root directory if it couldn't be read. It's nil if the walk was completed.
+func (ft *FileTraverser) Err() error {
+	return ft.err
+}
+
+// walk sends the files and directories starting from RootPath to the channel and appends them to Files.
+// The files and directories that can't be read are recorded in Warnings and skipped.
+func (ft *FileTraverser) walk(ctx context.Context, files chan<- File) error {
+	return filepath.WalkDir(ft.RootPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return err
+			if d == nil && path == ft.RootPath {
+				return err // Nothing can be traversed
+			}
+
+			op := "reading file"
+			if d == nil || d.IsDir() {
+				op = "reading directory"
+			}
+			ft.Warnings = append(ft.Warnings, Warning{Path: path, Op: op, Err: err})
+
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
 		}
 		if strings.HasPrefix(d.Name(), ".") { // Skip hidden files and directories
 			if d.IsDir() {
@@ -48,15 +135,48 @@ func (ft *FileTraverser) populateFiles() {
 			}
 			return nil
 		}
+		if path != ft.RootPath && matchesPathPatterns(ft.RootPath, path, ft.Exclude) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
 		fileType := TypeFile
 		if d.IsDir() {
 			fileType = TypeDir
 		}
-		ft.Files = append(ft.Files, File{Type: fileType, Path: path})
+
+		file := File{Type: fileType, Path: path}
+		if err := ctx.Err(); err != nil {
+			return err // Don't leave it to select, which picks a ready case at random
+		}
+		select {
+		case files <- file:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		ft.Files = append(ft.Files, file)
 		return nil
 	})
 }
 
+// Skips reports whether the traversal leaves out the file or directory at the path, because it's hidden
+// or excluded, or it's in a directory that is, or it's out of the root directory. The path needn't exist.
+func (ft *FileTraverser) Skips(path string) bool {
+	rel, err := filepath.Rel(ft.RootPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return true
+	}
+
+	for ; rel != "."; rel = filepath.Dir(rel) {
+		if strings.HasPrefix(filepath.Base(rel), ".") || matchesPathPatterns(ft.RootPath, filepath.Join(ft.RootPath, rel), ft.Exclude) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // NextFile returns the next file in the traversal. When there are no more files, it returns false.
 func (ft *FileTraverser) NextFile() (string, bool) {
 	ft.currentFile++
@@ -75,15 +195,46 @@ func (ft *FileTraverser) NextFile() (string, bool) {
 
 // PrintDirectoryStructure prints the directory structure to the console.
 func (ft *FileTraverser) PrintDirectoryStructure() (string, error) {
+	tree, _, err := ft.printTree(treeOptions{})
+	return tree, err
+}
+
+// treeOptions controls which parts of the directory structure are printed.
+type treeOptions struct {
+	maxDepth    int  // maxDepth is the deepest directory level whose content is listed, 0 means unlimited
+	skipTests   bool // skipTests leaves test files out of the tree
+	historyDays int  // historyDays is the history window of the annotations of the files and directories, 0 means no annotations
+}
+
+// treeOmissions counts what has been left out of a printed directory structure.
+type treeOmissions struct {
+	summarizedDirs int
+	testFiles      int
+}
+
+// printTree prints the directory structure. Directories deeper than maxDepth are
+// summarized by the number of files they contain instead of being listed.
+func (ft *FileTraverser) printTree(opts treeOptions) (string, treeOmissions, error) {
+	omitted := treeOmissions{}
 	if len(ft.Files) == 0 {
-		return "", fmt.Errorf("no files have been traversed")
+		return "", omitted, fmt.Errorf("no files have been traversed")
 	}
 
 	// Create a map of directories to files to maintain the structure
 	dirStructure := map[string][]File{}
+	dirFiles := map[string]File{}
 	for _, file := range ft.Files {
+		if file.Type == TypeDir {
+			dirFiles[file.Path] = file
+		}
+
+		if opts.skipTests && file.Type == TypeFile && isTestFile(file.Path) {
+			omitted.testFiles++
+			continue
+		}
+
 		dir := filepath.Dir(file.Path)
-		dirStructure[dir] = append(dirStructure[dir], File{Type: file.Type, Path: file.Path})
+		dirStructure[dir] = append(dirStructure[dir], file)
 	}
 
 	dirs := make([]string, 0, len(dirStructure))
@@ -103,8 +254,18 @@ func (ft *FileTraverser) PrintDirectoryStructure() (string, error) {
 			continue // Skip the parent of root directory
 		}
 
+		if opts.maxDepth > 0 && depth > opts.maxDepth {
+			if depth == opts.maxDepth+1 {
+				indent := strings.Repeat("\t", depth)
+				builder.WriteString(fmt.Sprintf("%s/%s (%s)\n", indent, filepath.Base(dir), pluralize(countFiles(dir, dirStructure), "file")))
+				omitted.summarizedDirs++
+			}
+
+			continue // The content of the deep directories are summarized
+		}
+
 		indent := strings.Repeat("\t", depth)
-		builder.WriteString(fmt.Sprintf("%s/%s\n", indent, filepath.Base(dir)))
+		builder.WriteString(fmt.Sprintf("%s/%s%s\n", indent, filepath.Base(dir), annotationSuffix(dirFiles[dir], opts)))
 
 		files := dirStructure[dir]
 		for _, file := range files {
@@ -116,7 +277,7 @@ func (ft *FileTraverser) PrintDirectoryStructure() (string, error) {
 				if _, ok := dirStructure[file.Path]; !ok {
 					depth := strings.Count(dir, string(os.PathSeparator)) - offset + 1
 					indent := strings.Repeat("\t", depth)
-					builder.WriteString(fmt.Sprintf("%s/%s\n", indent, filepath.Base(file.Path)))
+					builder.WriteString(fmt.Sprintf("%s/%s%s\n", indent, filepath.Base(file.Path), annotationSuffix(file, opts)))
 
 					continue
 				}
@@ -126,9 +287,64 @@ func (ft *FileTraverser) PrintDirectoryStructure() (string, error) {
 
 			depth := strings.Count(dir, string(os.PathSeparator)) - offset + 1
 			indent := strings.Repeat("\t", depth)
-			builder.WriteString(fmt.Sprintf("%s- %s\n", indent, filepath.Base(file.Path)))
+			builder.WriteString(fmt.Sprintf("%s- %s%s\n", indent, filepath.Base(file.Path), historySuffix(file.History, opts.historyDays)))
+		}
+	}
+
+	return builder.String(), omitted, nil
+}
+
+// historySuffix returns the history annotation appended to a line of the tree, if any.
+func historySuffix(history *FileHistory, days int) string {
+	if history == nil || days == 0 {
+		return ""
+	}
+
+	return " " + history.annotation(days)
+}
+
+// annotationSuffix returns the annotations appended to the line of a directory in the tree:
+// its owners and its history.
+func annotationSuffix(dir File, opts treeOptions) string {
+	suffix := ""
+	if len(dir.Owners) > 0 {
+		suffix += " " + ownersAnnotation(dir.Owners)
+	}
+
+	return suffix + historySuffix(dir.History, opts.historyDays)
+}
+
+// countFiles counts the files in a directory and all of its subdirectories.
+func countFiles(dir string, dirStructure map[string][]File) int {
+	count := 0
+	for d, files := range dirStructure {
+		if d != dir && !strings.HasPrefix(d, dir+string(os.PathSeparator)) {
+			continue
 		}
+
+		for _, file := range files {
+			if file.Type == TypeFile {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// isTestFile reports whether the file only contains tests, judging by its name.
+func isTestFile(path string) bool {
+	name := filepath.Base(path)
+
+	return strings.HasSuffix(name, "_test.go") ||
+		(strings.HasPrefix(name, "test_") && strings.HasSuffix(name, ".py")) ||
+		strings.HasSuffix(name, "_test.py")
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
 	}
 
-	return builder.String(), nil
+	return fmt.Sprintf("%d %ss", count, noun)
 }
@@ -1,6 +1,8 @@
 package reportgen
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -182,3 +184,114 @@ func TestPrintDirectoryStructure(t *testing.T) {
 		})
 	}
 }
+
+func TestWalk(t *testing.T) {
+	t.Run("Files are sent as they are found", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, "dir1"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "file1.txt"), []byte("test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "dir1", "file2.txt"), []byte("test"), 0644)
+
+		ft := newFileTraverser(tmpDir)
+		sent := []File{}
+		for file := range ft.Walk(context.Background()) {
+			sent = append(sent, file)
+		}
+
+		assert.NoError(t, ft.Err())
+		assert.Empty(t, ft.Warnings)
+		assert.Len(t, sent, 4)
+		assert.Equal(t, sent, ft.Files)
+	})
+
+	t.Run("Excluded files and directories are left out", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, "vendor", "lib"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, "api"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "vendor", "lib", "lib.go"), []byte("test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "api", "api.pb.go"), []byte("test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "api", "api.go"), []byte("test"), 0644)
+
+		ft := newFileTraverser(tmpDir)
+		ft.Exclude = []string{"vendor", "*.pb.go"}
+		for range ft.Walk(context.Background()) {
+		}
+
+		paths := []string{}
+		for _, file := range ft.Files {
+			paths = append(paths, file.Path)
+		}
+		assert.Equal(t, []string{tmpDir, filepath.Join(tmpDir, "api"), filepath.Join(tmpDir, "api", "api.go")}, paths)
+	})
+
+	t.Run("Canceling the context stops the walk", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		for i := 0; i < 10; i++ {
+			os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%d.txt", i)), []byte("test"), 0644)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ft := newFileTraverser(tmpDir)
+		files := ft.Walk(ctx)
+		<-files
+		cancel()
+		for range files {
+		}
+
+		assert.ErrorIs(t, ft.Err(), context.Canceled)
+		assert.Less(t, len(ft.Files), 11)
+	})
+
+	t.Run("Unreadable directories are skipped with a warning", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root can read any directory")
+		}
+
+		tmpDir := t.TempDir()
+		locked := filepath.Join(tmpDir, "locked")
+		os.MkdirAll(locked, 0755)
+		os.WriteFile(filepath.Join(locked, "secret.txt"), []byte("test"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "file1.txt"), []byte("test"), 0644)
+		os.Chmod(locked, 0)
+		defer os.Chmod(locked, 0755)
+
+		ft := NewFileTraverser(tmpDir)
+
+		assert.NoError(t, ft.Err())
+		assert.Len(t, ft.Warnings, 1)
+		assert.Equal(t, locked, ft.Warnings[0].Path)
+		assert.Equal(t, "reading directory", ft.Warnings[0].Op)
+		assert.Len(t, ft.Files, 3)
+	})
+
+	t.Run("A missing root directory stops the walk", func(t *testing.T) {
+		ft := NewFileTraverser(filepath.Join(t.TempDir(), "missing"))
+
+		assert.Error(t, ft.Err())
+		assert.Empty(t, ft.Files)
+	})
+}
+
+func TestFileTraverserSkips(t *testing.T) {
+	ft := &FileTraverser{RootPath: "/repo", Exclude: []string{"vendor", "docs/*.md"}}
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected bool
+	}{
+		{name: "Root directory", path: "/repo", expected: false},
+		{name: "File", path: "/repo/app/app.go", expected: false},
+		{name: "Hidden file", path: "/repo/.env", expected: true},
+		{name: "In a hidden directory", path: "/repo/.cache/report.md", expected: true},
+		{name: "In an excluded directory", path: "/repo/vendor/lib/lib.go", expected: true},
+		{name: "Excluded file", path: "/repo/docs/report.md", expected: true},
+		{name: "Out of the root directory", path: "/other/report.md", expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ft.Skips(tc.path))
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package reportgen
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// excerpt is a range of lines of a file shown in the report.
+type excerpt struct {
+	title   string // title is the symbol or the file the excerpt shows
+	file    string
+	line    int
+	endLine int // endLine is the last line, 0 means the end of the file
+}
+
+// excerptLanguages maps the file extensions to the languages of the fenced code blocks.
+var excerptLanguages = map[string]string{
+	".go":   "go",
+	".py":   "python",
+	".js":   "javascript",
+	".ts":   "typescript",
+	".java": "java",
+	".rs":   "rust",
+	".c":    "c",
+	".h":    "c",
+	".cpp":  "cpp",
+	".rb":   "ruby",
+	".sh":   "bash",
+	".md":   "markdown",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".json": "json",
+}
+
+const excerptsHeading = "\n\n## Source excerpts\n"
+
+// symbolBody is the location of the body of a component or a method.
+type symbolBody struct {
+	key      string // key is the symbol qualified by its directory, e.g. "cmd/repoexplainer:main.main"
+	location Location
+	isFunc   bool // isFunc is true for the funcs and the methods, false for the types
+}
+
+// findExcerpts selects the source excerpts asked for by the options:
+// the bodies of the named symbols, the bodies of the short funcs and methods and the whole matching files.
+// The excerpts are sorted by file and line, and the ones within a whole file are left out.
+// Symbols that aren't found are returned as well, so the report can tell about them,
+// and so are the ones found in several directories, with the directory-qualified names to use instead.
+func (rg *ReportGenerator) findExcerpts(outputCompMap OutputComponentMap) ([]excerpt, []string, []string) {
+	// Several packages can have symbols of the same name, like the "main.main" of every command
+	bodies := map[string][]symbolBody{}
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		dir := rg.report.relativeDir(dirPath)
+		for _, comp := range outputCompMap[dirPath] {
+			symbol := comp.Package + "." + componentName(comp)
+			if comp.Line > 0 {
+				location := Location{File: comp.File, Line: comp.Line, EndLine: comp.EndLine}
+				body := symbolBody{key: dir + ":" + symbol, location: location, isFunc: comp.Type == ComponentTypeFunc}
+				bodies[symbol] = append(bodies[symbol], body)
+			}
+
+			for method, location := range comp.MethodLocations {
+				body := symbolBody{key: dir + ":" + symbol + "." + method, location: location, isFunc: true}
+				bodies[symbol+"."+method] = append(bodies[symbol+"."+method], body)
+			}
+		}
+	}
+
+	selected := map[string]excerpt{}
+	qualified := map[string]symbolBody{}
+	selectBody := func(body symbolBody, title string) {
+		selected[body.key] = excerpt{title: title, file: body.location.File, line: body.location.Line, endLine: body.location.EndLine}
+	}
+	for symbol, candidates := range bodies {
+		for _, body := range candidates {
+			qualified[body.key] = body
+
+			// The symbols found in several directories are titled with their directory
+			title := symbol
+			if len(candidates) > 1 {
+				title = body.key
+			}
+			length := body.location.EndLine - body.location.Line + 1
+			if rg.options.IncludeBodiesUnder > 0 && length < rg.options.IncludeBodiesUnder && body.isFunc {
+				selectBody(body, title)
+			}
+		}
+	}
+
+	notFound := []string{}
+	ambiguous := []string{}
+	for _, symbol := range rg.options.IncludeBodies {
+		if body, ok := qualified[symbol]; ok {
+			selectBody(body, symbol)
+			continue
+		}
+
+		candidates := bodies[symbol]
+		switch len(candidates) {
+		case 0:
+			notFound = append(notFound, symbol)
+		case 1:
+			selectBody(candidates[0], symbol)
+		default:
+			keys := make([]string, 0, len(candidates))
+			for _, body := range candidates {
+				keys = append(keys, body.key)
+			}
+			ambiguous = append(ambiguous, fmt.Sprintf("%s (%s)", symbol, strings.Join(keys, ", ")))
+		}
+	}
+
+	wholeFiles := map[string]bool{}
+	for _, file := range rg.fileTraverser.Files {
+		if file.Type == TypeDir || !rg.matchesIncludeFiles(file.Path) {
+			continue
+		}
+
+		wholeFiles[file.Path] = true
+		selected[file.Path] = excerpt{title: rg.displayPath(file.Path), file: file.Path, line: 1}
+	}
+
+	excerpts := make([]excerpt, 0, len(selected))
+	for _, exc := range selected {
+		if exc.endLine != 0 && wholeFiles[exc.file] {
+			continue
+		}
+		excerpts = append(excerpts, exc)
+	}
+
+	sort.Slice(excerpts, func(i, j int) bool {
+		a, b := excerpts[i], excerpts[j]
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		if a.line != b.line {
+			return a.line < b.line
+		}
+		return a.title < b.title
+	})
+
+	return excerpts, notFound, ambiguous
+}
+
+// matchesIncludeFiles reports whether a file matches one of the IncludeFiles patterns.
+func (rg *ReportGenerator) matchesIncludeFiles(filePath string) bool {
+	return matchesPathPatterns(rg.rootPath, filePath, rg.options.IncludeFiles)
+}
+
+// matchesPathPatterns reports whether a path matches any of the glob patterns. Patterns with a slash
+// match the path relative to the root directory, the others match the base name.
+func matchesPathPatterns(rootPath, filePath string, patterns []string) bool {
+	rel, err := filepath.Rel(rootPath, filePath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renderExcerpts renders every source excerpt as a block of its own, so the blocks can be split into parts.
+// The last blocks list the symbols that aren't found and the ones found in several directories, if any.
+func (rg *ReportGenerator) renderExcerpts(outputCompMap OutputComponentMap) ([]string, error) {
+	excerpts, notFound, ambiguous := rg.findExcerpts(outputCompMap)
+
+	blocks := make([]string, 0, len(excerpts)+2)
+	for _, exc := range excerpts {
+		block, err := rg.renderExcerpt(exc)
+		if err != nil {
+			return nil, fmt.Errorf("rendering source excerpt of %s: %s", exc.title, err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(notFound) > 0 {
+		blocks = append(blocks, fmt.Sprintf("\nNo source found for: %s\n", strings.Join(notFound, ", ")))
+	}
+	if len(ambiguous) > 0 {
+		blocks = append(blocks, fmt.Sprintf("\nSeveral symbols found, name one with its directory: %s\n", strings.Join(ambiguous, "; ")))
+	}
+
+	return blocks, nil
+}
+
+// renderExcerpt renders the lines of an excerpt with their line numbers in a fenced code block.
+func (rg *ReportGenerator) renderExcerpt(exc excerpt) (string, error) {
+	lines, err := readLines(exc.file, exc.line, exc.endLine)
+	if err != nil {
+		return "", err
+	}
+
+	endLine := exc.line + len(lines) - 1
+	if len(lines) == 0 {
+		endLine = exc.line
+	}
+
+	// Code containing a backtick fence would end the block early
+	fence := "```"
+	for _, line := range lines {
+		if strings.Contains(line, "```") {
+			fence = "~~~~"
+			break
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("\n### %s\n\n", exc.title))
+	builder.WriteString(fmt.Sprintf("%s, lines %d-%d\n\n", rg.displayPath(exc.file), exc.line, endLine))
+	builder.WriteString(fence + excerptLanguages[filepath.Ext(exc.file)] + "\n")
+	for i, line := range lines {
+		builder.WriteString(fmt.Sprintf("%4d  %s\n", exc.line+i, line))
+	}
+	builder.WriteString(fence + "\n")
+
+	return builder.String(), nil
+}
+
+// readLines reads the lines from line to endLine of a file, both starting from 1.
+// endLine 0 means the end of the file.
+func readLines(filePath string, line, endLine int) ([]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := splitLines(content)
+	if endLine != 0 && endLine < len(lines) {
+		lines = lines[:endLine]
+	}
+	if line > len(lines) {
+		return []string{}, nil
+	}
+
+	return lines[line-1:], nil
+}
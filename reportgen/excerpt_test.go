@@ -0,0 +1,191 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateReportWithExcerpts(t *testing.T) {
+	source := "package server\n" +
+		"\n" +
+		"type Server struct {\n" +
+		"\tAddr string\n" +
+		"}\n" +
+		"\n" +
+		"func (s *Server) Start() error {\n" +
+		"\treturn nil\n" +
+		"}\n" +
+		"\n" +
+		"func Run() error {\n" +
+		"\ts := &Server{}\n" +
+		"\tif err := s.Start(); err != nil {\n" +
+		"\t\treturn err\n" +
+		"\t}\n" +
+		"\treturn nil\n" +
+		"}\n"
+
+	testCases := []struct {
+		name        string
+		options     Options
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:    "Body of a method",
+			options: Options{IncludeBodies: []string{"server.Server.Start"}},
+			contains: []string{
+				"## Source excerpts",
+				"### server.Server.Start",
+				"/server/server.go, lines 7-9",
+				"```go\n   7  func (s *Server) Start() error {\n   8  \treturn nil\n   9  }\n```\n",
+			},
+			notContains: []string{"func Run() error"},
+		},
+		{
+			name:     "Unknown symbol",
+			options:  Options{IncludeBodies: []string{"server.Run", "server.Missing"}},
+			contains: []string{"### server.Run", "  11  func Run() error {", "No source found for: server.Missing"},
+		},
+		{
+			name:        "Funcs under a number of lines",
+			options:     Options{IncludeBodiesUnder: 4},
+			contains:    []string{"### server.Server.Start"},
+			notContains: []string{"### server.Run", "### server.Server\n"},
+		},
+		{
+			name:        "Whole files matching a glob",
+			options:     Options{IncludeFiles: []string{"*.go"}, IncludeBodies: []string{"server.Run"}},
+			contains:    []string{"### /server/server.go", "/server/server.go, lines 1-17", "   1  package server"},
+			notContains: []string{"### server.Run"},
+		},
+		{
+			name:        "Excerpts are dropped to fit into the budget",
+			options:     Options{IncludeFiles: []string{"*.go"}, MaxTokens: 120},
+			contains:    []string{"1 source excerpts"},
+			notContains: []string{"## Source excerpts"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			filePath := filepath.Join(tmpDir, "server.go")
+			os.WriteFile(filePath, []byte(source), 0644)
+
+			finder := &fakeFinder{components: ComponentMap{
+				tmpDir + ":Server": Component{
+					File:            filePath,
+					Package:         "server",
+					Name:            "Server",
+					Type:            "struct",
+					Fields:          []string{"Addr string"},
+					Methods:         []string{"Start() error"},
+					Line:            3,
+					EndLine:         5,
+					MethodLocations: map[string]Location{"Start": {File: filePath, Line: 7, EndLine: 9}},
+				},
+				tmpDir + ":Run": Component{
+					File:    filePath,
+					Package: "server",
+					Name:    "Run() error",
+					Type:    "func",
+					Line:    11,
+					EndLine: 17,
+				},
+			}}
+
+			rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+			rg.SetOptions(tc.options)
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, buffer.String(), s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, buffer.String(), s)
+			}
+		})
+	}
+}
+
+func TestGenerateReportWithAmbiguousExcerpts(t *testing.T) {
+	tmpDir := t.TempDir()
+	components := ComponentMap{}
+	for _, dir := range []string{"a", "b"} {
+		dirPath := filepath.Join(tmpDir, "cmd", dir)
+		filePath := filepath.Join(dirPath, "main.go")
+		os.MkdirAll(dirPath, 0755)
+		os.WriteFile(filePath, []byte("package main\n\nfunc main() {\n\trun(\""+dir+"\")\n}\n"), 0644)
+		components[dirPath+":main"] = Component{File: filePath, Package: "main", Name: "main()", Type: "func", Line: 3, EndLine: 5}
+	}
+
+	testCases := []struct {
+		name        string
+		options     Options
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "Symbol in several directories",
+			options:  Options{IncludeBodies: []string{"main.main"}},
+			contains: []string{"Several symbols found, name one with its directory: main.main (cmd/a:main.main, cmd/b:main.main)"},
+		},
+		{
+			name:        "Symbol named with its directory",
+			options:     Options{IncludeBodies: []string{"cmd/b:main.main"}},
+			contains:    []string{"### cmd/b:main.main", `   4  	run("b")`},
+			notContains: []string{`run("a")`},
+		},
+		{
+			name:     "Funcs under a number of lines",
+			options:  Options{IncludeBodiesUnder: 4},
+			contains: []string{"### cmd/a:main.main", `run("a")`, "### cmd/b:main.main", `run("b")`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			finder := &fakeFinder{components: components}
+			rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+			rg.SetOptions(tc.options)
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, buffer.String(), s)
+			}
+			for _, s := range tc.notContains {
+				assert.NotContains(t, buffer.String(), s)
+			}
+		})
+	}
+}
+
+func TestGenerateReportWithLongLineExcerpt(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "app.min.js")
+	longLine := "function f(){" + strings.Repeat("a", 70000) + "}"
+	os.WriteFile(filePath, []byte("// app\n"+longLine+"\n"), 0644)
+
+	finder := &fakeFinder{components: ComponentMap{
+		tmpDir + ":f": Component{File: filePath, Package: "app", Name: "f()", Type: "func", Line: 2, EndLine: 2},
+	}}
+	rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{IncludeBodies: []string{"app.f"}})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "   2  "+longLine+"\n")
+}
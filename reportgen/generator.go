@@ -2,6 +2,7 @@ package reportgen
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -13,101 +14,280 @@ type ReportGenerator struct {
 	rootPath      string
 	fileTraverser *FileTraverser
 	finderFactory FinderFactory
+	options       Options
+	fileStats     []FileStats         // fileStats are the line counts of the scanned files
+	coverage      *coverageProfile    // coverage is the loaded coverage profile, nil if there's none
+	fileOwners    map[string][]string // fileOwners are the code owners of the files of the rendered report by path
+	warnings      []Warning           // warnings are the problems of the last run that didn't stop it
+	progress      func(Progress)      // progress is called after every scanned file, nil if not set
+	report        *Report             // report is the report being rendered
+	diagramIDs    *diagramIDs         // diagramIDs are the identifiers of the components and packages of the report
 }
 
 func NewReportGenerator(rootDirName, rootPath string, finderFactory FinderFactory) *ReportGenerator {
 	return &ReportGenerator{
 		rootDirName:   rootDirName,
 		rootPath:      rootPath,
-		fileTraverser: NewFileTraverser(rootPath),
+		fileTraverser: newFileTraverser(rootPath),
 		finderFactory: finderFactory,
 	}
 }
 
+// SetOptions sets the options used by the following GenerateReport calls.
+func (rg *ReportGenerator) SetOptions(opts Options) {
+	rg.options = opts
+}
+
+// SetProgress sets the func called after every file scanned by the following GenerateReport calls.
+// It's never called concurrently, but it may be called from another goroutine than the caller's.
+func (rg *ReportGenerator) SetProgress(progress func(Progress)) {
+	rg.progress = progress
+}
+
+// Warnings returns the problems of the last analysis that didn't stop it,
+// like the files and directories that couldn't be read and have been left out of the report.
+func (rg *ReportGenerator) Warnings() []Warning {
+	return rg.warnings
+}
+
 func (rg *ReportGenerator) GenerateReport(out io.Writer) error {
-	dirStructure, err := rg.fileTraverser.PrintDirectoryStructure()
+	return rg.GenerateReportContext(context.Background(), out)
+}
+
+// GenerateReportContext is GenerateReport stopping the traversal and the scanning of the files
+// as soon as the context is canceled.
+func (rg *ReportGenerator) GenerateReportContext(ctx context.Context, out io.Writer) error {
+	report, err := rg.Analyze(ctx)
 	if err != nil {
-		return fmt.Errorf("printing directory structure: %s", err)
+		return err
 	}
 
-	err = rg.findCodeStructuresInFiles()
+	return rg.Render(report, out)
+}
+
+// Render renders a report made by Analyze in the format of the options. The report may have been changed,
+// e.g. packages or components left out, and may come from JSON. The coverage of the funcs and methods
+// is only rendered if the coverage profile has been loaded by Analyze of the same generator.
+func (rg *ReportGenerator) Render(report *Report, out io.Writer) error {
+	err := rg.options.Validate()
 	if err != nil {
-		return fmt.Errorf("finding code structures in files: %s", err)
+		return fmt.Errorf("validating options: %s", err)
 	}
 
-	outputCompMap := rg.getOutputCompMap()
+	rg.useReport(report)
+	outputCompMap := report.ComponentMap()
+
+	var rendered string
+	switch {
+	case rg.options.Format == FormatDOT:
+		rendered = rg.renderDOT(outputCompMap, report.Dependencies)
+	case rg.options.Format == FormatPlantUML:
+		rendered = rg.renderPlantUML(outputCompMap, report.Dependencies)
+	case rg.options.Format == FormatHTML:
+		rendered, err = rg.renderHTML(outputCompMap)
+	case rg.options.MaxTokens > 0:
+		rendered, err = rg.renderWithinBudget(outputCompMap, rg.options.MaxTokens)
+	default:
+		rendered, _, err = rg.renderMarkdown(outputCompMap, detailLevel{})
+	}
+	if err != nil {
+		return fmt.Errorf("rendering report: %s", err)
+	}
 
 	writer := bufio.NewWriter(out)
-	writer.WriteString(fmt.Sprintf("# %s\n\n", rg.rootDirName))
-	writer.WriteString("## Directory structure\n\n")
-	writer.WriteString("```\n")
-	writer.WriteString(dirStructure)
-	writer.WriteString("```\n")
-	writer.WriteString("\n\n## Components\n")
+	writer.WriteString(rendered)
 
-	for dirPath, comps := range outputCompMap {
-		writer.WriteString(fmt.Sprintf(" - dir: %s\n", dirPath))
-		for _, comp := range comps {
-			// Make the file path start with the root directory
-			filePath := strings.TrimPrefix(comp.File, rg.rootPath)
-			if strings.Contains(filePath, "/") {
-				filePath = "/" + rg.rootDirName + filePath
-			} else {
-				filePath = "/" + rg.rootDirName + "/" + filePath
-			}
+	return writer.Flush()
+}
 
-			writer.WriteString(fmt.Sprintf("     - %s\n", comp.Name))
-			writer.WriteString(fmt.Sprintf("         - file: %s\n", filePath))
-			writer.WriteString(fmt.Sprintf("         - package: %s\n", comp.Package))
-			writer.WriteString(fmt.Sprintf("         - type: %s\n", comp.Type))
-			writer.WriteString("         - fields:\n")
-			for _, field := range comp.Fields {
-				writer.WriteString(fmt.Sprintf("             - %s\n", field))
-			}
-			writer.WriteString("         - methods:\n")
-			for _, method := range comp.Methods {
-				writer.WriteString(fmt.Sprintf("             - %s\n", method))
-			}
+// RenderTree renders the directory structure of a report made by Analyze, annotated with the history
+// and the owners if the options ask for them. The content of the directories deeper than maxDepth
+// is summarized by their number of files, 0 means no limit.
+func (rg *ReportGenerator) RenderTree(report *Report, maxDepth int, out io.Writer) error {
+	rg.useReport(report)
+
+	tree, _, err := rg.fileTraverser.printTree(treeOptions{maxDepth: maxDepth, historyDays: rg.treeHistoryDays()})
+	if err != nil {
+		return fmt.Errorf("printing directory structure: %s", err)
+	}
+
+	_, err = io.WriteString(out, tree)
+	return err
+}
+
+// RenderStats renders the statistics of a report made by Analyze: the size of every package
+// and the largest files and funcs.
+func (rg *ReportGenerator) RenderStats(report *Report, out io.Writer) error {
+	rg.useReport(report)
+
+	stats := strings.TrimPrefix(rg.renderStats(report.ComponentMap()), "\n\n")
+	_, err := io.WriteString(out, stats)
+	return err
+}
+
+// findCodeStructuresInFiles walks the repo and scans its files while the walk is still in progress.
+func (rg *ReportGenerator) findCodeStructuresInFiles(ctx context.Context) error {
+	rg.fileStats = nil
+	rg.warnings = nil
+	rg.fileTraverser = newFileTraverser(rg.rootPath)
+	rg.fileTraverser.Exclude = rg.options.Exclude
+	progress := &progressTracker{report: rg.progress}
+	setRoot(rg.finderFactory.GetFinders(), rg.rootPath)
+
+	var cache *fileCache
+	if rg.options.Cache {
+		var err error
+		cache, err = newFileCache(rg.rootPath, rg.finderFactory.GetFinders())
+		if err != nil {
+			return fmt.Errorf("opening cache: %s", err)
 		}
+	}
+
+	walkCtx, cancel := context.WithCancel(ctx)
+	files := rg.fileTraverser.Walk(walkCtx)
+	defer func() {
+		// Stop the walk if the scanning has failed, and wait for it to be over
+		cancel()
+		for range files {
+		}
+	}()
 
-		writer.Flush()
+	var err error
+	if creator, ok := rg.finderCreator(); ok && rg.options.workerCount() > 1 {
+		err = rg.scanFilesInParallel(files, creator, cache, progress)
+	} else {
+		err = rg.scanFilesOneByOne(files, cache, progress)
+	}
+	rg.releaseFinders(rg.finderFactory.GetFinders())
+	if err != nil {
+		return err
+	}
+
+	if err := rg.fileTraverser.Err(); err != nil {
+		return fmt.Errorf("walking %s: %s", rg.rootPath, err)
+	}
+	if err := ctx.Err(); err != nil {
+		// Canceled after the walk, while the last files were scanned
+		return err
+	}
+	rg.warnings = append(rg.fileTraverser.Warnings, rg.warnings...)
+
+	if cache != nil {
+		if err := cache.prune(); err != nil {
+			return fmt.Errorf("pruning cache: %s", err)
+		}
 	}
 
 	return nil
 }
 
-func (rg *ReportGenerator) findCodeStructuresInFiles() error {
-	// iterate over all files in the repo
-	filePath, ok := rg.fileTraverser.NextFile()
-	for ok {
-		file, err := os.Open(filePath)
+// scanFilesOneByOne scans the files sent by the traverser with the finders of the factory.
+func (rg *ReportGenerator) scanFilesOneByOne(files <-chan File, cache *fileCache, progress *progressTracker) error {
+	for file := range files {
+		if file.Type == TypeDir {
+			continue
+		}
+		progress.fileFound()
+
+		content, err := os.ReadFile(file.Path)
 		if err != nil {
-			return fmt.Errorf("opening file %s: %s", filePath, err)
+			rg.warnings = append(rg.warnings, Warning{Path: file.Path, Op: "reading file", Err: err})
+			progress.fileScanned(file.Path)
+			continue
 		}
-		defer file.Close()
 
-		// Set the file for all the finders
-		for _, finder := range rg.finderFactory.GetFinders() {
-			finder.SetFile(filePath)
+		stats, err := rg.findCodeStructuresInFile(file.Path, content, cache)
+		if err != nil {
+			return err
 		}
+		rg.fileStats = append(rg.fileStats, stats)
+		progress.fileScanned(file.Path)
+	}
+
+	return nil
+}
 
-		// Loop through all the lines in the file
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			for _, finder := range rg.finderFactory.GetFinders() {
-				finder.FindComponent(scanner.Text())
+// setRoot sets the root directory of the finders that are RootFinders.
+func setRoot(finders []ComponentFinder, rootPath string) {
+	for _, finder := range finders {
+		if rootFinder, ok := finder.(RootFinder); ok {
+			rootFinder.SetRoot(rootPath)
+		}
+	}
+}
+
+// releaseFinders closes the finders that are io.Closers once the files are scanned,
+// and adds the warnings of the WarningFinders.
+func (rg *ReportGenerator) releaseFinders(finders []ComponentFinder) {
+	for _, finder := range finders {
+		if closer, ok := finder.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				rg.warnings = append(rg.warnings, Warning{Op: fmt.Sprintf("closing finder %T", finder), Err: err})
 			}
 		}
 
-		// Check for errors during Scan. End of file is expected and not reported by Scan as an error.
-		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("scanning file %s: %s", filePath, err)
+		if warningFinder, ok := finder.(WarningFinder); ok {
+			rg.warnings = append(rg.warnings, warningFinder.GetWarnings()...)
 		}
+	}
+}
 
-		filePath, ok = rg.fileTraverser.NextFile()
+// findCodeStructuresInFile gives the lines of a file to the finders and counts them.
+// The findings of a file that hasn't changed since it was cached are restored from the cache instead.
+func (rg *ReportGenerator) findCodeStructuresInFile(filePath string, content []byte, cache *fileCache) (FileStats, error) {
+	if cache != nil {
+		if stats, ok := cache.restore(filePath, content); ok {
+			return stats, nil
+		}
 	}
 
-	return nil
+	stats := scanLines(filePath, content, rg.finderFactory.GetFinders())
+
+	if cache != nil {
+		results, complete, err := fileResults(rg.finderFactory.GetFinders())
+		if err == nil && complete {
+			err = cache.store(filePath, content, stats, results)
+		}
+		if err != nil {
+			return FileStats{}, fmt.Errorf("caching file %s: %s", filePath, err)
+		}
+	}
+
+	return stats, nil
+}
+
+// scanLines gives the lines of a file to the finders and counts them.
+func scanLines(filePath string, content []byte, finders []ComponentFinder) FileStats {
+	// Set the file for all the finders
+	for _, finder := range finders {
+		finder.SetFile(filePath)
+	}
+
+	// Loop through all the lines in the file
+	counter := newLineCounter(filePath)
+	for _, line := range splitLines(content) {
+		counter.count(line)
+		for _, finder := range finders {
+			finder.FindComponent(line)
+		}
+	}
+
+	return counter.stats
+}
+
+// splitLines splits the content of a file into lines like bufio.ScanLines, without its limit
+// on the length of a line, so a minified file is scanned like any other.
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
 }
 
 func (rg *ReportGenerator) getOutputCompMap() OutputComponentMap {
@@ -115,18 +295,58 @@ func (rg *ReportGenerator) getOutputCompMap() OutputComponentMap {
 	for _, finder := range rg.finderFactory.GetFinders() {
 		compMap := finder.GetComponents()
 		for key, comp := range compMap {
-			dirPath := strings.Split(key, ":")[0]
-			dirPath = strings.TrimPrefix(dirPath, rg.rootPath)
-
-			if strings.Contains(dirPath, "/") {
-				dirPath = "/" + rg.rootDirName + dirPath
-			} else {
-				dirPath = "/" + rg.rootDirName + "/" + dirPath
-			}
-
+			dirPath := rg.outputDirPath(strings.Split(key, ":")[0])
 			outputCompMap[dirPath] = append(outputCompMap[dirPath], comp)
 		}
 	}
 
+	// Only keep the components with the asked visibility
+	for dirPath, comps := range outputCompMap {
+		comps, _, _ = filterVisibility(dirPath, comps, rg.options.Visibility)
+		if len(comps) == 0 {
+			delete(outputCompMap, dirPath)
+			continue
+		}
+
+		outputCompMap[dirPath] = comps
+	}
+
 	return outputCompMap
 }
+
+// getOutputDependencies returns the dependencies found by the finders, with the directory paths
+// starting from the root directory like in the OutputComponentMap.
+func (rg *ReportGenerator) getOutputDependencies() DependencyMap {
+	outputDeps := DependencyMap{}
+	for _, finder := range rg.finderFactory.GetFinders() {
+		depFinder, ok := finder.(DependencyFinder)
+		if !ok {
+			continue
+		}
+
+		for dir, deps := range depFinder.GetDependencies() {
+			dirPath := rg.outputDirPath(dir)
+			for _, dep := range deps {
+				if dep.Dir != "" {
+					dep.Dir = rg.outputDirPath(dep.Dir)
+				}
+				outputDeps[dirPath] = append(outputDeps[dirPath], dep)
+			}
+		}
+	}
+
+	return outputDeps
+}
+
+// outputDirPath makes the directory path start with the root directory.
+func (rg *ReportGenerator) outputDirPath(dir string) string {
+	dirPath := strings.TrimPrefix(dir, rg.rootPath)
+	if dirPath == "" {
+		return "/" + rg.rootDirName
+	}
+	if strings.Contains(dirPath, "/") {
+		return "/" + rg.rootDirName + dirPath
+	}
+
+	return "/" + rg.rootDirName + "/" + dirPath
+}
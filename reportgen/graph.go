@@ -0,0 +1,203 @@
+package reportgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// graphEdge is a directed edge between two directories of the package dependency graph.
+type graphEdge struct {
+	from string
+	to   string
+}
+
+// packageGraph returns the directories and the dependencies between them within the repo.
+// External packages are left out. Both are sorted.
+func packageGraph(outputCompMap OutputComponentMap, deps DependencyMap) ([]string, []graphEdge) {
+	dirSet := map[string]bool{}
+	for dirPath := range outputCompMap {
+		dirSet[dirPath] = true
+	}
+
+	edgeSet := map[graphEdge]bool{}
+	for dirPath, dirDeps := range deps {
+		for _, dep := range dirDeps {
+			if dep.Dir == "" || dep.Dir == dirPath {
+				continue
+			}
+
+			dirSet[dirPath] = true
+			dirSet[dep.Dir] = true
+			edgeSet[graphEdge{from: dirPath, to: dep.Dir}] = true
+		}
+	}
+
+	dirs := make([]string, 0, len(dirSet))
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	edges := make([]graphEdge, 0, len(edgeSet))
+	for edge := range edgeSet {
+		edges = append(edges, edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		return edges[i].to < edges[j].to
+	})
+
+	return dirs, edges
+}
+
+// typeComponents returns the structs and interfaces of a directory.
+func typeComponents(comps []Component) []Component {
+	types := []Component{}
+	for _, comp := range comps {
+		if comp.Type == ComponentTypeStruct || comp.Type == ComponentTypeInterface {
+			types = append(types, comp)
+		}
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+
+	return types
+}
+
+// relativeDir returns the directory path relative to the root directory,
+// or the name of the root directory for the root directory itself.
+func (rg *ReportGenerator) relativeDir(dirPath string) string {
+	rel := strings.Trim(strings.TrimPrefix(dirPath, "/"+rg.rootDirName), "/")
+	if rel == "" {
+		return rg.rootDirName
+	}
+
+	return rel
+}
+
+// packageID returns an identifier of a package usable in diagrams.
+func (rg *ReportGenerator) packageID(dirPath string) string {
+	return rg.diagramIDs.get(dirPath, "pkg_"+strings.Trim(rg.relativeDirPath(dirPath), "/"))
+}
+
+// renderDOT renders the package dependency graph and the type relationship graph
+// in the Graphviz DOT language, as two clusters of a single graph.
+func (rg *ReportGenerator) renderDOT(outputCompMap OutputComponentMap, deps DependencyMap) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("digraph %s {\n", dotQuote(rg.rootDirName)))
+	builder.WriteString("\tcompound=true;\n")
+	builder.WriteString("\tnode [shape=box, fontname=\"Helvetica\"];\n\n")
+
+	dirs, edges := packageGraph(outputCompMap, deps)
+	builder.WriteString("\tsubgraph cluster_packages {\n")
+	builder.WriteString("\t\tlabel=\"Package dependencies\";\n")
+	for _, dir := range dirs {
+		builder.WriteString(fmt.Sprintf("\t\t%s [label=%s, shape=folder];\n", rg.packageID(dir), dotQuote(rg.relativeDir(dir))))
+	}
+	for _, edge := range edges {
+		builder.WriteString(fmt.Sprintf("\t\t%s -> %s;\n", rg.packageID(edge.from), rg.packageID(edge.to)))
+	}
+	builder.WriteString("\t}\n\n")
+
+	builder.WriteString("\tsubgraph cluster_types {\n")
+	builder.WriteString("\t\tlabel=\"Type relationships\";\n")
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		for _, comp := range typeComponents(outputCompMap[dirPath]) {
+			style := ""
+			if comp.Type == ComponentTypeInterface {
+				style = ", style=dashed"
+			}
+			builder.WriteString(fmt.Sprintf("\t\t%s [label=%s%s];\n", rg.diagramID(dirPath, comp.Name), dotQuote(comp.Package+"."+comp.Name), style))
+		}
+	}
+	for _, rel := range rg.relationships(outputCompMap) {
+		fromDir, fromName, _ := strings.Cut(rel.From, ":")
+		toDir, toName, _ := strings.Cut(rel.To, ":")
+
+		attrs := dotEdgeAttributes[rel.Kind]
+		if rel.Label != "" {
+			attrs += ", label=" + dotQuote(rel.Label)
+		}
+		builder.WriteString(fmt.Sprintf("\t\t%s -> %s [%s];\n", rg.diagramID(fromDir, fromName), rg.diagramID(toDir, toName), attrs))
+	}
+	builder.WriteString("\t}\n")
+	builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// dotEdgeAttributes maps the relationship kinds to the UML-like DOT edge styles.
+var dotEdgeAttributes = map[string]string{
+	RelationEmbedding:      "arrowhead=empty",
+	RelationComposition:    "dir=back, arrowtail=diamond",
+	RelationImplementation: "arrowhead=empty, style=dashed",
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// renderPlantUML renders the package dependency diagram and the type relationship diagram
+// as two PlantUML diagrams in a single file.
+func (rg *ReportGenerator) renderPlantUML(outputCompMap OutputComponentMap, deps DependencyMap) string {
+	var builder strings.Builder
+
+	dirs, edges := packageGraph(outputCompMap, deps)
+	builder.WriteString("@startuml packages\n")
+	builder.WriteString(fmt.Sprintf("title %s package dependencies\n", rg.rootDirName))
+	for _, dir := range dirs {
+		builder.WriteString(fmt.Sprintf("package %s as %s\n", plantUMLQuote(rg.relativeDir(dir)), rg.packageID(dir)))
+	}
+	for _, edge := range edges {
+		builder.WriteString(fmt.Sprintf("%s ..> %s\n", rg.packageID(edge.from), rg.packageID(edge.to)))
+	}
+	builder.WriteString("@enduml\n\n")
+
+	builder.WriteString("@startuml types\n")
+	builder.WriteString(fmt.Sprintf("title %s type relationships\n", rg.rootDirName))
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		types := typeComponents(outputCompMap[dirPath])
+		if len(types) == 0 {
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("package %s {\n", plantUMLQuote(rg.relativeDir(dirPath))))
+		for _, comp := range types {
+			keyword := "class"
+			if comp.Type == ComponentTypeInterface {
+				keyword = "interface"
+			}
+			builder.WriteString(fmt.Sprintf("  %s %s as %s\n", keyword, plantUMLQuote(comp.Name), rg.diagramID(dirPath, comp.Name)))
+		}
+		builder.WriteString("}\n")
+	}
+	for _, rel := range rg.relationships(outputCompMap) {
+		fromDir, fromName, _ := strings.Cut(rel.From, ":")
+		toDir, toName, _ := strings.Cut(rel.To, ":")
+
+		builder.WriteString(fmt.Sprintf("%s %s %s", rg.diagramID(fromDir, fromName), plantUMLArrows[rel.Kind], rg.diagramID(toDir, toName)))
+		if rel.Label != "" {
+			builder.WriteString(" : " + rel.Label)
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("@enduml\n")
+
+	return builder.String()
+}
+
+// plantUMLArrows maps the relationship kinds to the PlantUML arrows.
+var plantUMLArrows = map[string]string{
+	RelationEmbedding:      "--|>",
+	RelationComposition:    "*--",
+	RelationImplementation: "..|>",
+}
+
+func plantUMLQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `'`) + `"`
+}
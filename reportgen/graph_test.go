@@ -0,0 +1,89 @@
+package reportgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderGraphs(t *testing.T) {
+	compMap := OutputComponentMap{
+		"/repo/app": {
+			{Package: "app", Name: "Run() error", Type: "func"},
+		},
+		"/repo/reportgen": {
+			{Package: "reportgen", Name: "Finder", Type: "interface", Methods: []string{"Find() error"}},
+			{Package: "reportgen", Name: "Generator", Type: "struct", Fields: []string{"finder Finder"}, Methods: []string{"Find() error"}},
+		},
+	}
+	deps := DependencyMap{
+		"/repo/app": {
+			{ImportPath: "example.com/repo/reportgen", Dir: "/repo/reportgen"},
+			{ImportPath: "fmt"},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:   "DOT",
+			format: FormatDOT,
+			expected: "digraph \"repo\" {\n" +
+				"\tcompound=true;\n" +
+				"\tnode [shape=box, fontname=\"Helvetica\"];\n\n" +
+				"\tsubgraph cluster_packages {\n" +
+				"\t\tlabel=\"Package dependencies\";\n" +
+				"\t\tpkg_app [label=\"app\", shape=folder];\n" +
+				"\t\tpkg_reportgen [label=\"reportgen\", shape=folder];\n" +
+				"\t\tpkg_app -> pkg_reportgen;\n" +
+				"\t}\n\n" +
+				"\tsubgraph cluster_types {\n" +
+				"\t\tlabel=\"Type relationships\";\n" +
+				"\t\treportgen_Finder [label=\"reportgen.Finder\", style=dashed];\n" +
+				"\t\treportgen_Generator [label=\"reportgen.Generator\"];\n" +
+				"\t\treportgen_Generator -> reportgen_Finder [dir=back, arrowtail=diamond, label=\"finder\"];\n" +
+				"\t\treportgen_Generator -> reportgen_Finder [arrowhead=empty, style=dashed];\n" +
+				"\t}\n" +
+				"}\n",
+		},
+		{
+			name:   "PlantUML",
+			format: FormatPlantUML,
+			expected: "@startuml packages\n" +
+				"title repo package dependencies\n" +
+				"package \"app\" as pkg_app\n" +
+				"package \"reportgen\" as pkg_reportgen\n" +
+				"pkg_app ..> pkg_reportgen\n" +
+				"@enduml\n\n" +
+				"@startuml types\n" +
+				"title repo type relationships\n" +
+				"package \"reportgen\" {\n" +
+				"  interface \"Finder\" as reportgen_Finder\n" +
+				"  class \"Generator\" as reportgen_Generator\n" +
+				"}\n" +
+				"reportgen_Generator *-- reportgen_Finder : finder\n" +
+				"reportgen_Generator ..|> reportgen_Finder\n" +
+				"@enduml\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rg := NewReportGenerator("repo", t.TempDir(), &fakeFinderFactory{})
+			rg.useReport(&Report{Name: "repo", Relationships: FindRelationships(compMap)})
+
+			var output string
+			switch tc.format {
+			case FormatDOT:
+				output = rg.renderDOT(compMap, deps)
+			case FormatPlantUML:
+				output = rg.renderPlantUML(compMap, deps)
+			}
+
+			assert.Equal(t, tc.expected, output)
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package reportgen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultHistoryDays is the number of days of the window the commits are counted over by default.
+	DefaultHistoryDays = 90
+
+	// historyTopAuthors is the number of the top contributors listed per file and directory.
+	historyTopAuthors = 3
+	// historyListCount is the number of the hot spots and the stale packages listed in the history section.
+	historyListCount = 5
+)
+
+// FileHistory is the git history of a file or a directory.
+type FileHistory struct {
+	LastModified time.Time `json:"lastModified"` // LastModified is the commit time of the last commit changing it
+	Commits      int       `json:"commits"`      // Commits is the number of commits changing it within the history window
+	Authors      []string  `json:"authors"`      // Authors are the top contributors, the author of the most commits first
+}
+
+// annotation renders the history like "(modified 2024-05-01, 4 commits in 90d, by alice, bob)".
+func (h FileHistory) annotation(days int) string {
+	text := fmt.Sprintf("modified %s, %s in %dd", h.LastModified.Format("2006-01-02"), pluralize(h.Commits, "commit"), days)
+	if len(h.Authors) > 0 {
+		text += ", by " + strings.Join(h.Authors, ", ")
+	}
+
+	return "(" + text + ")"
+}
+
+// gitCommit is a commit with the files it changed.
+type gitCommit struct {
+	time   time.Time
+	author string
+	files  []string // files are the full paths to the changed files
+}
+
+// gitLogFormat starts every commit with a NUL byte followed by the commit time and the author.
+const gitLogFormat = "--format=%x00%ct %aN"
+
+// readGitLog runs git log in the root directory and returns the commits changing the files in it.
+// Only the local repository is read.
+func readGitLog(rootPath string) ([]gitCommit, error) {
+	cmd := exec.Command("git", "-c", "core.quotePath=false", "log", "--relative", "--no-renames", "--name-only", gitLogFormat, "--", ".")
+	cmd.Dir = rootPath
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return parseGitLog(bytes.NewReader(out), rootPath)
+}
+
+// parseGitLog parses the output of git log with gitLogFormat and --name-only.
+// The file names are relative to the root directory.
+func parseGitLog(r io.Reader, rootPath string) ([]gitCommit, error) {
+	commits := []gitCommit{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			timestamp, author, _ := strings.Cut(header, " ")
+			seconds, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid commit header %q", header)
+			}
+
+			commits = append(commits, gitCommit{time: time.Unix(seconds, 0).UTC(), author: author})
+			continue
+		}
+
+		if line == "" || len(commits) == 0 {
+			continue
+		}
+
+		last := &commits[len(commits)-1]
+		last.files = append(last.files, filepath.Join(rootPath, filepath.FromSlash(line)))
+	}
+
+	return commits, scanner.Err()
+}
+
+// buildHistories sums up the commits by file and by directory, up to the root directory. A commit changing
+// several files of a directory, or of its subdirectories, counts once for it. Only the commits since
+// the start of the window are counted, but the top contributors are the authors of the most commits of the whole history.
+func buildHistories(commits []gitCommit, rootPath string, since time.Time) map[string]*FileHistory {
+	histories := map[string]*FileHistory{}
+	authors := map[string]map[string]int{}
+
+	for _, commit := range commits {
+		paths := map[string]bool{}
+		for _, file := range commit.files {
+			paths[file] = true
+			for dir := filepath.Dir(file); !paths[dir]; dir = filepath.Dir(dir) {
+				paths[dir] = true
+				if dir == rootPath || dir == filepath.Dir(dir) {
+					break
+				}
+			}
+		}
+
+		for path := range paths {
+			history, ok := histories[path]
+			if !ok {
+				history = &FileHistory{}
+				histories[path] = history
+				authors[path] = map[string]int{}
+			}
+
+			if commit.time.After(history.LastModified) {
+				history.LastModified = commit.time
+			}
+			if !commit.time.Before(since) {
+				history.Commits++
+			}
+			authors[path][commit.author]++
+		}
+	}
+
+	for path, history := range histories {
+		history.Authors = topAuthors(authors[path])
+	}
+
+	return histories
+}
+
+// topAuthors returns the authors of the most commits, sorted by the number of commits and then by name.
+func topAuthors(commits map[string]int) []string {
+	authors := make([]string, 0, len(commits))
+	for author := range commits {
+		authors = append(authors, author)
+	}
+
+	sort.Slice(authors, func(i, j int) bool {
+		if commits[authors[i]] != commits[authors[j]] {
+			return commits[authors[i]] > commits[authors[j]]
+		}
+		return authors[i] < authors[j]
+	})
+
+	if len(authors) > historyTopAuthors {
+		authors = authors[:historyTopAuthors]
+	}
+
+	return authors
+}
+
+// historyDays returns the number of days of the history window.
+func (opts Options) historyDays() int {
+	if opts.HistoryDays > 0 {
+		return opts.HistoryDays
+	}
+
+	return DefaultHistoryDays
+}
+
+// loadHistory reads the git history if asked for, and annotates the traversed files and directories with it.
+// Out of a git repository, or without git, the history is left out with a warning.
+func (rg *ReportGenerator) loadHistory() {
+	files := rg.fileTraverser.Files
+	for i := range files {
+		files[i].History = nil
+	}
+
+	if !rg.options.History {
+		return
+	}
+
+	commits, err := readGitLog(rg.rootPath)
+	if err != nil {
+		rg.warnings = append(rg.warnings, Warning{Path: rg.rootPath, Op: "reading git history", Err: err})
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -rg.options.historyDays())
+	histories := buildHistories(commits, rg.rootPath, since)
+	for i := range files {
+		files[i].History = histories[files[i].Path]
+	}
+}
+
+// showsHistory reports whether the history is rendered: it's asked for and it has been loaded.
+func (rg *ReportGenerator) showsHistory() bool {
+	if !rg.options.History {
+		return false
+	}
+
+	for _, file := range rg.fileTraverser.Files {
+		if file.History != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dirHistory returns the history of a traversed directory, nil if there's none.
+func (rg *ReportGenerator) dirHistory(dirPath string) *FileHistory {
+	for _, file := range rg.fileTraverser.Files {
+		if file.Type == TypeDir && file.Path == dirPath {
+			return file.History
+		}
+	}
+
+	return nil
+}
+
+// renderHistory renders the history section: the files changed most often within the window
+// and the packages left untouched for the longest time.
+func (rg *ReportGenerator) renderHistory(sections []markdownSection) string {
+	hotSpots := []File{}
+	for _, file := range rg.fileTraverser.Files {
+		if file.Type == TypeFile && file.History != nil && file.History.Commits > 0 {
+			hotSpots = append(hotSpots, file)
+		}
+	}
+	sort.SliceStable(hotSpots, func(i, j int) bool {
+		return hotSpots[i].History.Commits > hotSpots[j].History.Commits
+	})
+	if len(hotSpots) > historyListCount {
+		hotSpots = hotSpots[:historyListCount]
+	}
+
+	stale := []markdownSection{}
+	for _, section := range sections {
+		if section.history != nil {
+			stale = append(stale, section)
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].history.LastModified.Before(stale[j].history.LastModified)
+	})
+	if len(stale) > historyListCount {
+		stale = stale[:historyListCount]
+	}
+
+	days := rg.options.historyDays()
+
+	var builder strings.Builder
+	builder.WriteString("\n\n## History\n")
+	builder.WriteString(fmt.Sprintf("\nMost changed files in the last %d days:\n", days))
+	if len(hotSpots) == 0 {
+		builder.WriteString(" - none\n")
+	}
+	for _, file := range hotSpots {
+		builder.WriteString(fmt.Sprintf(" - %s: %s\n", rg.displayPath(file.Path), pluralize(file.History.Commits, "commit")))
+	}
+
+	if len(stale) > 0 {
+		builder.WriteString("\nLeast recently changed packages:\n")
+		for _, section := range stale {
+			builder.WriteString(fmt.Sprintf(" - %s (%s): %s\n", section.pkg, section.dirPath, section.history.LastModified.Format("2006-01-02")))
+		}
+	}
+
+	return builder.String()
+}
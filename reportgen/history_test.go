@@ -0,0 +1,159 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildHistories(t *testing.T) {
+	log := "\x001700000000 alice\n" +
+		"\n" +
+		"app/app.go\n" +
+		"app/util.go\n" +
+		"\x001650000000 carol\n" +
+		"\n" +
+		"app/store/store.go\n" +
+		"\x001600000000 bob\n" +
+		"\n" +
+		"app/app.go\n" +
+		"\x001500000000 alice\n" +
+		"\n" +
+		"README.md\n" +
+		"app/app.go\n"
+
+	commits, err := parseGitLog(strings.NewReader(log), "/repo")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 4)
+
+	histories := buildHistories(commits, "/repo", time.Unix(1550000000, 0))
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected *FileHistory
+	}{
+		{
+			name: "File changed by several authors",
+			path: "/repo/app/app.go",
+			expected: &FileHistory{
+				LastModified: time.Unix(1700000000, 0).UTC(),
+				Commits:      2,
+				Authors:      []string{"alice", "bob"},
+			},
+		},
+		{
+			name: "Commits changing several files of a directory and its subdirectories count once",
+			path: "/repo/app",
+			expected: &FileHistory{
+				LastModified: time.Unix(1700000000, 0).UTC(),
+				Commits:      3,
+				Authors:      []string{"alice", "bob", "carol"},
+			},
+		},
+		{
+			name: "Commits count for every directory up to the root directory",
+			path: "/repo",
+			expected: &FileHistory{
+				LastModified: time.Unix(1700000000, 0).UTC(),
+				Commits:      3,
+				Authors:      []string{"alice", "bob", "carol"},
+			},
+		},
+		{
+			name:     "Commits don't count above the root directory",
+			path:     "/",
+			expected: nil,
+		},
+		{
+			name: "Commits before the window are not counted",
+			path: "/repo/README.md",
+			expected: &FileHistory{
+				LastModified: time.Unix(1500000000, 0).UTC(),
+				Commits:      0,
+				Authors:      []string{"alice"},
+			},
+		},
+		{
+			name:     "File never committed",
+			path:     "/repo/app/new.go",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, histories[tc.path])
+		})
+	}
+}
+
+func TestGenerateReportWithHistory(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "server.go")
+	os.WriteFile(filePath, []byte("package server"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "notes.txt"), []byte("notes"), 0644)
+
+	git := func(env []string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		cmd.Env = append(os.Environ(), env...)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+	alice := []string{"GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@example.com", "GIT_COMMITTER_NAME=alice",
+		"GIT_COMMITTER_EMAIL=alice@example.com", "GIT_AUTHOR_DATE=2001-02-03T12:00:00Z", "GIT_COMMITTER_DATE=2001-02-03T12:00:00Z"}
+	git(nil, "init", "-q")
+	git(nil, "add", "server.go")
+	git(alice, "commit", "-q", "-m", "Add server")
+
+	finder := &fakeFinder{components: ComponentMap{
+		tmpDir + ":Server": Component{File: filePath, Package: "server", Name: "Server", Type: "struct"},
+	}}
+	rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{History: true, HistoryDays: 30})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "\t- server.go (modified 2001-02-03, 0 commits in 30d, by alice)\n")
+	assert.Contains(t, buffer.String(), "\t- notes.txt\n")
+	assert.Contains(t, buffer.String(), " - dir: /server (modified 2001-02-03, 0 commits in 30d, by alice)\n")
+	assert.Contains(t, buffer.String(), "## History\n\nMost changed files in the last 30 days:\n - none\n\n"+
+		"Least recently changed packages:\n - server (/server): 2001-02-03\n")
+}
+
+func TestGenerateReportWithHistoryOutOfGit(t *testing.T) {
+	tmpDir := t.TempDir()
+	// git doesn't look for a repository above the directory
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(tmpDir))
+	filePath := filepath.Join(tmpDir, "server.go")
+	os.WriteFile(filePath, []byte("package server"), 0644)
+
+	finder := &fakeFinder{components: ComponentMap{
+		tmpDir + ":Server": Component{File: filePath, Package: "server", Name: "Server", Type: "struct"},
+	}}
+	rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{History: true})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "\t- server.go\n")
+	assert.Contains(t, buffer.String(), " - dir: /server\n")
+	assert.NotContains(t, buffer.String(), "## History")
+	assert.Len(t, rg.Warnings(), 1)
+	assert.Equal(t, "reading git history", rg.Warnings()[0].Op)
+}
@@ -0,0 +1,241 @@
+package reportgen
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// htmlPage is the data of the HTML report template.
+type htmlPage struct {
+	Title      string
+	Tree       *htmlTreeNode
+	Components []htmlComponent
+}
+
+// htmlTreeNode is a file or a directory of the collapsible directory tree.
+type htmlTreeNode struct {
+	Name     string
+	IsDir    bool
+	Open     bool // Open expands the directory when the page is loaded
+	Children []*htmlTreeNode
+}
+
+// htmlComponent is the card of a component.
+type htmlComponent struct {
+	ID        string
+	Component Component
+	File      string
+	Relations []htmlRelationGroup
+}
+
+// htmlRelationGroup lists the components related to a component in the same way, e.g. "Implements".
+type htmlRelationGroup struct {
+	Label string
+	Links []htmlLink
+}
+
+type htmlLink struct {
+	ID   string
+	Name string
+}
+
+// htmlRelationLabels maps the relationship kinds to the labels of the outgoing and the incoming relationships.
+var htmlRelationLabels = map[string][2]string{
+	RelationEmbedding:      {"Embeds", "Embedded by"},
+	RelationComposition:    {"Has fields of", "Used in fields of"},
+	RelationImplementation: {"Implements", "Implemented by"},
+}
+
+// renderHTML renders the report as a single HTML page that works offline: a collapsible directory tree,
+// a searchable component index and a card for every component, linked to its related components.
+func (rg *ReportGenerator) renderHTML(outputCompMap OutputComponentMap) (string, error) {
+	page := htmlPage{
+		Title: rg.rootDirName,
+		Tree:  rg.buildHTMLTree(),
+	}
+
+	// Group the related components of every component by the kind and direction of the relationship
+	names := map[string]string{}
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			names[dirPath+":"+comp.Name] = comp.Package + "." + comp.Name
+		}
+	}
+
+	related := map[string]map[string][]htmlLink{}
+	addRelated := func(key, label, otherKey string) {
+		if related[key] == nil {
+			related[key] = map[string][]htmlLink{}
+		}
+		otherDir, otherName, _ := strings.Cut(otherKey, ":")
+		related[key][label] = append(related[key][label], htmlLink{ID: rg.diagramID(otherDir, otherName), Name: names[otherKey]})
+	}
+	for _, rel := range rg.relationships(outputCompMap) {
+		labels := htmlRelationLabels[rel.Kind]
+		addRelated(rel.From, labels[0], rel.To)
+		addRelated(rel.To, labels[1], rel.From)
+	}
+
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		comps := append([]Component{}, outputCompMap[dirPath]...)
+		sort.SliceStable(comps, func(i, j int) bool {
+			return comps[i].Name < comps[j].Name
+		})
+
+		for _, comp := range comps {
+			key := dirPath + ":" + comp.Name
+			card := htmlComponent{
+				ID:        rg.diagramID(dirPath, comp.Name),
+				Component: comp,
+				File:      rg.displayPath(comp.File),
+			}
+
+			for _, kind := range []string{RelationImplementation, RelationEmbedding, RelationComposition} {
+				for _, label := range htmlRelationLabels[kind] {
+					if links := related[key][label]; len(links) > 0 {
+						card.Relations = append(card.Relations, htmlRelationGroup{Label: label, Links: links})
+					}
+				}
+			}
+
+			page.Components = append(page.Components, card)
+		}
+	}
+
+	var builder strings.Builder
+	err := htmlTemplate.Execute(&builder, page)
+	if err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+// buildHTMLTree builds the directory tree from the traversed files.
+// Files are listed before the subdirectories, both sorted by name.
+func (rg *ReportGenerator) buildHTMLTree() *htmlTreeNode {
+	root := &htmlTreeNode{Name: rg.rootDirName, IsDir: true, Open: true}
+	nodes := map[string]*htmlTreeNode{rg.fileTraverser.RootPath: root}
+
+	var getNode func(path string) *htmlTreeNode
+	getNode = func(path string) *htmlTreeNode {
+		if node, ok := nodes[path]; ok {
+			return node
+		}
+
+		parent := getNode(filepath.Dir(path))
+		node := &htmlTreeNode{Name: filepath.Base(path), IsDir: true}
+		parent.Children = append(parent.Children, node)
+		nodes[path] = node
+
+		return node
+	}
+
+	for _, file := range rg.fileTraverser.Files {
+		if file.Path == rg.fileTraverser.RootPath || !strings.HasPrefix(file.Path, rg.fileTraverser.RootPath+string(os.PathSeparator)) {
+			continue
+		}
+
+		if file.Type == TypeDir {
+			getNode(file.Path)
+			continue
+		}
+
+		parent := getNode(filepath.Dir(file.Path))
+		parent.Children = append(parent.Children, &htmlTreeNode{Name: filepath.Base(file.Path)})
+	}
+
+	for _, node := range nodes {
+		sort.SliceStable(node.Children, func(i, j int) bool {
+			a, b := node.Children[i], node.Children[j]
+			if a.IsDir != b.IsDir {
+				return !a.IsDir
+			}
+			return a.Name < b.Name
+		})
+	}
+
+	return root
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - repoexplainer</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; display: flex; color: #1f2328; }
+nav { width: 320px; height: 100vh; overflow: auto; position: sticky; top: 0; padding: 16px; box-sizing: border-box; border-right: 1px solid #d0d7de; background: #f6f8fa; }
+main { flex: 1; padding: 16px 32px; min-width: 0; }
+h1 { margin-top: 0; }
+input[type=search] { width: 100%; padding: 6px; box-sizing: border-box; margin-bottom: 12px; }
+ul { list-style: none; padding-left: 16px; margin: 0; }
+nav > ul { padding-left: 0; }
+summary { cursor: pointer; }
+.index a { text-decoration: none; }
+.card { border: 1px solid #d0d7de; border-radius: 6px; padding: 12px 16px; margin-bottom: 16px; }
+.card h3 { margin: 0 0 4px 0; font-family: monospace; word-break: break-all; }
+.meta { color: #656d76; font-size: 0.9em; }
+.doc { white-space: pre-wrap; background: #f6f8fa; padding: 8px; border-radius: 4px; }
+.members li { font-family: monospace; }
+.type { display: inline-block; font-size: 0.75em; padding: 0 6px; border-radius: 10px; background: #ddf4ff; }
+.hidden { display: none; }
+</style>
+</head>
+<body>
+<nav>
+<h2>Components</h2>
+<input type="search" id="search" placeholder="Search components..." autocomplete="off">
+<ul class="index">
+{{- range .Components}}
+<li data-search="{{.Component.Package}} {{.Component.Name}} {{.Component.Type}}"><a href="#{{.ID}}">{{.Component.Package}}.{{.Component.Name}}</a></li>
+{{- end}}
+</ul>
+</nav>
+<main>
+<h1>{{.Title}}</h1>
+<h2>Directory structure</h2>
+<ul>{{template "node" .Tree}}</ul>
+<h2>Components</h2>
+{{- range .Components}}
+<section class="card" id="{{.ID}}" data-search="{{.Component.Package}} {{.Component.Name}} {{.Component.Type}}">
+<h3>{{.Component.Name}}</h3>
+<div class="meta"><span class="type">{{.Component.Type}}</span> package {{.Component.Package}} &middot; {{.File}}</div>
+{{- with .Component.Doc}}
+<p class="doc">{{.}}</p>
+{{- end}}
+{{- with .Component.Fields}}
+<h4>Fields</h4>
+<ul class="members">{{range .}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+{{- with .Component.Methods}}
+<h4>Methods</h4>
+<ul class="members">{{range .}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+{{- range .Relations}}
+<div>{{.Label}}: {{range $i, $link := .Links}}{{if $i}}, {{end}}<a href="#{{$link.ID}}">{{$link.Name}}</a>{{end}}</div>
+{{- end}}
+</section>
+{{- end}}
+</main>
+<script>
+document.getElementById("search").addEventListener("input", function (event) {
+  var query = event.target.value.toLowerCase();
+  document.querySelectorAll("[data-search]").forEach(function (element) {
+    var matches = element.getAttribute("data-search").toLowerCase().indexOf(query) !== -1;
+    element.classList.toggle("hidden", !matches);
+  });
+});
+</script>
+</body>
+</html>
+{{define "node"}}
+{{- if .IsDir}}<li><details{{if .Open}} open{{end}}><summary>{{.Name}}/</summary><ul>{{range .Children}}{{template "node" .}}{{end}}</ul></details></li>
+{{- else}}<li>{{.Name}}</li>
+{{- end}}
+{{- end}}
+`))
@@ -0,0 +1,66 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateHTMLReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "shop"), 0755)
+	filePath := filepath.Join(tmpDir, "shop", "shop.go")
+	os.WriteFile(filePath, []byte("package shop"), 0644)
+
+	finder := &fakeFinder{components: ComponentMap{
+		filepath.Dir(filePath) + ":Cart": Component{
+			File:    filePath,
+			Package: "shop",
+			Name:    "Cart",
+			Type:    "interface",
+			Methods: []string{"Total() int"},
+			Doc:     "Cart holds the <items> to buy.",
+		},
+		filepath.Dir(filePath) + ":order": Component{
+			File:    filePath,
+			Package: "shop",
+			Name:    "order",
+			Type:    "struct",
+			Fields:  []string{"items []string"},
+			Methods: []string{"Total() int"},
+		},
+	}}
+
+	rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{Format: FormatHTML})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+	assert.NoError(t, err)
+
+	output := buffer.String()
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Directory tree", expected: "<summary>shop/</summary><ul><li>shop.go</li></ul>"},
+		{name: "Component index", expected: `<a href="#shop_Cart">shop.Cart</a>`},
+		{name: "Component card", expected: `<section class="card" id="shop_order"`},
+		{name: "Escaped doc comment", expected: `<p class="doc">Cart holds the &lt;items&gt; to buy.</p>`},
+		{name: "Link to the implemented interface", expected: `Implements: <a href="#shop_Cart">shop.Cart</a>`},
+		{name: "Link to the implementing type", expected: `Implemented by: <a href="#shop_order">shop.order</a>`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Contains(t, output, tc.expected)
+		})
+	}
+
+	// The page must work offline
+	assert.NotContains(t, output, "http://")
+	assert.NotContains(t, output, "https://")
+}
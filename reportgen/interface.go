@@ -1,5 +1,11 @@
 package reportgen
 
+import "errors"
+
+// ErrIncompleteResult is returned by FileResult, along with the findings, when the finder failed
+// on the current file. The findings are used by the run, but they are not cached.
+var ErrIncompleteResult = errors.New("incomplete file result")
+
 // ComponentFinder is an interface for finding definitions of components like interfaces,
 // structs, functions, etc., within files. It analyzes lines of code and identifies
 // components based on the provided definitions.
@@ -19,7 +25,61 @@ type ComponentFinder interface {
 	GetComponents() ComponentMap
 }
 
+// DependencyFinder is an optional interface of a ComponentFinder that also finds the packages
+// each directory depends on. The dependencies are used to draw the package dependency graph.
+type DependencyFinder interface {
+	// GetDependencies returns a DependencyMap of all the imports found by the finder.
+	GetDependencies() DependencyMap
+}
+
+// MarkerFinder is an optional interface of a ComponentFinder that also finds the markers
+// left in comments, like TODO notes and deprecation notices.
+type MarkerFinder interface {
+	// GetMarkers returns all the markers found by the finder.
+	GetMarkers() []Marker
+}
+
+// RootFinder is an optional interface of a ComponentFinder whose findings depend on the root directory
+// of the repo, like the names of the modules relative to it.
+type RootFinder interface {
+	// SetRoot sets the path of the root directory. It's called before the files are given.
+	SetRoot(rootPath string)
+}
+
+// FileResultFinder is an optional interface of a ComponentFinder whose findings can be taken out
+// and put back file by file, so the findings of the files that haven't changed can be cached between runs.
+type FileResultFinder interface {
+	// Version identifies what the finder finds. It must change whenever the finder would find
+	// something else in the same file, so the findings cached by older versions are not used.
+	Version() string
+
+	// FileResult returns the findings of the current file, after its last line is given to FindComponent.
+	// If the finder failed on the file, it returns the findings with ErrIncompleteResult.
+	FileResult() ([]byte, error)
+
+	// RestoreFileResult adds the findings of a file returned by FileResult in an earlier run,
+	// instead of the file being set by SetFile and its lines given to FindComponent.
+	RestoreFileResult(filePath string, result []byte) error
+}
+
+// WarningFinder is an optional interface of a ComponentFinder that can fail on some files without
+// stopping the report, like a finder running an external process. Its warnings are added to the report.
+// A finder that is also an io.Closer is closed once the files are scanned, but it must still return
+// its findings afterwards, and be ready to be given files again by the next report.
+type WarningFinder interface {
+	// GetWarnings returns the warnings since the last call.
+	GetWarnings() []Warning
+}
+
 // FinderFactory is an interface for creating ComponentFinder instances.
 type FinderFactory interface {
 	GetFinders() []ComponentFinder
 }
+
+// FinderCreator is an optional interface of a FinderFactory that creates new sets of its finders,
+// so the files can be scanned in parallel. If every finder is a FileResultFinder, every worker scans
+// its files with its own set and the findings are merged into the finders of GetFinders.
+type FinderCreator interface {
+	// NewFinders returns a new set of the same finders as GetFinders, in the same order, with nothing found yet.
+	NewFinders() []ComponentFinder
+}
@@ -0,0 +1,293 @@
+package reportgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// detailLevel describes which details are left out when rendering the report.
+// The zero value renders every detail.
+type detailLevel struct {
+	dropUnexported bool // dropUnexported leaves out everything but the public API
+	collapseFields bool // collapseFields replaces the field list by the number of fields
+	dropExcerpts   bool // dropExcerpts leaves out the source excerpts
+	treeDepth      int  // treeDepth limits the directory levels listed in the tree, 0 means unlimited
+	dropTests      bool // dropTests leaves out test files and the components defined in them
+}
+
+// omissions counts what has been left out of a rendered report.
+type omissions struct {
+	treeOmissions
+	unexportedComps   int
+	unexportedMembers int
+	collapsedFields   int
+	excerpts          int
+	testComps         int
+}
+
+func (o omissions) any() bool {
+	return o != omissions{}
+}
+
+// markdownSection holds the rendered components of one directory.
+type markdownSection struct {
+	dirPath    string
+	pkg        string
+	components []Component  // components are the filtered components of the directory
+	comps      []string     // comps are the rendered components
+	history    *FileHistory // history is the git history of the directory, nil if not loaded
+	historyTag string       // historyTag is the rendered history annotation of the directory line
+}
+
+// renderMarkdown renders the report in Markdown, leaving out the details according to the level.
+func (rg *ReportGenerator) renderMarkdown(outputCompMap OutputComponentMap, level detailLevel) (string, omissions, error) {
+	dirStructure, omitted, err := rg.fileTraverser.printTree(treeOptions{
+		maxDepth:    level.treeDepth,
+		skipTests:   level.dropTests,
+		historyDays: rg.treeHistoryDays(),
+	})
+	if err != nil {
+		return "", omissions{}, fmt.Errorf("printing directory structure: %s", err)
+	}
+
+	report := omissions{treeOmissions: omitted}
+	sections := rg.renderSections(outputCompMap, level, &report)
+
+	var builder strings.Builder
+	builder.WriteString(rg.renderTitle())
+	builder.WriteString(renderDirStructure(dirStructure))
+	builder.WriteString(componentsHeading)
+	for _, section := range sections {
+		builder.WriteString(section.dirLine())
+		for _, comp := range section.comps {
+			builder.WriteString(comp)
+		}
+	}
+
+	if rg.options.Tests && !level.dropTests {
+		if tests := rg.renderTests(findTests(outputCompMap)); len(tests) > 0 {
+			builder.WriteString(testsHeading)
+			builder.WriteString(strings.Join(tests, ""))
+		}
+	}
+
+	if rg.options.Markers {
+		if markers := rg.getMarkers(outputCompMap); len(markers) > 0 {
+			builder.WriteString(rg.renderMarkers(markers))
+		}
+	}
+
+	if rg.coverage != nil {
+		builder.WriteString(rg.renderCoverage(outputCompMap))
+	}
+
+	if rg.showsHistory() {
+		builder.WriteString(rg.renderHistory(sections))
+	}
+
+	if rg.options.Stats {
+		builder.WriteString(rg.renderStats(outputCompMap))
+	}
+
+	if rg.options.hasExcerpts() {
+		excerpts, err := rg.renderExcerpts(outputCompMap)
+		if err != nil {
+			return "", omissions{}, err
+		}
+
+		if level.dropExcerpts {
+			report.excerpts = len(excerpts)
+		} else if len(excerpts) > 0 {
+			builder.WriteString(excerptsHeading)
+			builder.WriteString(strings.Join(excerpts, ""))
+		}
+	}
+
+	if rg.options.Mermaid {
+		builder.WriteString(rg.renderDiagramSection(sections))
+	}
+
+	return builder.String(), report, nil
+}
+
+// renderDiagramSection renders the class diagram of the components in the sections.
+func (rg *ReportGenerator) renderDiagramSection(sections []markdownSection) string {
+	compMap := OutputComponentMap{}
+	for _, section := range sections {
+		compMap[section.dirPath] = section.components
+	}
+
+	return "\n\n## Class diagram\n\n" + rg.renderMermaid(compMap)
+}
+
+const componentsHeading = "\n\n## Components\n"
+
+func (rg *ReportGenerator) renderTitle() string {
+	return fmt.Sprintf("# %s\n\n", rg.rootDirName)
+}
+
+func renderDirStructure(dirStructure string) string {
+	return "## Directory structure\n\n```\n" + dirStructure + "```\n"
+}
+
+func (section markdownSection) dirLine() string {
+	return fmt.Sprintf(" - dir: %s%s\n", section.dirPath, section.historyTag)
+}
+
+// treeHistoryDays returns the history window of the annotations of the tree, 0 if there's no history.
+func (rg *ReportGenerator) treeHistoryDays() int {
+	if !rg.showsHistory() {
+		return 0
+	}
+
+	return rg.options.historyDays()
+}
+
+// componentNotes are the annotations of the components rendered next to them.
+type componentNotes struct {
+	testNames         map[string][]string          // testNames maps the component keys to the names of their tests
+	deprecated        map[string]string            // deprecated maps the component keys to the deprecation notices
+	deprecatedMethods map[string]map[string]string // deprecatedMethods maps the component keys to the notices by method name
+}
+
+// renderSections renders the components of every directory, sorted by the directory path.
+func (rg *ReportGenerator) renderSections(outputCompMap OutputComponentMap, level detailLevel, report *omissions) []markdownSection {
+	notes := componentNotes{}
+	if rg.options.Tests {
+		notes.testNames = testedBy(findTests(outputCompMap))
+	}
+	if rg.options.Markers {
+		notes.deprecated, notes.deprecatedMethods = deprecations(rg.getMarkers(outputCompMap))
+	}
+
+	sections := []markdownSection{}
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		comps := rg.filterComponents(dirPath, outputCompMap[dirPath], level, report)
+		if len(comps) == 0 {
+			continue
+		}
+
+		section := markdownSection{dirPath: dirPath, pkg: comps[0].Package, components: comps}
+		if history := rg.dirHistory(filepath.Dir(comps[0].File)); history != nil {
+			section.history = history
+			section.historyTag = " " + history.annotation(rg.options.historyDays())
+		}
+		for _, comp := range comps {
+			section.comps = append(section.comps, rg.renderComponent(dirPath, comp, level, report, notes))
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections
+}
+
+// renderComponent renders a component with its notes: the tests that likely test it and the deprecation notices,
+// its code owners if the CODEOWNERS are loaded, and the coverage of its funcs and methods if a coverage profile is loaded.
+func (rg *ReportGenerator) renderComponent(dirPath string, comp Component, level detailLevel, report *omissions, notes componentNotes) string {
+	key := dirPath + ":" + comp.Name
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("     - %s\n", comp.Name))
+	builder.WriteString(fmt.Sprintf("         - file: %s\n", rg.displayPath(comp.File)))
+	builder.WriteString(fmt.Sprintf("         - package: %s\n", comp.Package))
+	builder.WriteString(fmt.Sprintf("         - type: %s\n", comp.Type))
+	if owners := rg.fileOwners[comp.File]; len(owners) > 0 {
+		builder.WriteString(fmt.Sprintf("         - owners: %s\n", strings.Join(owners, ", ")))
+	}
+	if notice, ok := notes.deprecated[key]; ok {
+		builder.WriteString(fmt.Sprintf("         - deprecated: %s\n", notice))
+	}
+	if rg.coverage != nil {
+		if cov, ok := rg.coverage.funcCoverage(comp); ok {
+			builder.WriteString(fmt.Sprintf("         - coverage: %s\n", cov))
+		}
+	}
+	if level.collapseFields && len(comp.Fields) > 0 {
+		builder.WriteString(fmt.Sprintf("         - fields: %d (collapsed)\n", len(comp.Fields)))
+		report.collapsedFields += len(comp.Fields)
+	} else {
+		builder.WriteString("         - fields:\n")
+		for _, field := range comp.Fields {
+			builder.WriteString(fmt.Sprintf("             - %s\n", field))
+		}
+	}
+	builder.WriteString("         - methods:\n")
+	for _, method := range comp.Methods {
+		annotations := []string{}
+		if rg.coverage != nil {
+			if cov, ok := rg.coverage.methodCoverage(comp, method); ok {
+				annotations = append(annotations, "coverage: "+cov.String())
+			}
+		}
+		if notice, ok := notes.deprecatedMethods[key][memberName(method)]; ok {
+			annotations = append(annotations, "deprecated: "+notice)
+		}
+
+		if len(annotations) > 0 {
+			builder.WriteString(fmt.Sprintf("             - %s (%s)\n", method, strings.Join(annotations, ", ")))
+		} else {
+			builder.WriteString(fmt.Sprintf("             - %s\n", method))
+		}
+	}
+	if testNames := notes.testNames[key]; len(testNames) > 0 {
+		builder.WriteString(fmt.Sprintf("         - tested by: %s\n", strings.Join(testNames, ", ")))
+	}
+
+	return builder.String()
+}
+
+// filterComponents returns the components of a directory to render at the given level,
+// sorted by name, and counts the left out ones.
+func (rg *ReportGenerator) filterComponents(dirPath string, comps []Component, level detailLevel, omitted *omissions) []Component {
+	filtered := make([]Component, 0, len(comps))
+	for _, comp := range comps {
+		if level.dropTests && isTestFile(comp.File) {
+			omitted.testComps++
+			continue
+		}
+
+		// The tests are rendered in the tests section
+		if rg.options.Tests && isTestFunc(comp) {
+			continue
+		}
+
+		filtered = append(filtered, comp)
+	}
+
+	// Leaving out the unexported details would leave nothing when only those are asked for
+	if level.dropUnexported && rg.options.Visibility != VisibilityUnexported {
+		var hiddenComps, hiddenMembers int
+		filtered, hiddenComps, hiddenMembers = filterVisibility(dirPath, filtered, VisibilityExported)
+		omitted.unexportedComps += hiddenComps
+		omitted.unexportedMembers += hiddenMembers
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Name < filtered[j].Name
+	})
+
+	return filtered
+}
+
+// displayPath makes the file path start with the root directory.
+func (rg *ReportGenerator) displayPath(path string) string {
+	filePath := strings.TrimPrefix(path, rg.rootPath)
+	if strings.Contains(filePath, "/") {
+		return "/" + rg.rootDirName + filePath
+	}
+
+	return "/" + rg.rootDirName + "/" + filePath
+}
+
+func sortedDirs(outputCompMap OutputComponentMap) []string {
+	dirs := make([]string, 0, len(outputCompMap))
+	for dir := range outputCompMap {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	return dirs
+}
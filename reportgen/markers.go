@@ -0,0 +1,150 @@
+package reportgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// markerKindOrder is the order of the marker kinds in the markers section.
+var markerKindOrder = map[string]int{
+	MarkerDeprecated: 0,
+	MarkerFixme:      1,
+	MarkerHack:       2,
+	MarkerTodo:       3,
+}
+
+// placedMarker is a marker with the component it's in or documents.
+type placedMarker struct {
+	Marker
+	component string // component is like "pkg.Func", "pkg.Type" or "pkg.Type.Method", empty if the marker is outside of any
+	compKey   string // compKey is the key of the component in the format "dirPath:Name"
+	method    string // method is the method name if the marker is in or documents a method
+	documents bool   // documents is true if the marker is in the doc comment of the component or the method
+}
+
+// declSpan is the line range of a component or a method.
+type declSpan struct {
+	name    string
+	compKey string
+	method  string
+	line    int
+	endLine int
+}
+
+// getFoundMarkers returns the markers found by the finders.
+func (rg *ReportGenerator) getFoundMarkers() []Marker {
+	markers := []Marker{}
+	for _, finder := range rg.finderFactory.GetFinders() {
+		if markerFinder, ok := finder.(MarkerFinder); ok {
+			markers = append(markers, markerFinder.GetMarkers()...)
+		}
+	}
+
+	return markers
+}
+
+// getMarkers returns the markers of the rendered report with the components they are in or document,
+// sorted by kind, file and line.
+func (rg *ReportGenerator) getMarkers(outputCompMap OutputComponentMap) []placedMarker {
+	spans := map[string][]declSpan{}
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			compKey := dirPath + ":" + comp.Name
+			name := comp.Package + "." + componentName(comp)
+			if comp.Line > 0 {
+				spans[comp.File] = append(spans[comp.File], declSpan{name: name, compKey: compKey, line: comp.Line, endLine: comp.EndLine})
+			}
+
+			for method, location := range comp.MethodLocations {
+				spans[location.File] = append(spans[location.File], declSpan{
+					name:    name + "." + method,
+					compKey: compKey,
+					method:  method,
+					line:    location.Line,
+					endLine: location.EndLine,
+				})
+			}
+		}
+	}
+
+	markers := []placedMarker{}
+	for _, marker := range rg.report.Markers {
+		placed := placedMarker{Marker: marker}
+		if span, documents, ok := enclosingSpan(spans[marker.File], marker); ok {
+			placed.component, placed.compKey, placed.method = span.name, span.compKey, span.method
+			placed.documents = documents
+		}
+		markers = append(markers, placed)
+	}
+
+	sort.SliceStable(markers, func(i, j int) bool {
+		a, b := markers[i], markers[j]
+		if a.Kind != b.Kind {
+			return markerKindOrder[a.Kind] < markerKindOrder[b.Kind]
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+
+	return markers
+}
+
+// enclosingSpan returns the declaration documented by the comment of a marker, i.e. starting right below it,
+// or else the smallest declaration containing the marker.
+// documents is true for the documented declaration.
+func enclosingSpan(spans []declSpan, marker Marker) (span declSpan, documents bool, ok bool) {
+	for _, s := range spans {
+		if marker.NextCodeLine != 0 && s.line == marker.NextCodeLine {
+			return s, true, true
+		}
+
+		if s.line <= marker.Line && marker.Line <= s.endLine && (!ok || s.endLine-s.line < span.endLine-span.line) {
+			span, ok = s, true
+		}
+	}
+
+	return span, false, ok
+}
+
+// deprecations maps the keys of the deprecated components to the deprecation notices,
+// and the keys of the components with deprecated methods to the notices by method name.
+func deprecations(markers []placedMarker) (map[string]string, map[string]map[string]string) {
+	comps := map[string]string{}
+	methods := map[string]map[string]string{}
+	for _, marker := range markers {
+		if marker.Kind != MarkerDeprecated || !marker.documents {
+			continue
+		}
+
+		if marker.method == "" {
+			comps[marker.compKey] = marker.Text
+			continue
+		}
+
+		if methods[marker.compKey] == nil {
+			methods[marker.compKey] = map[string]string{}
+		}
+		methods[marker.compKey][marker.method] = marker.Text
+	}
+
+	return comps, methods
+}
+
+// renderMarkers renders the markers section, one line per marker.
+func (rg *ReportGenerator) renderMarkers(markers []placedMarker) string {
+	var builder strings.Builder
+	builder.WriteString("\n\n## Markers\n")
+	for _, marker := range markers {
+		location := fmt.Sprintf("%s:%d", rg.displayPath(marker.File), marker.Line)
+		if marker.component != "" {
+			location += ", " + marker.component
+		}
+
+		builder.WriteString(fmt.Sprintf(" - %s (%s): %s\n", marker.Kind, location, marker.Text))
+	}
+
+	return builder.String()
+}
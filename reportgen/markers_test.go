@@ -0,0 +1,76 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMarkerFinder is a ComponentFinder that returns a fixed set of markers.
+type fakeMarkerFinder struct {
+	fakeFinder
+	markers []Marker
+}
+
+func (f *fakeMarkerFinder) GetMarkers() []Marker { return f.markers }
+
+func TestGenerateReportWithMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "server.go")
+	os.WriteFile(filePath, []byte("package server"), 0644)
+
+	finder := &fakeMarkerFinder{
+		fakeFinder: fakeFinder{components: ComponentMap{
+			tmpDir + ":Server": Component{
+				File:    filePath,
+				Package: "server",
+				Name:    "Server",
+				Type:    "struct",
+				Fields:  []string{"Addr string", "Port int"},
+				Methods: []string{"Start() error", "Listen() error"},
+				Line:    5,
+				EndLine: 9,
+				MethodLocations: map[string]Location{
+					"Start":  {File: filePath, Line: 11, EndLine: 15},
+					"Listen": {File: filePath, Line: 18, EndLine: 20},
+				},
+			},
+			tmpDir + ":Old": Component{
+				File:    filePath,
+				Package: "server",
+				Name:    "Old()",
+				Type:    "func",
+				Line:    24,
+				EndLine: 24,
+			},
+		}},
+		markers: []Marker{
+			{Kind: MarkerTodo, Text: "use a pool", File: filePath, Line: 13},
+			{Kind: MarkerDeprecated, Text: "Use Start.", File: filePath, Line: 17, NextCodeLine: 18},
+			{Kind: MarkerDeprecated, Text: "Use Addr.", File: filePath, Line: 7, NextCodeLine: 8},
+			{Kind: MarkerDeprecated, Text: "Use New.", File: filePath, Line: 23, NextCodeLine: 24},
+			{Kind: MarkerFixme, Text: "outside of any component", File: filePath, Line: 2},
+		},
+	}
+
+	rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{Markers: true})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "     - Old()\n         - file: /server/server.go\n         - package: server\n         - type: func\n         - deprecated: Use New.\n")
+	assert.Contains(t, buffer.String(), "             - Listen() error (deprecated: Use Start.)\n")
+	assert.Contains(t, buffer.String(), "             - Start() error\n")
+	assert.NotContains(t, buffer.String(), "         - deprecated: Use Addr.")
+	assert.Contains(t, buffer.String(), "## Markers\n"+
+		" - Deprecated (/server/server.go:7, server.Server): Use Addr.\n"+
+		" - Deprecated (/server/server.go:17, server.Server.Listen): Use Start.\n"+
+		" - Deprecated (/server/server.go:23, server.Old): Use New.\n"+
+		" - FIXME (/server/server.go:2): outside of any component\n"+
+		" - TODO (/server/server.go:13, server.Server.Start): use a pool\n")
+}
@@ -0,0 +1,120 @@
+package reportgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mermaidArrows maps the relationship kinds to the Mermaid class diagram arrows.
+// The arrows point from the component the relationship starts with.
+var mermaidArrows = map[string]string{
+	RelationEmbedding:      "--|>",
+	RelationComposition:    "*--",
+	RelationImplementation: "..|>",
+}
+
+// renderMermaid renders the structs and interfaces as a Mermaid class diagram,
+// with their fields and methods as members and their relationships as edges.
+// The result is a fenced code block that Markdown viewers render as a diagram.
+func (rg *ReportGenerator) renderMermaid(outputCompMap OutputComponentMap) string {
+	var builder strings.Builder
+	builder.WriteString("```mermaid\n")
+	builder.WriteString("classDiagram\n")
+
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		for _, comp := range outputCompMap[dirPath] {
+			if comp.Type != ComponentTypeStruct && comp.Type != ComponentTypeInterface {
+				continue
+			}
+
+			builder.WriteString(fmt.Sprintf("    class %s[\"%s.%s\"] {\n", rg.diagramID(dirPath, comp.Name), comp.Package, comp.Name))
+			builder.WriteString(fmt.Sprintf("        <<%s>>\n", comp.Type))
+			for _, field := range comp.Fields {
+				builder.WriteString(fmt.Sprintf("        %s%s\n", visibilityMarker(memberName(field)), mermaidMember(field)))
+			}
+			for _, method := range comp.Methods {
+				builder.WriteString(fmt.Sprintf("        %s%s\n", visibilityMarker(memberName(method)), mermaidMember(method)))
+			}
+			builder.WriteString("    }\n")
+		}
+	}
+
+	for _, rel := range rg.relationships(outputCompMap) {
+		fromDir, fromName, _ := strings.Cut(rel.From, ":")
+		toDir, toName, _ := strings.Cut(rel.To, ":")
+
+		builder.WriteString(fmt.Sprintf("    %s %s %s", rg.diagramID(fromDir, fromName), mermaidArrows[rel.Kind], rg.diagramID(toDir, toName)))
+		if rel.Label != "" {
+			builder.WriteString(" : " + mermaidMember(rel.Label))
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString("```\n")
+
+	return builder.String()
+}
+
+// diagramIDs gives the components and the packages of the rendered report identifiers that are unique in it.
+type diagramIDs struct {
+	byKey map[string]string // byKey maps the keys of the components and the packages to their identifiers
+	taken map[string]bool
+}
+
+func newDiagramIDs() *diagramIDs {
+	return &diagramIDs{byKey: map[string]string{}, taken: map[string]bool{}}
+}
+
+// get returns the identifier of a key, made of the text with the characters not allowed in diagram identifiers
+// replaced by "_". The keys whose texts only differ by those characters, like "a/b:C" and "a_b:C",
+// get a numbered suffix in the order they are first asked for.
+func (ids *diagramIDs) get(key, text string) string {
+	if id, ok := ids.byKey[key]; ok {
+		return id
+	}
+
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, text)
+
+	id := base
+	for n := 2; ids.taken[id]; n++ {
+		id = fmt.Sprintf("%s_%d", base, n)
+	}
+	ids.byKey[key] = id
+	ids.taken[id] = true
+
+	return id
+}
+
+// diagramID returns an identifier of a component that is unique in the repo and only
+// consists of the characters allowed in diagram identifiers,
+// e.g. "compfinder_golang_ComponentFinder" for "/repo/compfinder/golang:ComponentFinder".
+func (rg *ReportGenerator) diagramID(dirPath, name string) string {
+	return rg.diagramIDs.get(dirPath+":"+name, strings.Trim(rg.relativeDirPath(dirPath)+"/"+name, "/"))
+}
+
+// relativeDirPath returns the directory path without the root directory, e.g. "/compfinder/golang".
+func (rg *ReportGenerator) relativeDirPath(dirPath string) string {
+	return strings.TrimPrefix(dirPath, "/"+rg.rootDirName)
+}
+
+// mermaidMember removes the parts of a field or method that Mermaid can't parse.
+// Struct tags are dropped, and braces would end the class definition.
+func mermaidMember(member string) string {
+	member = strings.TrimSpace(strings.Split(member, "`")[0])
+	member = strings.NewReplacer("{", "", "}", "", "\"", "'").Replace(member)
+
+	return member
+}
+
+func visibilityMarker(name string) string {
+	if IsExported(name) {
+		return "+"
+	}
+
+	return "-"
+}
@@ -0,0 +1,71 @@
+package reportgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMermaid(t *testing.T) {
+	rg := NewReportGenerator("repo", t.TempDir(), &fakeFinderFactory{})
+	compMap := OutputComponentMap{
+		"/repo/shop": {
+			{Package: "shop", Name: "Cart", Type: "interface", Methods: []string{"Total() int"}},
+			{Package: "shop", Name: "Item", Type: "struct", Fields: []string{"Price int"}},
+			{Package: "shop", Name: "order", Type: "struct", Fields: []string{"items []Item `json:\"items\"`"}, Methods: []string{"Total() int"}},
+			{Package: "shop", Name: "New() Cart", Type: "func"},
+		},
+	}
+
+	expected := "```mermaid\n" +
+		"classDiagram\n" +
+		"    class shop_Cart[\"shop.Cart\"] {\n" +
+		"        <<interface>>\n" +
+		"        +Total() int\n" +
+		"    }\n" +
+		"    class shop_Item[\"shop.Item\"] {\n" +
+		"        <<struct>>\n" +
+		"        +Price int\n" +
+		"    }\n" +
+		"    class shop_order[\"shop.order\"] {\n" +
+		"        <<struct>>\n" +
+		"        -items []Item\n" +
+		"        +Total() int\n" +
+		"    }\n" +
+		"    shop_order ..|> shop_Cart\n" +
+		"    shop_order *-- shop_Item : items\n" +
+		"```\n"
+
+	rg.useReport(&Report{Name: "repo", Relationships: FindRelationships(compMap)})
+	assert.Equal(t, expected, rg.renderMermaid(compMap))
+}
+
+func TestDiagramIDCollisions(t *testing.T) {
+	rg := NewReportGenerator("repo", t.TempDir(), &fakeFinderFactory{})
+	compMap := OutputComponentMap{
+		"/repo/a/b": {{Package: "b", Name: "C", Type: "struct", Fields: []string{"D *D"}}},
+		"/repo/a_b": {
+			{Package: "a_b", Name: "C", Type: "struct"},
+			{Package: "a_b", Name: "D", Type: "struct", Fields: []string{"C C"}},
+		},
+	}
+	rg.useReport(&Report{
+		Name: "repo",
+		Packages: []Package{
+			{Dir: "/repo/a/b", Name: "b", Components: compMap["/repo/a/b"]},
+			{Dir: "/repo/a_b", Name: "a_b", Components: compMap["/repo/a_b"]},
+		},
+		Relationships: []Relationship{
+			{From: "/repo/a_b:D", To: "/repo/a_b:C", Kind: RelationComposition, Label: "C"},
+		},
+	})
+
+	output := rg.renderMermaid(compMap)
+
+	assert.Contains(t, output, "    class a_b_C[\"b.C\"] {\n")
+	assert.Contains(t, output, "    class a_b_C_2[\"a_b.C\"] {\n")
+	assert.Contains(t, output, "    a_b_D *-- a_b_C_2 : C\n")
+	assert.Equal(t, "a_b_C", rg.diagramID("/repo/a/b", "C"))
+	assert.Equal(t, "pkg_a_b", rg.packageID("/repo/a/b"))
+	assert.Equal(t, "pkg_a_b_2", rg.packageID("/repo/a_b"))
+}
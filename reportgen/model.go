@@ -1,14 +1,38 @@
 package reportgen
 
+// Types of components that the report knows how to relate to each other.
+const (
+	ComponentTypeStruct    = "struct"
+	ComponentTypeInterface = "interface"
+	ComponentTypeFunc      = "func"
+)
+
 // Component represents a discovered component within the repository.
 // This could be a struct, interface, function, etc., within a Go file.
 type Component struct {
-	File    string   `json:"file"`    // Full path to the file where the component is defined
-	Package string   `json:"package"` // Package name where the component is defined
-	Name    string   `json:"name"`    // Name of the struct
-	Type    string   `json:"type"`    // Component type (e.g., "struct", "interface" and "func")
-	Fields  []string `json:"fields"`  // Fields of the component (relevant for structs and interfaces)
-	Methods []string `json:"methods"` // Methods attached to the component (relevant for structs and interfaces)
+	File    string   `json:"file"`          // Full path to the file where the component is defined
+	Package string   `json:"package"`       // Package name where the component is defined
+	Name    string   `json:"name"`          // Name of the struct
+	Type    string   `json:"type"`          // Component type (e.g., "struct", "interface" and "func")
+	Fields  []string `json:"fields"`        // Fields of the component (relevant for structs and interfaces)
+	Methods []string `json:"methods"`       // Methods attached to the component (relevant for structs and interfaces)
+	Doc     string   `json:"doc,omitempty"` // Doc comment of the component, if any
+
+	Line            int                 `json:"line,omitempty"`            // First line of the definition, starting from 1, 0 if unknown
+	EndLine         int                 `json:"endLine,omitempty"`         // Last line of the definition, 0 if unknown
+	MethodLocations map[string]Location `json:"methodLocations,omitempty"` // Locations of the methods by method name, if known
+	TestCases       []string            `json:"testCases,omitempty"`       // Names of the table-driven test cases and subtests of a test func
+
+	// Visibility is VisibilityExported or VisibilityUnexported if the finder knows whether the component
+	// is part of the public API, like from the __all__ list of a Python module. It's empty if the name tells it.
+	Visibility string `json:"visibility,omitempty"`
+}
+
+// Location is a range of lines in a file.
+type Location struct {
+	File    string `json:"file"`    // Full path to the file
+	Line    int    `json:"line"`    // First line, starting from 1
+	EndLine int    `json:"endLine"` // Last line
 }
 
 // ComponentMap maps a directory path to a slice of Components contained within.
@@ -20,3 +44,30 @@ type ComponentMap map[string]Component
 // The key is the directory path.
 // Key format: "path/to/dir". The path doesn't inclue the root directory and file name.
 type OutputComponentMap map[string][]Component
+
+// Dependency is a package imported by the files in a directory.
+type Dependency struct {
+	ImportPath string `json:"importPath"`    // Import path of the package
+	Dir        string `json:"dir,omitempty"` // Full path to the directory of the package if it's in the repo, otherwise empty
+}
+
+// DependencyMap maps a directory path to the packages imported by the files in it.
+// Key format: "path/to/dir".
+type DependencyMap map[string][]Dependency
+
+// Kinds of markers left in comments.
+const (
+	MarkerTodo       = "TODO"
+	MarkerFixme      = "FIXME"
+	MarkerHack       = "HACK"
+	MarkerDeprecated = "Deprecated"
+)
+
+// Marker is a TODO, FIXME or HACK note or a "Deprecated:" paragraph found in a comment.
+type Marker struct {
+	Kind         string `json:"kind"`                   // One of MarkerTodo, MarkerFixme, MarkerHack and MarkerDeprecated
+	Text         string `json:"text"`                   // Text after the marker, e.g. "handle errors" of "TODO(bob): handle errors"
+	File         string `json:"file"`                   // Full path to the file where the marker is
+	Line         int    `json:"line"`                   // Line of the marker, starting from 1
+	NextCodeLine int    `json:"nextCodeLine,omitempty"` // Line of the code right below the comment of the marker, 0 if none
+}
@@ -0,0 +1,154 @@
+package reportgen
+
+import (
+	"fmt"
+	"path"
+)
+
+// Output formats of the report.
+const (
+	FormatMarkdown = "markdown" // FormatMarkdown is the report describing the repo for chat-based AI
+	FormatDOT      = "dot"      // FormatDOT is the package and type graphs in the Graphviz DOT language
+	FormatPlantUML = "plantuml" // FormatPlantUML is the package and type diagrams in PlantUML
+	FormatHTML     = "html"     // FormatHTML is an interactive single page report that works offline
+)
+
+// Options controls how the ReportGenerator builds the report.
+// The zero value generates the full report.
+type Options struct {
+	// MaxTokens is the approximate number of tokens the report should fit into.
+	// Details are left out progressively until the report fits. 0 means no limit.
+	MaxTokens int
+
+	// ChunkTokens is the approximate number of tokens of each part generated by GenerateChunks.
+	// 0 means the report is not split.
+	ChunkTokens int
+
+	// Visibility selects the components and members shown in the report:
+	// VisibilityAll (the default), VisibilityExported or VisibilityUnexported.
+	Visibility string
+
+	// Mermaid adds a Mermaid class diagram of the structs and interfaces to the report.
+	Mermaid bool
+
+	// Format is the output format of GenerateReport: FormatMarkdown (the default), FormatDOT,
+	// FormatPlantUML or FormatHTML.
+	// Token budgets and chunks are only supported by FormatMarkdown.
+	Format string
+
+	// IncludeBodies are the symbols whose source code is shown in the report,
+	// e.g. "reportgen.ReportGenerator.GenerateReport" or "app.Run". The symbols of the same name in several
+	// directories are named with their directory, e.g. "cmd/repoexplainer:main.main".
+	IncludeBodies []string
+
+	// IncludeBodiesUnder shows the source code of every func and method shorter than this number of lines.
+	// 0 means none.
+	IncludeBodiesUnder int
+
+	// IncludeFiles are glob patterns of the files shown as a whole in the report.
+	// Patterns with a slash match the path relative to the root directory, the others match the file name.
+	IncludeFiles []string
+
+	// Exclude are glob patterns of the files and directories left out of the report, like "*.pb.go" or "vendor".
+	// Patterns with a slash match the path relative to the root directory, the others match the name.
+	Exclude []string
+
+	// Tests moves the tests, benchmarks, fuzz targets and examples to a section of their own,
+	// with the components they likely test and their test case names.
+	// The tested components list the tests that test them.
+	Tests bool
+
+	// Markers adds a section listing the TODO, FIXME and HACK notes and the deprecation notices,
+	// and flags the deprecated components and methods.
+	Markers bool
+
+	// CoverageProfile is the path to a coverage profile produced by "go test -coverprofile".
+	// The funcs and methods are annotated with their statement coverage, and a coverage section
+	// lists the coverage of every package and the untested exported funcs and methods.
+	CoverageProfile string
+
+	// History annotates the files and directories of the tree and the packages with the git history
+	// of the local repository: the last modification date, the number of commits within the window
+	// of HistoryDays and the top contributors. A history section lists the hot spots and the stale packages.
+	History bool
+
+	// HistoryDays is the number of days of the window the commits are counted over.
+	// 0 means DefaultHistoryDays.
+	HistoryDays int
+
+	// Owners annotates the directories of the tree and the components with their code owners
+	// from the CODEOWNERS file in the root, the .github or the docs directory.
+	Owners bool
+
+	// Cache keeps the findings of every file in CacheDir under the root directory, so the next runs
+	// only scan the files that have changed. Every finder must be a FileResultFinder.
+	Cache bool
+
+	// Workers is the number of files scanned in parallel, 0 means the number of CPUs.
+	// Files are scanned one by one if the finder factory is not a FinderCreator
+	// or a finder is not a FileResultFinder.
+	Workers int
+
+	// Stats adds a statistics section with the size of every package and the largest files and funcs.
+	Stats bool
+}
+
+// Validate checks whether the options have valid values.
+func (opts Options) Validate() error {
+	if opts.MaxTokens < 0 {
+		return fmt.Errorf("max tokens must not be negative")
+	}
+
+	if opts.ChunkTokens < 0 {
+		return fmt.Errorf("chunk tokens must not be negative")
+	}
+
+	if opts.IncludeBodiesUnder < 0 {
+		return fmt.Errorf("include bodies under must not be negative")
+	}
+
+	if opts.Workers < 0 {
+		return fmt.Errorf("workers must not be negative")
+	}
+
+	if opts.HistoryDays < 0 {
+		return fmt.Errorf("history days must not be negative")
+	}
+
+	for _, pattern := range opts.IncludeFiles {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid include file pattern %q: %s", pattern, err)
+		}
+	}
+
+	for _, pattern := range opts.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %s", pattern, err)
+		}
+	}
+
+	switch opts.Format {
+	case "", FormatMarkdown:
+	case FormatDOT, FormatPlantUML, FormatHTML:
+		if opts.MaxTokens > 0 || opts.ChunkTokens > 0 {
+			return fmt.Errorf("token budgets and chunks are only supported by the %s format", FormatMarkdown)
+		}
+
+		if opts.hasExcerpts() {
+			return fmt.Errorf("source excerpts are only supported by the %s format", FormatMarkdown)
+		}
+
+		if opts.Stats || opts.Tests || opts.Markers || opts.CoverageProfile != "" || opts.History || opts.Owners {
+			return fmt.Errorf("statistics, tests, markers, coverage, history and owners are only supported by the %s format", FormatMarkdown)
+		}
+	default:
+		return fmt.Errorf("unknown format %q, expected %q, %q, %q or %q", opts.Format, FormatMarkdown, FormatDOT, FormatPlantUML, FormatHTML)
+	}
+
+	return validateVisibility(opts.Visibility)
+}
+
+// hasExcerpts reports whether any source excerpts are asked for.
+func (opts Options) hasExcerpts() bool {
+	return len(opts.IncludeBodies) > 0 || opts.IncludeBodiesUnder > 0 || len(opts.IncludeFiles) > 0
+}
@@ -0,0 +1,356 @@
+package reportgen
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// packageQualifier matches the package names qualifying the identifiers, e.g. "reportgen." in "reportgen.Component".
+var packageQualifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*\.`)
+
+// Kinds of relationships between components.
+const (
+	RelationEmbedding      = "embedding"      // RelationEmbedding is a type embedded in a struct or an interface
+	RelationComposition    = "composition"    // RelationComposition is a type used by a field of a struct
+	RelationImplementation = "implementation" // RelationImplementation is a type implementing an interface
+)
+
+// Relationship is a directed relation between two components.
+// From and To are keys of the components in the format "path/to/dir:ComponentName".
+type Relationship struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"` // Label is the field name of a composition
+}
+
+// typeIndex finds the struct and interface components referenced in Go type expressions.
+type typeIndex struct {
+	byDir     map[string]map[string]Component // byDir maps a directory path to its types by name
+	dirsByPkg map[string][]string             // dirsByPkg maps a package name to the directories declaring it
+}
+
+func newTypeIndex(outputCompMap OutputComponentMap) *typeIndex {
+	idx := &typeIndex{
+		byDir:     map[string]map[string]Component{},
+		dirsByPkg: map[string][]string{},
+	}
+
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			if comp.Type != ComponentTypeStruct && comp.Type != ComponentTypeInterface {
+				continue
+			}
+
+			if idx.byDir[dirPath] == nil {
+				idx.byDir[dirPath] = map[string]Component{}
+				idx.dirsByPkg[comp.Package] = append(idx.dirsByPkg[comp.Package], dirPath)
+			}
+			idx.byDir[dirPath][comp.Name] = comp
+		}
+	}
+
+	return idx
+}
+
+// resolve returns the key of the type named by an identifier used in the given directory.
+// Identifiers qualified by a package name are only resolved if the package name is unique in the repo.
+func (idx *typeIndex) resolve(dirPath, ident string) (string, bool) {
+	ident = strings.TrimLeft(ident, "*")
+	pkg, name, qualified := strings.Cut(ident, ".")
+	if !qualified {
+		_, ok := idx.byDir[dirPath][ident]
+		return dirPath + ":" + ident, ok
+	}
+
+	dirs := idx.dirsByPkg[pkg]
+	if len(dirs) != 1 {
+		return "", false
+	}
+
+	_, ok := idx.byDir[dirs[0]][name]
+	return dirs[0] + ":" + name, ok
+}
+
+// FindRelationships finds the embedding, composition and implementation relationships
+// between the struct and interface components. The result is sorted.
+func FindRelationships(outputCompMap OutputComponentMap) []Relationship {
+	idx := newTypeIndex(outputCompMap)
+	relationships := []Relationship{}
+	seen := map[Relationship]bool{}
+	add := func(rel Relationship) {
+		if !seen[rel] {
+			seen[rel] = true
+			relationships = append(relationships, rel)
+		}
+	}
+
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			from := dirPath + ":" + comp.Name
+
+			switch comp.Type {
+			case ComponentTypeStruct:
+				for _, field := range comp.Fields {
+					names, typeExpr := splitField(field)
+					for _, ident := range typeIdentifiers(typeExpr) {
+						to, ok := idx.resolve(dirPath, ident)
+						if !ok {
+							continue
+						}
+
+						if len(names) == 0 {
+							add(Relationship{From: from, To: to, Kind: RelationEmbedding})
+						} else {
+							add(Relationship{From: from, To: to, Kind: RelationComposition, Label: strings.Join(names, ", ")})
+						}
+					}
+				}
+			case ComponentTypeInterface:
+				for _, method := range comp.Methods {
+					if isEmbeddedInterface(method) {
+						if to, ok := idx.resolve(dirPath, method); ok {
+							add(Relationship{From: from, To: to, Kind: RelationEmbedding})
+						}
+					}
+				}
+			}
+		}
+	}
+
+	for _, rel := range findImplementations(outputCompMap, idx) {
+		add(rel)
+	}
+
+	sort.Slice(relationships, func(i, j int) bool {
+		a, b := relationships[i], relationships[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Label < b.Label
+	})
+
+	return relationships
+}
+
+// relationships returns the relationships of the report between the components of the map,
+// so the components left out of the report, or of a section of it, are left out of the diagrams too.
+func (rg *ReportGenerator) relationships(outputCompMap OutputComponentMap) []Relationship {
+	keys := map[string]bool{}
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			keys[dirPath+":"+comp.Name] = true
+		}
+	}
+
+	relationships := []Relationship{}
+	for _, rel := range rg.report.Relationships {
+		if keys[rel.From] && keys[rel.To] {
+			relationships = append(relationships, rel)
+		}
+	}
+
+	return relationships
+}
+
+// findImplementations finds the types whose methods include all the methods of an interface.
+func findImplementations(outputCompMap OutputComponentMap, idx *typeIndex) []Relationship {
+	type methodSet struct {
+		key     string
+		methods map[string]bool
+	}
+
+	var interfaces, implementers []methodSet
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			key := dirPath + ":" + comp.Name
+			switch comp.Type {
+			case ComponentTypeInterface:
+				methods := map[string]bool{}
+				collectInterfaceMethods(idx, dirPath, comp, methods, map[string]bool{})
+				if len(methods) > 0 {
+					interfaces = append(interfaces, methodSet{key: key, methods: methods})
+				}
+			case ComponentTypeStruct:
+				methods := map[string]bool{}
+				for _, method := range comp.Methods {
+					methods[signatureKey(method)] = true
+				}
+				implementers = append(implementers, methodSet{key: key, methods: methods})
+			}
+		}
+	}
+
+	relationships := []Relationship{}
+	for _, iface := range interfaces {
+		for _, impl := range implementers {
+			implements := true
+			for method := range iface.methods {
+				if !impl.methods[method] {
+					implements = false
+					break
+				}
+			}
+
+			if implements {
+				relationships = append(relationships, Relationship{From: impl.key, To: iface.key, Kind: RelationImplementation})
+			}
+		}
+	}
+
+	return relationships
+}
+
+// collectInterfaceMethods collects the methods of an interface, including the ones of the embedded interfaces.
+func collectInterfaceMethods(idx *typeIndex, dirPath string, iface Component, methods, visited map[string]bool) {
+	visited[dirPath+":"+iface.Name] = true
+	for _, method := range iface.Methods {
+		if !isEmbeddedInterface(method) {
+			methods[signatureKey(method)] = true
+			continue
+		}
+
+		key, ok := idx.resolve(dirPath, method)
+		if !ok || visited[key] {
+			continue
+		}
+
+		embeddedDir, name, _ := strings.Cut(key, ":")
+		collectInterfaceMethods(idx, embeddedDir, idx.byDir[embeddedDir][name], methods, visited)
+	}
+}
+
+// isEmbeddedInterface reports whether an interface element is an embedded type instead of a method.
+func isEmbeddedInterface(element string) bool {
+	return !strings.Contains(element, "(")
+}
+
+// splitField splits a struct field definition into the field names and the type expression.
+// Embedded fields have no names.
+func splitField(field string) ([]string, string) {
+	field = strings.TrimSpace(strings.Split(field, "`")[0])
+	parts := strings.Fields(field)
+	if len(parts) <= 1 {
+		return nil, field
+	}
+
+	// Field names are separated by commas, e.g. "a, b int"
+	i := 0
+	for i < len(parts)-1 && strings.HasSuffix(parts[i], ",") {
+		i++
+	}
+
+	names := make([]string, 0, i+1)
+	for _, name := range parts[:i+1] {
+		names = append(names, strings.TrimSuffix(name, ","))
+	}
+
+	return names, strings.Join(parts[i+1:], " ")
+}
+
+// typeIdentifiers returns the possibly qualified type names used in a type expression,
+// e.g. "map[string]*reportgen.Component" gives "string" and "reportgen.Component".
+func typeIdentifiers(typeExpr string) []string {
+	return strings.FieldsFunc(typeExpr, func(r rune) bool {
+		return !(r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127)
+	})
+}
+
+// signatureKey normalizes a method signature so it's comparable between a type and an interface,
+// ignoring the parameter names, the package qualifiers and the white spaces,
+// e.g. "GetComponents(filePath string) reportgen.ComponentMap" gives "GetComponents(string)ComponentMap".
+func signatureKey(method string) string {
+	name := identifierBefore(method, "(")
+	params, results := splitParens(strings.TrimSpace(method[len(name):]))
+
+	if strings.HasPrefix(results, "(") {
+		results, _ = splitParens(results)
+	}
+
+	key := name + "(" + strings.Join(paramTypes(params), ",") + ")" + strings.Join(paramTypes(results), ",")
+
+	// The interface might be declared in another package than the types of its methods
+	return packageQualifier.ReplaceAllString(key, "")
+}
+
+// splitParens returns the content of the leading parenthesized group and the text after it.
+func splitParens(s string) (string, string) {
+	if !strings.HasPrefix(s, "(") {
+		return "", strings.TrimSpace(s)
+	}
+
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], strings.TrimSpace(s[i+1:])
+			}
+		}
+	}
+
+	return s[1:], ""
+}
+
+// paramTypes returns the types of a parameter list like "a, b int, c string".
+func paramTypes(list string) []string {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+
+	items := splitTopLevel(list)
+	named := false
+	for _, item := range items {
+		if len(strings.Fields(item)) > 1 {
+			named = true
+			break
+		}
+	}
+
+	types := make([]string, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		parts := strings.Fields(items[i])
+		switch {
+		case !named:
+			types[i] = strings.Join(parts, "")
+		case len(parts) > 1:
+			types[i] = strings.Join(parts[1:], "")
+		case i+1 < len(items):
+			// Grouped parameter names share the type of the following parameter
+			types[i] = types[i+1]
+		}
+	}
+
+	return types
+}
+
+// splitTopLevel splits a list by the commas outside of brackets and parentheses.
+func splitTopLevel(list string) []string {
+	items := []string{}
+	depth, start := 0, 0
+	for i, r := range list {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				items = append(items, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(items, list[start:])
+}
@@ -0,0 +1,98 @@
+package reportgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRelationships(t *testing.T) {
+	testCases := []struct {
+		name     string
+		compMap  OutputComponentMap
+		expected []Relationship
+	}{
+		{
+			name: "Embedding and composition in the same package",
+			compMap: OutputComponentMap{
+				"/repo/shop": {
+					{Package: "shop", Name: "Base", Type: "struct", Fields: []string{"ID int"}},
+					{Package: "shop", Name: "Item", Type: "struct"},
+					{Package: "shop", Name: "Order", Type: "struct", Fields: []string{"*Base", "items, gifts []*Item `json:\"items\"`", "Total int"}},
+				},
+			},
+			expected: []Relationship{
+				{From: "/repo/shop:Order", To: "/repo/shop:Base", Kind: RelationEmbedding},
+				{From: "/repo/shop:Order", To: "/repo/shop:Item", Kind: RelationComposition, Label: "items, gifts"},
+			},
+		},
+		{
+			name: "Implementation of an interface in another package",
+			compMap: OutputComponentMap{
+				"/repo/reportgen": {
+					{Package: "reportgen", Name: "Finder", Type: "interface", Methods: []string{"SetFile(filePath string)", "GetComponents() ComponentMap"}},
+					{Package: "reportgen", Name: "Generator", Type: "struct", Fields: []string{"finder Finder"}},
+				},
+				"/repo/golang": {
+					{Package: "golang", Name: "GoFinder", Type: "struct", Methods: []string{"SetFile(path string)", "GetComponents() reportgen.ComponentMap", "extra()"}},
+					{Package: "golang", Name: "Partial", Type: "struct", Methods: []string{"SetFile(path string)"}},
+				},
+			},
+			expected: []Relationship{
+				{From: "/repo/golang:GoFinder", To: "/repo/reportgen:Finder", Kind: RelationImplementation},
+				{From: "/repo/reportgen:Generator", To: "/repo/reportgen:Finder", Kind: RelationComposition, Label: "finder"},
+			},
+		},
+		{
+			name: "Embedded interfaces are part of the method set",
+			compMap: OutputComponentMap{
+				"/repo/io": {
+					{Package: "io", Name: "Reader", Type: "interface", Methods: []string{"Read(p []byte) (n int, err error)"}},
+					{Package: "io", Name: "ReadCloser", Type: "interface", Methods: []string{"Reader", "Close() error"}},
+					{Package: "io", Name: "File", Type: "struct", Methods: []string{"Read(buf []byte) (int, error)", "Close() error"}},
+				},
+			},
+			expected: []Relationship{
+				{From: "/repo/io:File", To: "/repo/io:ReadCloser", Kind: RelationImplementation},
+				{From: "/repo/io:File", To: "/repo/io:Reader", Kind: RelationImplementation},
+				{From: "/repo/io:ReadCloser", To: "/repo/io:Reader", Kind: RelationEmbedding},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, FindRelationships(tc.compMap))
+		})
+	}
+}
+
+func TestSignatureKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		signature string
+		expected  string
+	}{
+		{
+			name:      "No parameters",
+			signature: "Close() error",
+			expected:  "Close()error",
+		},
+		{
+			name:      "Grouped parameter names",
+			signature: "Add(a, b int) int",
+			expected:  "Add(int,int)int",
+		},
+		{
+			name:      "Named results and qualified types",
+			signature: "Find(ctx context.Context, f func(string) bool) (comps []reportgen.Component, err error)",
+			expected:  "Find(Context,func(string)bool)[]Component,error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, signatureKey(tc.signature))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package reportgen
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Report is what the analysis of a repo has found, before it's rendered.
+// Other programs can inspect it, change it (e.g. leave out packages or components)
+// and marshal it to JSON before rendering it with Render.
+type Report struct {
+	Name     string `json:"name"`     // Name is the name of the repo, i.e. the base name of the root directory
+	RootPath string `json:"rootPath"` // RootPath is the full path to the root directory
+
+	// Tree are the traversed files and directories, in the order of the traversal.
+	Tree []File `json:"tree"`
+
+	// Packages are the directories with components, sorted by the directory path.
+	// Only the components with the visibility of Options.Visibility are kept.
+	Packages []Package `json:"packages"`
+
+	// Relationships are the embedding, composition and implementation relationships between the components.
+	// The diagrams render the ones between the components of Packages.
+	Relationships []Relationship `json:"relationships"`
+
+	// Dependencies are the packages imported by the files of every directory.
+	Dependencies DependencyMap `json:"dependencies,omitempty"`
+
+	// Markers are the TODO, FIXME and HACK notes and the deprecation notices.
+	Markers []Marker `json:"markers,omitempty"`
+
+	// Stats are the line counts of the scanned files.
+	Stats []FileStats `json:"stats"`
+
+	// Warnings are the files and directories that couldn't be read and have been left out.
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+// Package is a directory with components.
+type Package struct {
+	Dir        string      `json:"dir"`  // Dir is the directory path starting from the root directory, e.g. "/repo/reportgen"
+	Name       string      `json:"name"` // Name is the package name of the first component
+	Components []Component `json:"components"`
+}
+
+// ComponentMap returns the components of the packages by directory path.
+func (r *Report) ComponentMap() OutputComponentMap {
+	outputCompMap := OutputComponentMap{}
+	for _, pkg := range r.Packages {
+		if len(pkg.Components) > 0 {
+			outputCompMap[pkg.Dir] = append(outputCompMap[pkg.Dir], pkg.Components...)
+		}
+	}
+
+	return outputCompMap
+}
+
+// Analyze traverses the repo and scans its files, and loads the coverage profile, the git history and
+// the CODEOWNERS if the options ask for them. The traversal and the scanning stop as soon as
+// the context is canceled.
+func (rg *ReportGenerator) Analyze(ctx context.Context) (*Report, error) {
+	err := rg.options.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("validating options: %s", err)
+	}
+
+	err = rg.findCodeStructuresInFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("finding code structures in files: %s", err)
+	}
+
+	err = rg.loadCoverage()
+	if err != nil {
+		return nil, err
+	}
+
+	rg.loadHistory()
+
+	err = rg.loadCodeOwners()
+	if err != nil {
+		return nil, err
+	}
+
+	outputCompMap := rg.getOutputCompMap()
+	report := &Report{
+		Name:          rg.rootDirName,
+		RootPath:      rg.rootPath,
+		Tree:          append([]File{}, rg.fileTraverser.Files...),
+		Packages:      []Package{},
+		Relationships: FindRelationships(outputCompMap),
+		Dependencies:  rg.getOutputDependencies(),
+		Markers:       rg.getFoundMarkers(),
+		Stats:         append([]FileStats{}, rg.fileStats...),
+		Warnings:      append([]Warning{}, rg.warnings...),
+	}
+
+	for _, dirPath := range sortedDirs(outputCompMap) {
+		comps := append([]Component{}, outputCompMap[dirPath]...)
+		// The finders find the components in no particular order, and modules of a directory can share names
+		sort.SliceStable(comps, func(i, j int) bool {
+			if comps[i].Name != comps[j].Name {
+				return comps[i].Name < comps[j].Name
+			}
+			if comps[i].File != comps[j].File {
+				return comps[i].File < comps[j].File
+			}
+
+			return comps[i].Line < comps[j].Line
+		})
+		report.Packages = append(report.Packages, Package{Dir: dirPath, Name: comps[0].Package, Components: comps})
+	}
+
+	return report, nil
+}
+
+// useReport makes the renderers render the report.
+func (rg *ReportGenerator) useReport(report *Report) {
+	rg.report = report
+	rg.rootDirName = report.Name
+	rg.rootPath = report.RootPath
+	rg.fileTraverser = &FileTraverser{RootPath: report.RootPath, Files: report.Tree, currentFile: -1}
+	rg.fileStats = report.Stats
+	rg.warnings = report.Warnings
+
+	// The identifiers are given in the order of the report, so they don't depend on what's rendered first
+	rg.diagramIDs = newDiagramIDs()
+	for _, pkg := range report.Packages {
+		for _, comp := range pkg.Components {
+			rg.diagramID(pkg.Dir, comp.Name)
+		}
+	}
+
+	rg.fileOwners = map[string][]string{}
+	for _, file := range report.Tree {
+		if file.Type == TypeFile && len(file.Owners) > 0 {
+			rg.fileOwners[file.Path] = file.Owners
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package reportgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeAndRender(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "server"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "store"), 0755)
+	serverPath := filepath.Join(tmpDir, "server", "server.go")
+	storePath := filepath.Join(tmpDir, "store", "store.go")
+	os.WriteFile(serverPath, []byte("package server\n\n// TODO: add TLS\n"), 0644)
+	os.WriteFile(storePath, []byte("package store\n"), 0644)
+
+	finder := &fakeMarkerFinder{
+		fakeFinder: fakeFinder{components: ComponentMap{
+			filepath.Join(tmpDir, "server") + ":Server":  Component{File: serverPath, Package: "server", Name: "Server", Type: "struct", Fields: []string{"Store store.Store"}},
+			filepath.Join(tmpDir, "server") + ":Handler": Component{File: serverPath, Package: "server", Name: "Handler", Type: "interface"},
+			filepath.Join(tmpDir, "store") + ":Store":    Component{File: storePath, Package: "store", Name: "Store", Type: "struct"},
+		}},
+		markers: []Marker{{Kind: MarkerTodo, Text: "add TLS", File: serverPath, Line: 3}},
+	}
+
+	rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{Markers: true, Stats: true})
+
+	report, err := rg.Analyze(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "repo", report.Name)
+	assert.Len(t, report.Tree, 5)
+	assert.Len(t, report.Stats, 2)
+	assert.Len(t, report.Markers, 1)
+	assert.Equal(t, []Relationship{{From: "/repo/server:Server", To: "/repo/store:Store", Kind: RelationComposition, Label: "Store"}}, report.Relationships)
+	assert.Len(t, report.Packages, 2)
+	assert.Equal(t, "/repo/server", report.Packages[0].Dir)
+	assert.Equal(t, "server", report.Packages[0].Name)
+	assert.Equal(t, "Handler", report.Packages[0].Components[0].Name)
+	assert.Equal(t, "Server", report.Packages[0].Components[1].Name)
+
+	t.Run("The analysis is rendered like GenerateReport", func(t *testing.T) {
+		var rendered, generated bytes.Buffer
+		assert.NoError(t, rg.Render(report, &rendered))
+		assert.NoError(t, rg.GenerateReport(&generated))
+
+		assert.Equal(t, generated.String(), rendered.String())
+	})
+
+	t.Run("Packages left out of the report are not rendered", func(t *testing.T) {
+		filtered := *report
+		filtered.Packages = report.Packages[:1]
+
+		var buffer bytes.Buffer
+		err := rg.Render(&filtered, &buffer)
+
+		assert.NoError(t, err)
+		assert.Contains(t, buffer.String(), " - dir: /repo/server\n")
+		assert.NotContains(t, buffer.String(), " - dir: /repo/store\n")
+	})
+
+	t.Run("The diagrams render the relationships of the report", func(t *testing.T) {
+		diagrams := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{})
+		diagrams.SetOptions(Options{Mermaid: true})
+
+		var buffer bytes.Buffer
+		assert.NoError(t, diagrams.Render(report, &buffer))
+		assert.Contains(t, buffer.String(), "    server_Server *-- store_Store : Store\n")
+
+		filtered := *report
+		filtered.Relationships = nil
+		buffer.Reset()
+		assert.NoError(t, diagrams.Render(&filtered, &buffer))
+		assert.NotContains(t, buffer.String(), "server_Server *-- store_Store")
+	})
+
+	t.Run("A report is rendered the same after a JSON round trip", func(t *testing.T) {
+		data, err := json.Marshal(report)
+		assert.NoError(t, err)
+
+		var decoded Report
+		assert.NoError(t, json.Unmarshal(data, &decoded))
+
+		other := NewReportGenerator("other", t.TempDir(), &fakeFinderFactory{})
+		other.SetOptions(Options{Markers: true, Stats: true})
+
+		var original, roundTrip bytes.Buffer
+		assert.NoError(t, rg.Render(report, &original))
+		assert.NoError(t, other.Render(&decoded, &roundTrip))
+
+		assert.Contains(t, roundTrip.String(), "# repo\n")
+		assert.Contains(t, roundTrip.String(), "## Markers\n")
+		assert.Equal(t, original.String(), roundTrip.String())
+	})
+}
+
+// fakeClosingFinder is a ComponentFinder with warnings that must be closed.
+type fakeClosingFinder struct {
+	fakeFinder
+	warnings []Warning
+	closed   int
+}
+
+func (f *fakeClosingFinder) GetWarnings() []Warning {
+	warnings := f.warnings
+	f.warnings = nil
+	return warnings
+}
+
+func (f *fakeClosingFinder) Close() error {
+	f.closed++
+	return nil
+}
+
+func TestAnalyzeReleasesFinders(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "a.rules"), []byte("rule Allow\n"), 0644)
+
+	finder := &fakeClosingFinder{warnings: []Warning{{Path: filepath.Join(tmpDir, "a.rules"), Op: "running plugin rules", Err: assert.AnError}}}
+	rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+
+	report, err := rg.Analyze(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finder.closed)
+	assert.Len(t, report.Warnings, 1)
+	assert.Equal(t, "running plugin rules", report.Warnings[0].Op)
+}
@@ -0,0 +1,185 @@
+package reportgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// scannedFile is what a worker found in a file.
+type scannedFile struct {
+	path    string
+	stats   FileStats
+	warning *Warning          // warning is set if the file couldn't be read
+	results []json.RawMessage // results are the findings of every finder, in the order of the finders
+	err     error
+}
+
+// scanJob is a file to scan and the index of its slot in the scanned files.
+type scanJob struct {
+	index int
+	path  string
+}
+
+// Progress tells how far the scanning of the files has got.
+type Progress struct {
+	Path    string // Path is the path to the file just scanned
+	Found   int    // Found is the number of files found by the traversal so far
+	Scanned int    // Scanned is the number of files scanned so far, the cached and unreadable ones included
+}
+
+// progressTracker counts the found and scanned files and reports the progress after every scanned file.
+// Workers scanning in parallel share it, so the progress is never reported concurrently.
+type progressTracker struct {
+	mu      sync.Mutex
+	report  func(Progress) // report is nil if nobody listens
+	found   int
+	scanned int
+}
+
+func (pt *progressTracker) fileFound() {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.found++
+}
+
+func (pt *progressTracker) fileScanned(filePath string) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.scanned++
+	if pt.report != nil {
+		pt.report(Progress{Path: filePath, Found: pt.found, Scanned: pt.scanned})
+	}
+}
+
+// workerCount returns the number of workers scanning the files in parallel.
+func (opts Options) workerCount() int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+
+	return runtime.NumCPU()
+}
+
+// finderCreator returns the FinderCreator of the finder factory, ok is false if the files can't be scanned
+// in parallel because the factory can't create new finders or the findings of a finder can't be merged.
+func (rg *ReportGenerator) finderCreator() (FinderCreator, bool) {
+	creator, ok := rg.finderFactory.(FinderCreator)
+	if !ok {
+		return nil, false
+	}
+
+	for _, finder := range rg.finderFactory.GetFinders() {
+		if _, ok := finder.(FileResultFinder); !ok {
+			return nil, false
+		}
+	}
+
+	return creator, true
+}
+
+// scanFilesInParallel scans the files sent by the traverser with a pool of workers, each with its own set
+// of finders, so the scanning starts while the walk is still in progress. The findings of every file are then
+// merged into the finders of the factory in the order of the files, so the report doesn't depend on which
+// worker scanned which file.
+func (rg *ReportGenerator) scanFilesInParallel(files <-chan File, creator FinderCreator, cache *fileCache, progress *progressTracker) error {
+	mu := sync.Mutex{}
+	scanned := []scannedFile{}
+	jobs := make(chan scanJob)
+
+	wg := sync.WaitGroup{}
+	workerFinders := make([][]ComponentFinder, rg.options.workerCount())
+	for i := range workerFinders {
+		finders := creator.NewFinders()
+		setRoot(finders, rg.rootPath)
+		workerFinders[i] = finders
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				file := scanFile(job.path, finders, cache)
+
+				mu.Lock()
+				scanned[job.index] = file
+				mu.Unlock()
+				progress.fileScanned(job.path)
+			}
+		}()
+	}
+
+	for file := range files {
+		if file.Type == TypeDir {
+			continue
+		}
+
+		progress.fileFound()
+
+		// The slot of the file is added before a worker can fill it
+		mu.Lock()
+		job := scanJob{index: len(scanned), path: file.Path}
+		scanned = append(scanned, scannedFile{})
+		mu.Unlock()
+
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, finders := range workerFinders {
+		rg.releaseFinders(finders)
+	}
+
+	finders := rg.finderFactory.GetFinders()
+	for _, file := range scanned {
+		if file.err != nil {
+			return file.err
+		}
+		if file.warning != nil {
+			rg.warnings = append(rg.warnings, *file.warning)
+			continue
+		}
+
+		for j, finder := range finders {
+			if err := finder.(FileResultFinder).RestoreFileResult(file.path, file.results[j]); err != nil {
+				return fmt.Errorf("merging the findings in file %s: %s", file.path, err)
+			}
+		}
+		rg.fileStats = append(rg.fileStats, file.stats)
+	}
+
+	return nil
+}
+
+// scanFile scans a file with the finders of a worker and returns their findings,
+// or returns the cached findings if the file hasn't changed.
+func scanFile(filePath string, finders []ComponentFinder, cache *fileCache) scannedFile {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return scannedFile{path: filePath, warning: &Warning{Path: filePath, Op: "reading file", Err: err}}
+	}
+
+	if cache != nil {
+		if entry, ok := cache.load(filePath, content); ok {
+			return scannedFile{path: filePath, stats: entry.stats(filePath), results: entry.Results}
+		}
+	}
+
+	stats := scanLines(filePath, content, finders)
+
+	results, complete, err := fileResults(finders)
+	if err != nil {
+		return scannedFile{path: filePath, err: err}
+	}
+
+	if cache != nil && complete {
+		if err := cache.store(filePath, content, stats, results); err != nil {
+			return scannedFile{path: filePath, err: fmt.Errorf("caching file %s: %s", filePath, err)}
+		}
+	}
+
+	return scannedFile{path: filePath, stats: stats, results: results}
+}
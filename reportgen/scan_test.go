@@ -0,0 +1,130 @@
+package reportgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCreatorFactory is a FinderFactory that creates new sets of fakeResultFinders for the workers.
+type fakeCreatorFactory struct {
+	finder  *fakeResultFinder
+	created int
+}
+
+func (ff *fakeCreatorFactory) GetFinders() []ComponentFinder { return []ComponentFinder{ff.finder} }
+
+func (ff *fakeCreatorFactory) NewFinders() []ComponentFinder {
+	ff.created++
+	return []ComponentFinder{newFakeResultFinder()}
+}
+
+func TestGenerateReportInParallel(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		dir := filepath.Join(tmpDir, fmt.Sprintf("pkg%d", i%3))
+		os.MkdirAll(dir, 0755)
+		content := fmt.Sprintf("package server\nfunc F%d()\n", i)
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.go", i)), []byte(content), 0644)
+	}
+
+	testCases := []struct {
+		name            string
+		workers         int
+		cache           bool
+		expectedCreated int
+	}{
+		{name: "One worker scans the files one by one", workers: 1, expectedCreated: 0},
+		{name: "Several workers", workers: 4, expectedCreated: 4},
+		{name: "Several workers with the cache", workers: 3, cache: true, expectedCreated: 3},
+	}
+
+	var expected string
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			factory := &fakeCreatorFactory{finder: newFakeResultFinder()}
+			rg := NewReportGenerator("server", tmpDir, factory)
+			rg.SetOptions(Options{Workers: tc.workers, Cache: tc.cache, Stats: true})
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCreated, factory.created)
+			assert.Len(t, factory.finder.components, 20)
+			assert.Contains(t, buffer.String(), "| **Total** |  | 20 | 40 |")
+			if expected == "" {
+				expected = buffer.String()
+			}
+			assert.Equal(t, expected, buffer.String())
+		})
+	}
+}
+
+func TestGenerateReportWithUnreadableFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "f1.go"), []byte("package server\nfunc F1()\n"), 0644)
+	// A dangling symlink is traversed but can't be read
+	os.Symlink(filepath.Join(tmpDir, "missing.go"), filepath.Join(tmpDir, "f2.go"))
+
+	for _, workers := range []int{1, 2} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			factory := &fakeCreatorFactory{finder: newFakeResultFinder()}
+			rg := NewReportGenerator("server", tmpDir, factory)
+			rg.SetOptions(Options{Workers: workers})
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			assert.Len(t, factory.finder.components, 1)
+			assert.Len(t, rg.Warnings(), 1)
+			assert.Equal(t, filepath.Join(tmpDir, "f2.go"), rg.Warnings()[0].Path)
+			assert.Equal(t, "reading file", rg.Warnings()[0].Op)
+		})
+	}
+}
+
+func TestGenerateReportContextCanceled(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "f1.go"), []byte("package server\nfunc F1()\n"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rg := NewReportGenerator("server", tmpDir, &fakeCreatorFactory{finder: newFakeResultFinder()})
+	var buffer bytes.Buffer
+	err := rg.GenerateReportContext(ctx, &buffer)
+
+	assert.ErrorContains(t, err, context.Canceled.Error())
+	assert.Empty(t, buffer.String())
+}
+
+func TestGenerateReportWithLongLine(t *testing.T) {
+	tmpDir := t.TempDir()
+	// A minified file has lines longer than the limit of bufio.Scanner
+	content := "func F1()\n" + strings.Repeat("a", 70000) + "\r\nfunc F2()\n"
+	os.WriteFile(filepath.Join(tmpDir, "app.min.js"), []byte(content), 0644)
+
+	for _, workers := range []int{1, 2} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			factory := &fakeCreatorFactory{finder: newFakeResultFinder()}
+			rg := NewReportGenerator("server", tmpDir, factory)
+			rg.SetOptions(Options{Workers: workers, Stats: true})
+
+			var buffer bytes.Buffer
+			err := rg.GenerateReport(&buffer)
+
+			assert.NoError(t, err)
+			assert.Len(t, factory.finder.components, 2)
+			assert.Contains(t, buffer.String(), "/server/app.min.js: 3 lines\n")
+			assert.Empty(t, rg.Warnings())
+		})
+	}
+}
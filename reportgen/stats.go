@@ -0,0 +1,256 @@
+package reportgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// largestCount is the number of the largest files and functions listed in the statistics.
+const largestCount = 5
+
+// FileStats counts the lines of a file.
+type FileStats struct {
+	Path     string `json:"path"` // Path is the full path to the file
+	Lines    int    `json:"lines"`
+	Code     int    `json:"code"`
+	Comments int    `json:"comments"`
+	Blank    int    `json:"blank"`
+}
+
+// lineCounter counts the code, comment and blank lines of a file, one line at a time.
+type lineCounter struct {
+	stats          FileStats
+	syntax         CommentSyntax
+	inBlockComment bool
+}
+
+func newLineCounter(filePath string) *lineCounter {
+	return &lineCounter{
+		stats:  FileStats{Path: filePath},
+		syntax: CommentSyntaxOf(filePath),
+	}
+}
+
+// count counts a line. Lines with both code and a comment are counted as code.
+func (lc *lineCounter) count(line string) {
+	lc.stats.Lines++
+	trimmed := strings.TrimSpace(line)
+
+	switch {
+	case lc.inBlockComment:
+		lc.stats.Comments++
+		if strings.Contains(trimmed, lc.syntax.BlockEnd) {
+			lc.inBlockComment = false
+		}
+	case trimmed == "":
+		lc.stats.Blank++
+	case lc.syntax.Line != "" && strings.HasPrefix(trimmed, lc.syntax.Line):
+		lc.stats.Comments++
+	case lc.syntax.BlockStart != "" && strings.HasPrefix(trimmed, lc.syntax.BlockStart):
+		lc.stats.Comments++
+		lc.inBlockComment = !strings.Contains(trimmed[len(lc.syntax.BlockStart):], lc.syntax.BlockEnd)
+	default:
+		lc.stats.Code++
+	}
+}
+
+// packageStats sums up the statistics of a directory.
+type packageStats struct {
+	dirPath    string
+	pkg        string
+	files      int
+	code       int
+	comments   int
+	blank      int
+	structs    int
+	interfaces int
+	funcs      int
+	methods    int
+	testFiles  int
+	testFuncs  int
+}
+
+func (ps *packageStats) add(other packageStats) {
+	ps.files += other.files
+	ps.code += other.code
+	ps.comments += other.comments
+	ps.blank += other.blank
+	ps.structs += other.structs
+	ps.interfaces += other.interfaces
+	ps.funcs += other.funcs
+	ps.methods += other.methods
+	ps.testFiles += other.testFiles
+	ps.testFuncs += other.testFuncs
+}
+
+// sizedFunc is a func or a method with its number of lines.
+type sizedFunc struct {
+	name  string
+	file  string
+	line  int
+	lines int
+}
+
+// isSourceFile reports whether a file is source code, i.e. of a language whose comment syntax is known.
+// The other files, like README.md and go.sum, aren't counted in the statistics.
+func isSourceFile(filePath string) bool {
+	return CommentSyntaxOf(filePath) != CommentSyntax{}
+}
+
+// collectPackageStats sums up the line counts of the scanned source files and the components by directory,
+// sorted by the directory path.
+func (rg *ReportGenerator) collectPackageStats(outputCompMap OutputComponentMap) []packageStats {
+	byDir := map[string]*packageStats{}
+	getDir := func(dirPath string) *packageStats {
+		if byDir[dirPath] == nil {
+			byDir[dirPath] = &packageStats{dirPath: dirPath}
+		}
+		return byDir[dirPath]
+	}
+
+	for _, file := range rg.fileStats {
+		if !isSourceFile(file.Path) {
+			continue
+		}
+
+		ps := getDir(rg.outputDirPath(filepath.Dir(file.Path)))
+		ps.files++
+		ps.code += file.Code
+		ps.comments += file.Comments
+		ps.blank += file.Blank
+		if isTestFile(file.Path) {
+			ps.testFiles++
+		}
+	}
+
+	for dirPath, comps := range outputCompMap {
+		ps := getDir(dirPath)
+		for _, comp := range comps {
+			ps.pkg = comp.Package
+			switch comp.Type {
+			case ComponentTypeStruct:
+				ps.structs++
+				ps.methods += len(comp.Methods)
+			case ComponentTypeInterface:
+				ps.interfaces++
+			case ComponentTypeFunc:
+				ps.funcs++
+				if isTestFile(comp.File) && strings.HasPrefix(comp.Name, "Test") {
+					ps.testFuncs++
+				}
+			}
+		}
+	}
+
+	stats := make([]packageStats, 0, len(byDir))
+	for _, ps := range byDir {
+		stats = append(stats, *ps)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].dirPath < stats[j].dirPath
+	})
+
+	return stats
+}
+
+// largestFiles returns the source files with the most lines, the largest first.
+func (rg *ReportGenerator) largestFiles() []FileStats {
+	files := []FileStats{}
+	for _, file := range rg.fileStats {
+		if isSourceFile(file.Path) {
+			files = append(files, file)
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Lines > files[j].Lines
+	})
+
+	if len(files) > largestCount {
+		files = files[:largestCount]
+	}
+
+	return files
+}
+
+// largestFuncs returns the funcs and methods with the most lines, the largest first.
+// Only the ones whose location is known are taken into account.
+func largestFuncs(outputCompMap OutputComponentMap) []sizedFunc {
+	funcs := []sizedFunc{}
+	for _, comps := range outputCompMap {
+		for _, comp := range comps {
+			if comp.Type == ComponentTypeFunc && comp.Line > 0 {
+				funcs = append(funcs, sizedFunc{
+					name:  comp.Package + "." + componentName(comp),
+					file:  comp.File,
+					line:  comp.Line,
+					lines: comp.EndLine - comp.Line + 1,
+				})
+			}
+
+			for method, location := range comp.MethodLocations {
+				funcs = append(funcs, sizedFunc{
+					name:  comp.Package + "." + comp.Name + "." + method,
+					file:  location.File,
+					line:  location.Line,
+					lines: location.EndLine - location.Line + 1,
+				})
+			}
+		}
+	}
+
+	sort.Slice(funcs, func(i, j int) bool {
+		if funcs[i].lines != funcs[j].lines {
+			return funcs[i].lines > funcs[j].lines
+		}
+		return funcs[i].name < funcs[j].name
+	})
+
+	if len(funcs) > largestCount {
+		funcs = funcs[:largestCount]
+	}
+
+	return funcs
+}
+
+// renderStats renders the statistics section: a table of the packages with a total row,
+// and the largest files and funcs.
+func (rg *ReportGenerator) renderStats(outputCompMap OutputComponentMap) string {
+	var builder strings.Builder
+	builder.WriteString("\n\n## Statistics\n\n")
+	builder.WriteString("| Package | Dir | Files | Code lines | Comment lines | Blank lines | Structs | Interfaces | Funcs | Methods | Test files | Test funcs |\n")
+	builder.WriteString("|---|---|---|---|---|---|---|---|---|---|---|---|\n")
+
+	total := packageStats{pkg: "**Total**"}
+	for _, ps := range rg.collectPackageStats(outputCompMap) {
+		builder.WriteString(ps.tableRow())
+		total.add(ps)
+	}
+	builder.WriteString(total.tableRow())
+
+	builder.WriteString("\nLargest files:\n")
+	for _, file := range rg.largestFiles() {
+		builder.WriteString(fmt.Sprintf(" - %s: %s\n", rg.displayPath(file.Path), pluralize(file.Lines, "line")))
+	}
+
+	if funcs := largestFuncs(outputCompMap); len(funcs) > 0 {
+		builder.WriteString("\nLargest funcs and methods:\n")
+		for _, fn := range funcs {
+			builder.WriteString(fmt.Sprintf(" - %s: %s (%s:%d)\n", fn.name, pluralize(fn.lines, "line"), rg.displayPath(fn.file), fn.line))
+		}
+	}
+
+	return builder.String()
+}
+
+func (ps packageStats) tableRow() string {
+	pkg := ps.pkg
+	if pkg == "" {
+		pkg = "-"
+	}
+
+	return fmt.Sprintf("| %s | %s | %d | %d | %d | %d | %d | %d | %d | %d | %d | %d |\n",
+		pkg, ps.dirPath, ps.files, ps.code, ps.comments, ps.blank,
+		ps.structs, ps.interfaces, ps.funcs, ps.methods, ps.testFiles, ps.testFuncs)
+}
@@ -0,0 +1,100 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineCounter(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filePath string
+		lines    []string
+		expected FileStats
+	}{
+		{
+			name:     "Go file with line and block comments",
+			filePath: "a.go",
+			lines: []string{
+				"// Package a does things.",
+				"package a",
+				"",
+				"/*",
+				"block",
+				"*/",
+				"var x = 1 // trailing comments are code",
+				"/* single line block */",
+			},
+			expected: FileStats{Path: "a.go", Lines: 8, Code: 2, Comments: 5, Blank: 1},
+		},
+		{
+			name:     "Python file",
+			filePath: "a.py",
+			lines:    []string{"# comment", "x = 1", "   ", "// not a comment"},
+			expected: FileStats{Path: "a.py", Lines: 4, Code: 2, Comments: 1, Blank: 1},
+		},
+		{
+			name:     "Unknown file type has no comments",
+			filePath: "notes.txt",
+			lines:    []string{"# title", "", "text"},
+			expected: FileStats{Path: "notes.txt", Lines: 3, Code: 2, Blank: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			counter := newLineCounter(tc.filePath)
+			for _, line := range tc.lines {
+				counter.count(line)
+			}
+
+			assert.Equal(t, tc.expected, counter.stats)
+		})
+	}
+}
+
+func TestGenerateReportWithStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "server.go"), []byte("package server\n\n// Server serves.\ntype Server struct{}\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "server_test.go"), []byte("package server\n"), 0644)
+	// Files without code aren't counted
+	os.WriteFile(filepath.Join(tmpDir, "README.md"), []byte(strings.Repeat("Read me.\n", 10)), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "go.sum"), []byte(strings.Repeat("example.com/mod v1.0.0 h1:abc=\n", 10)), 0644)
+
+	finder := &fakeFinder{components: ComponentMap{
+		tmpDir + ":Server": Component{
+			File:            filepath.Join(tmpDir, "server.go"),
+			Package:         "server",
+			Name:            "Server",
+			Type:            "struct",
+			Methods:         []string{"Start() error"},
+			MethodLocations: map[string]Location{"Start": {File: filepath.Join(tmpDir, "server.go"), Line: 6, EndLine: 20}},
+		},
+		tmpDir + ":TestServer": Component{
+			File:    filepath.Join(tmpDir, "server_test.go"),
+			Package: "server",
+			Name:    "TestServer(t *testing.T)",
+			Type:    "func",
+			Line:    3,
+			EndLine: 5,
+		},
+	}}
+
+	rg := NewReportGenerator("server", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{Stats: true})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "## Statistics")
+	assert.Contains(t, buffer.String(), "| server | /server | 2 | 3 | 1 | 1 | 1 | 0 | 1 | 1 | 1 | 1 |\n")
+	assert.Contains(t, buffer.String(), "| **Total** |  | 2 | 3 | 1 | 1 | 1 | 0 | 1 | 1 | 1 | 1 |\n")
+	assert.Contains(t, buffer.String(), "Largest files:\n - /server/server.go: 4 lines\n - /server/server_test.go: 1 line\n")
+	assert.Contains(t, buffer.String(), "Largest funcs and methods:\n - server.Server.Start: 15 lines (/server/server.go:6)\n - server.TestServer: 3 lines (/server/server_test.go:3)\n")
+}
@@ -0,0 +1,160 @@
+package reportgen
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Symbol types besides the component types.
+const SymbolTypeMethod = "method"
+
+// Symbol is a component or a method of a report.
+type Symbol struct {
+	Dir  string // Dir is the directory path relative to the root directory, "." for the root directory
+	Name string // Name is the name qualified by the package name, e.g. "reportgen.ReportGenerator.Render"
+	Type string // Type is the type of the component, or SymbolTypeMethod
+
+	// Signature is what a change of the symbol is detected by: the parameters and results of a func or method,
+	// or the fields of a struct or interface.
+	Signature string
+
+	File string // File is the file path relative to the root directory
+	Line int    // Line is the line of the definition, 0 if unknown
+}
+
+// Key identifies the symbol in the repo.
+func (s Symbol) Key() string {
+	return s.Dir + ":" + s.Name
+}
+
+// Symbols returns the components of the packages and their methods, sorted by file and line.
+func (r *Report) Symbols() []Symbol {
+	symbols := []Symbol{}
+	for _, pkg := range r.Packages {
+		dir := r.relativeDir(pkg.Dir)
+		for _, comp := range pkg.Components {
+			name := componentName(comp)
+			symbol := Symbol{
+				Dir:  dir,
+				Name: comp.Package + "." + name,
+				Type: comp.Type,
+				File: r.relativePath(comp.File),
+				Line: comp.Line,
+			}
+			if comp.Type == ComponentTypeFunc {
+				symbol.Signature = strings.TrimPrefix(comp.Name, name)
+			} else {
+				symbol.Signature = strings.Join(comp.Fields, "; ")
+			}
+			symbols = append(symbols, symbol)
+
+			for _, method := range comp.Methods {
+				methodName := memberName(method)
+				symbol := Symbol{
+					Dir:       dir,
+					Name:      comp.Package + "." + name + "." + methodName,
+					Type:      SymbolTypeMethod,
+					Signature: strings.TrimPrefix(method, methodName),
+					File:      r.relativePath(comp.File),
+				}
+				if location, ok := comp.MethodLocations[methodName]; ok {
+					symbol.File = r.relativePath(location.File)
+					symbol.Line = location.Line
+				}
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].File != symbols[j].File {
+			return symbols[i].File < symbols[j].File
+		}
+		if symbols[i].Line != symbols[j].Line {
+			return symbols[i].Line < symbols[j].Line
+		}
+		return symbols[i].Name < symbols[j].Name
+	})
+
+	return symbols
+}
+
+// relativeDir turns a directory path starting from the root directory, like "/repo/reportgen",
+// into a path relative to the root directory.
+func (r *Report) relativeDir(dirPath string) string {
+	dir := strings.TrimPrefix(strings.TrimPrefix(dirPath, "/"+r.Name), "/")
+	if dir == "" {
+		return "."
+	}
+
+	return dir
+}
+
+// relativePath turns a full file path into a path relative to the root directory.
+func (r *Report) relativePath(filePath string) string {
+	relPath, err := filepath.Rel(r.RootPath, filePath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return filePath
+	}
+
+	return relPath
+}
+
+// Kinds of symbol changes.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// SymbolChange is a symbol added, removed or changed between two reports.
+type SymbolChange struct {
+	Kind string // Kind is ChangeAdded, ChangeRemoved or ChangeChanged
+	Old  Symbol // Old is the symbol before the change, empty if it's been added
+	New  Symbol // New is the symbol after the change, empty if it's been removed
+}
+
+// DiffSymbols compares the symbols of two reports, sorted by directory and name.
+// A symbol has changed if its type or signature has changed, moving it within its directory isn't a change.
+func DiffSymbols(oldSymbols, newSymbols []Symbol) []SymbolChange {
+	oldByKey := map[string]Symbol{}
+	for _, symbol := range oldSymbols {
+		oldByKey[symbol.Key()] = symbol
+	}
+
+	changes := []SymbolChange{}
+	newKeys := map[string]bool{}
+	for _, symbol := range newSymbols {
+		newKeys[symbol.Key()] = true
+
+		old, ok := oldByKey[symbol.Key()]
+		switch {
+		case !ok:
+			changes = append(changes, SymbolChange{Kind: ChangeAdded, New: symbol})
+		case old.Type != symbol.Type || old.Signature != symbol.Signature:
+			changes = append(changes, SymbolChange{Kind: ChangeChanged, Old: old, New: symbol})
+		}
+	}
+
+	for _, symbol := range oldSymbols {
+		if !newKeys[symbol.Key()] {
+			changes = append(changes, SymbolChange{Kind: ChangeRemoved, Old: symbol})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].symbol().Key() < changes[j].symbol().Key()
+	})
+
+	return changes
+}
+
+// symbol returns the symbol the change is about.
+func (c SymbolChange) symbol() Symbol {
+	if c.Kind == ChangeRemoved {
+		return c.Old
+	}
+
+	return c.New
+}
@@ -0,0 +1,102 @@
+package reportgen
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportSymbols(t *testing.T) {
+	report := &Report{
+		Name:     "repo",
+		RootPath: "/home/me/repo",
+		Packages: []Package{
+			{Dir: "/repo", Name: "main", Components: []Component{
+				{File: "/home/me/repo/main.go", Package: "main", Name: "main()", Type: ComponentTypeFunc, Line: 5},
+			}},
+			{Dir: "/repo/server", Name: "server", Components: []Component{
+				{
+					File:            "/home/me/repo/server/server.go",
+					Package:         "server",
+					Name:            "Server",
+					Type:            ComponentTypeStruct,
+					Fields:          []string{"Addr string"},
+					Methods:         []string{"Start() error", "stop()"},
+					Line:            3,
+					MethodLocations: map[string]Location{"Start": {File: "/home/me/repo/server/start.go", Line: 7, EndLine: 9}},
+				},
+			}},
+		},
+	}
+
+	symbols := report.Symbols()
+
+	assert.Equal(t, []Symbol{
+		{Dir: ".", Name: "main.main", Type: ComponentTypeFunc, Signature: "()", File: "main.go", Line: 5},
+		{Dir: "server", Name: "server.Server.stop", Type: SymbolTypeMethod, Signature: "()", File: "server/server.go"},
+		{Dir: "server", Name: "server.Server", Type: ComponentTypeStruct, Signature: "Addr string", File: "server/server.go", Line: 3},
+		{Dir: "server", Name: "server.Server.Start", Type: SymbolTypeMethod, Signature: "() error", File: "server/start.go", Line: 7},
+	}, symbols)
+}
+
+func TestDiffSymbols(t *testing.T) {
+	oldSymbols := []Symbol{
+		{Dir: "server", Name: "server.Server", Type: ComponentTypeStruct, Signature: "Addr string", File: "server/server.go", Line: 3},
+		{Dir: "server", Name: "server.Server.Start", Type: SymbolTypeMethod, Signature: "() error", File: "server/server.go", Line: 7},
+		{Dir: "server", Name: "server.helper", Type: ComponentTypeFunc, Signature: "()", File: "server/server.go", Line: 12},
+		{Dir: "client", Name: "server.Server", Type: ComponentTypeStruct, File: "client/server.go", Line: 3},
+	}
+	newSymbols := []Symbol{
+		{Dir: "server", Name: "server.Server", Type: ComponentTypeStruct, Signature: "Addr string; Port int", File: "server/server.go", Line: 3},
+		{Dir: "server", Name: "server.Server.Start", Type: SymbolTypeMethod, Signature: "() error", File: "server/start.go", Line: 5},
+		{Dir: "server", Name: "server.Server.Stop", Type: SymbolTypeMethod, Signature: "() error", File: "server/server.go", Line: 9},
+		{Dir: "client", Name: "server.Server", Type: ComponentTypeStruct, File: "client/server.go", Line: 3},
+	}
+
+	changes := DiffSymbols(oldSymbols, newSymbols)
+
+	assert.Equal(t, []SymbolChange{
+		{Kind: ChangeChanged, Old: oldSymbols[0], New: newSymbols[0]},
+		{Kind: ChangeAdded, New: newSymbols[2]},
+		{Kind: ChangeRemoved, Old: oldSymbols[2]},
+	}, changes)
+	assert.Empty(t, DiffSymbols(oldSymbols, oldSymbols))
+}
+
+func TestRenderTreeAndStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "server", "internal"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "server", "server.go"), []byte("package server\n\n// Server serves\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "server", "internal", "pool.go"), []byte("package internal\n"), 0644)
+
+	rg := NewReportGenerator("repo", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{&fakeFinder{components: ComponentMap{}}}})
+	report, err := rg.Analyze(context.Background())
+	assert.NoError(t, err)
+
+	t.Run("Tree", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.NoError(t, rg.RenderTree(report, 0, &buffer))
+		assert.Contains(t, buffer.String(), "\t\t- server.go\n")
+		assert.Contains(t, buffer.String(), "\t\t\t- pool.go\n")
+	})
+
+	t.Run("Tree with a maximum depth", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.NoError(t, rg.RenderTree(report, 1, &buffer))
+		assert.Contains(t, buffer.String(), "\t\t/internal (1 file)\n")
+		assert.NotContains(t, buffer.String(), "pool.go")
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		var buffer bytes.Buffer
+		assert.NoError(t, rg.RenderStats(report, &buffer))
+		assert.Contains(t, buffer.String(), "## Statistics\n")
+		assert.NotContains(t, buffer.String(), "## Directory structure")
+		assert.Contains(t, buffer.String(), "Largest files:\n")
+	})
+}
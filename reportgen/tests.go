@@ -0,0 +1,214 @@
+package reportgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of test funcs, named after the prefixes the go test command looks for.
+const (
+	TestKindTest      = "test"
+	TestKindBenchmark = "benchmark"
+	TestKindFuzz      = "fuzz"
+	TestKindExample   = "example"
+)
+
+// testPrefixes maps the name prefixes of the test funcs to their kinds.
+var testPrefixes = []struct {
+	prefix string
+	kind   string
+}{
+	{"Test", TestKindTest},
+	{"Benchmark", TestKindBenchmark},
+	{"Fuzz", TestKindFuzz},
+	{"Example", TestKindExample},
+}
+
+// testFunc is a test, benchmark, fuzz target or example with the component it likely tests.
+type testFunc struct {
+	comp      Component
+	name      string
+	kind      string
+	target    string // target is the tested component like "Type" or "Type.Method", empty if unknown
+	targetKey string // targetKey is the key of the tested component in the format "dirPath:Name"
+}
+
+// classifyTest returns the kind of a test func and its name without the prefix,
+// following the rules of the go test command: the prefix must not be followed by a lowercase letter.
+// ok is false for the funcs that aren't tests, e.g. helpers in test files.
+func classifyTest(comp Component) (kind, subject string, ok bool) {
+	if comp.Type != ComponentTypeFunc || !isTestFile(comp.File) {
+		return "", "", false
+	}
+
+	name := componentName(comp)
+	for _, p := range testPrefixes {
+		if !strings.HasPrefix(name, p.prefix) {
+			continue
+		}
+
+		rest := name[len(p.prefix):]
+		if r, _ := utf8.DecodeRuneInString(rest); unicode.IsLower(r) {
+			continue
+		}
+
+		return p.kind, strings.TrimPrefix(rest, "_"), true
+	}
+
+	return "", "", false
+}
+
+// findTests finds the test funcs of every directory and the components they likely test.
+// A test is mapped to the component of the same directory whose name is the longest prefix
+// of the test name, e.g. TestStructFinderFindComponent tests StructFinder.FindComponent,
+// or to the method whose name is the longest prefix, e.g. TestGenerateReport tests ReportGenerator.GenerateReport.
+func findTests(outputCompMap OutputComponentMap) map[string][]testFunc {
+	tests := map[string][]testFunc{}
+	for dirPath, comps := range outputCompMap {
+		for _, comp := range comps {
+			kind, subject, ok := classifyTest(comp)
+			if !ok {
+				continue
+			}
+
+			test := testFunc{comp: comp, name: componentName(comp), kind: kind}
+			test.target, test.targetKey = findTestTarget(dirPath, comps, subject)
+			tests[dirPath] = append(tests[dirPath], test)
+		}
+
+		sort.Slice(tests[dirPath], func(i, j int) bool {
+			return tests[dirPath][i].name < tests[dirPath][j].name
+		})
+	}
+
+	return tests
+}
+
+// findTestTarget finds the component tested by a test from the test name without the prefix.
+func findTestTarget(dirPath string, comps []Component, subject string) (string, string) {
+	if subject == "" {
+		return "", ""
+	}
+
+	// The longest component name wins, e.g. StructFinder over Struct
+	var target *Component
+	for i, comp := range comps {
+		if isTestFile(comp.File) {
+			continue
+		}
+
+		name := upperFirst(componentName(comp))
+		if strings.HasPrefix(subject, name) && (target == nil || len(name) > len(componentName(*target))) {
+			target = &comps[i]
+		}
+	}
+
+	if target != nil {
+		rest := strings.TrimPrefix(subject[len(componentName(*target)):], "_")
+		if method := longestPrefixMethod(target.Methods, rest); method != "" {
+			return target.Name + "." + method, dirPath + ":" + target.Name
+		}
+
+		return componentName(*target), dirPath + ":" + target.Name
+	}
+
+	// The test might be named after a method only
+	bestMethod := ""
+	for i, comp := range comps {
+		if isTestFile(comp.File) || comp.Type != ComponentTypeStruct {
+			continue
+		}
+
+		if method := longestPrefixMethod(comp.Methods, subject); len(method) > len(bestMethod) {
+			bestMethod = method
+			target = &comps[i]
+		}
+	}
+
+	if target != nil {
+		return target.Name + "." + bestMethod, dirPath + ":" + target.Name
+	}
+
+	return "", ""
+}
+
+// longestPrefixMethod returns the name of the method that is the longest prefix of the text,
+// ignoring the case of the first letter.
+func longestPrefixMethod(methods []string, text string) string {
+	best := ""
+	for _, method := range methods {
+		name := memberName(method)
+		if name != "" && strings.HasPrefix(text, upperFirst(name)) && len(name) > len(best) {
+			best = name
+		}
+	}
+
+	return best
+}
+
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// testedBy maps the keys of the components to the names of the tests that likely test them.
+func testedBy(tests map[string][]testFunc) map[string][]string {
+	testNames := map[string][]string{}
+	for _, dirTests := range tests {
+		for _, test := range dirTests {
+			if test.targetKey != "" {
+				testNames[test.targetKey] = append(testNames[test.targetKey], test.name)
+			}
+		}
+	}
+
+	for _, names := range testNames {
+		sort.Strings(names)
+	}
+
+	return testNames
+}
+
+// isTestFunc reports whether a component is a test, benchmark, fuzz target or example.
+func isTestFunc(comp Component) bool {
+	_, _, ok := classifyTest(comp)
+	return ok
+}
+
+const testsHeading = "\n\n## Tests\n"
+
+// renderTests renders the tests of every directory as a block of its own, sorted by the directory path.
+func (rg *ReportGenerator) renderTests(tests map[string][]testFunc) []string {
+	dirs := make([]string, 0, len(tests))
+	for dirPath := range tests {
+		dirs = append(dirs, dirPath)
+	}
+	sort.Strings(dirs)
+
+	blocks := make([]string, 0, len(dirs))
+	for _, dirPath := range dirs {
+		var builder strings.Builder
+		builder.WriteString(fmt.Sprintf(" - dir: %s\n", dirPath))
+		for _, test := range tests[dirPath] {
+			builder.WriteString(fmt.Sprintf("     - %s\n", test.name))
+			builder.WriteString(fmt.Sprintf("         - kind: %s\n", test.kind))
+			builder.WriteString(fmt.Sprintf("         - file: %s\n", rg.displayPath(test.comp.File)))
+			if test.target != "" {
+				builder.WriteString(fmt.Sprintf("         - tests: %s\n", test.target))
+			}
+			if len(test.comp.TestCases) > 0 {
+				builder.WriteString("         - cases:\n")
+				for _, name := range test.comp.TestCases {
+					builder.WriteString(fmt.Sprintf("             - %s\n", name))
+				}
+			}
+		}
+
+		blocks = append(blocks, builder.String())
+	}
+
+	return blocks
+}
@@ -0,0 +1,137 @@
+package reportgen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyTest(t *testing.T) {
+	testCases := []struct {
+		name            string
+		comp            Component
+		expectedKind    string
+		expectedSubject string
+		expectedOk      bool
+	}{
+		{
+			name:            "Test",
+			comp:            Component{File: "a_test.go", Name: "TestStructFinder(t *testing.T)", Type: "func"},
+			expectedKind:    TestKindTest,
+			expectedSubject: "StructFinder",
+			expectedOk:      true,
+		},
+		{
+			name:            "Benchmark with an underscore",
+			comp:            Component{File: "a_test.go", Name: "Benchmark_parse(b *testing.B)", Type: "func"},
+			expectedKind:    TestKindBenchmark,
+			expectedSubject: "parse",
+			expectedOk:      true,
+		},
+		{
+			name:            "Fuzz target",
+			comp:            Component{File: "a_test.go", Name: "FuzzParse(f *testing.F)", Type: "func"},
+			expectedKind:    TestKindFuzz,
+			expectedSubject: "Parse",
+			expectedOk:      true,
+		},
+		{
+			name:         "Package example",
+			comp:         Component{File: "a_test.go", Name: "Example()", Type: "func"},
+			expectedKind: TestKindExample,
+			expectedOk:   true,
+		},
+		{
+			name: "Lowercase letter after the prefix",
+			comp: Component{File: "a_test.go", Name: "Testify()", Type: "func"},
+		},
+		{
+			name: "Test name outside of a test file",
+			comp: Component{File: "a.go", Name: "TestServer()", Type: "func"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, subject, ok := classifyTest(tc.comp)
+
+			assert.Equal(t, tc.expectedKind, kind)
+			assert.Equal(t, tc.expectedSubject, subject)
+			assert.Equal(t, tc.expectedOk, ok)
+		})
+	}
+}
+
+func TestGenerateReportWithTests(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "finder.go"), []byte("package finder"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "finder_test.go"), []byte("package finder"), 0644)
+
+	testFile := filepath.Join(tmpDir, "finder_test.go")
+	finder := &fakeFinder{components: ComponentMap{
+		tmpDir + ":StructFinder": Component{
+			File:    filepath.Join(tmpDir, "finder.go"),
+			Package: "finder",
+			Name:    "StructFinder",
+			Type:    "struct",
+			Methods: []string{"FindComponent(line string)", "GetComponents() ComponentMap"},
+		},
+		tmpDir + ":parse": Component{
+			File:    filepath.Join(tmpDir, "finder.go"),
+			Package: "finder",
+			Name:    "parse(line string) string",
+			Type:    "func",
+		},
+		tmpDir + ":TestStructFinderFindComponent": Component{
+			File:      testFile,
+			Package:   "finder",
+			Name:      "TestStructFinderFindComponent(t *testing.T)",
+			Type:      "func",
+			TestCases: []string{"Simple struct", "Embedded struct"},
+		},
+		tmpDir + ":TestGetComponents": Component{
+			File:    testFile,
+			Package: "finder",
+			Name:    "TestGetComponents(t *testing.T)",
+			Type:    "func",
+		},
+		tmpDir + ":BenchmarkParse": Component{
+			File:    testFile,
+			Package: "finder",
+			Name:    "BenchmarkParse(b *testing.B)",
+			Type:    "func",
+		},
+		tmpDir + ":newFixture": Component{
+			File:    testFile,
+			Package: "finder",
+			Name:    "newFixture() *StructFinder",
+			Type:    "func",
+		},
+	}}
+
+	rg := NewReportGenerator("finder", tmpDir, &fakeFinderFactory{finders: []ComponentFinder{finder}})
+	rg.SetOptions(Options{Tests: true})
+
+	var buffer bytes.Buffer
+	err := rg.GenerateReport(&buffer)
+
+	assert.NoError(t, err)
+
+	components, tests, found := strings.Cut(buffer.String(), "## Tests")
+	assert.True(t, found)
+
+	assert.Contains(t, components, "         - tested by: TestGetComponents, TestStructFinderFindComponent\n")
+	assert.Contains(t, components, "     - parse(line string) string\n")
+	assert.Contains(t, components, "         - tested by: BenchmarkParse\n")
+	assert.Contains(t, components, "newFixture() *StructFinder")
+	assert.NotContains(t, components, "TestStructFinderFindComponent(t *testing.T)")
+
+	assert.Contains(t, tests, "     - BenchmarkParse\n         - kind: benchmark\n         - file: /finder/finder_test.go\n         - tests: parse\n")
+	assert.Contains(t, tests, "     - TestGetComponents\n         - kind: test\n         - file: /finder/finder_test.go\n         - tests: StructFinder.GetComponents\n")
+	assert.Contains(t, tests, "         - tests: StructFinder.FindComponent\n         - cases:\n             - Simple struct\n             - Embedded struct\n")
+	assert.NotContains(t, tests, "newFixture")
+}
@@ -0,0 +1,238 @@
+package reportgen
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	VisibilityAll        = "all"        // VisibilityAll shows every component
+	VisibilityExported   = "exported"   // VisibilityExported shows the public API only
+	VisibilityUnexported = "unexported" // VisibilityUnexported shows everything except the public API
+)
+
+func validateVisibility(visibility string) error {
+	switch visibility {
+	case "", VisibilityAll, VisibilityExported, VisibilityUnexported:
+		return nil
+	default:
+		return fmt.Errorf("unknown visibility %q, expected %q, %q or %q",
+			visibility, VisibilityAll, VisibilityExported, VisibilityUnexported)
+	}
+}
+
+// filterVisibility returns the components of a directory visible with the given visibility,
+// along with the number of hidden components and hidden fields and methods.
+//
+// The public API consists of the exported components and their exported fields and methods,
+// except in internal directories, which can't be imported from outside of the repo.
+// Unexported types are part of the public API too, when they are reachable from it,
+// e.g. returned by an exported func. Only their exported fields and methods are public.
+func filterVisibility(dirPath string, comps []Component, visibility string) ([]Component, int, int) {
+	switch visibility {
+	case VisibilityExported:
+		if isInternalDir(dirPath) {
+			return nil, len(comps), 0
+		}
+
+		return publicComponents(comps)
+	case VisibilityUnexported:
+		if isInternalDir(dirPath) {
+			return comps, 0, 0
+		}
+
+		return nonPublicComponents(comps)
+	default:
+		return comps, 0, 0
+	}
+}
+
+func publicComponents(comps []Component) ([]Component, int, int) {
+	// Types can be referenced by other components, funcs can't
+	types := map[string]int{}
+	for i, comp := range comps {
+		if comp.Type != ComponentTypeFunc {
+			types[componentName(comp)] = i
+		}
+	}
+
+	reachable := make([]bool, len(comps))
+	queue := []int{}
+	for i, comp := range comps {
+		if isExportedComponent(comp) {
+			reachable[i] = true
+			queue = append(queue, i)
+		}
+	}
+
+	// Follow the references from the public API to the unexported types
+	for len(queue) > 0 {
+		comp := comps[queue[0]]
+		queue = queue[1:]
+
+		texts := []string{comp.Name}
+		texts = append(texts, filterMembers(comp.File, comp.Fields, true)...)
+		texts = append(texts, filterMembers(comp.File, comp.Methods, true)...)
+		for _, text := range texts {
+			for _, ident := range referencedIdentifiers(text) {
+				if i, ok := types[ident]; ok && !reachable[i] {
+					reachable[i] = true
+					queue = append(queue, i)
+				}
+			}
+		}
+	}
+
+	public := []Component{}
+	hiddenComps, hiddenMembers := 0, 0
+	for i, comp := range comps {
+		if !reachable[i] {
+			hiddenComps++
+			continue
+		}
+
+		fields, methods := filterMembers(comp.File, comp.Fields, true), filterMembers(comp.File, comp.Methods, true)
+		hiddenMembers += len(comp.Fields) - len(fields) + len(comp.Methods) - len(methods)
+		comp.Fields, comp.Methods = fields, methods
+		public = append(public, comp)
+	}
+
+	return public, hiddenComps, hiddenMembers
+}
+
+func nonPublicComponents(comps []Component) ([]Component, int, int) {
+	nonPublic := []Component{}
+	hiddenComps, hiddenMembers := 0, 0
+	for _, comp := range comps {
+		if !isExportedComponent(comp) {
+			nonPublic = append(nonPublic, comp)
+			continue
+		}
+
+		// Show exported types by their unexported fields and methods only
+		fields, methods := filterMembers(comp.File, comp.Fields, false), filterMembers(comp.File, comp.Methods, false)
+		if comp.Type == ComponentTypeFunc || len(fields)+len(methods) == 0 {
+			hiddenComps++
+			continue
+		}
+
+		hiddenMembers += len(comp.Fields) - len(fields) + len(comp.Methods) - len(methods)
+		comp.Fields, comp.Methods = fields, methods
+		nonPublic = append(nonPublic, comp)
+	}
+
+	return nonPublic, hiddenComps, hiddenMembers
+}
+
+// filterMembers returns the exported or the unexported fields or methods of a component defined in the file.
+func filterMembers(filePath string, members []string, exported bool) []string {
+	if len(members) == 0 {
+		return members
+	}
+
+	filtered := make([]string, 0, len(members))
+	for _, member := range members {
+		if isExportedName(filePath, memberName(member)) == exported {
+			filtered = append(filtered, member)
+		}
+	}
+
+	return filtered
+}
+
+// referencedIdentifiers returns the unqualified identifiers used in a signature or a field definition.
+// Struct tags are ignored.
+func referencedIdentifiers(text string) []string {
+	text = strings.Split(text, "`")[0]
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	idents := make([]string, 0, len(words))
+	for _, word := range words {
+		// Identifiers qualified by a package name belong to other packages
+		if !strings.Contains(word, ".") {
+			idents = append(idents, word)
+		}
+	}
+
+	return idents
+}
+
+// isInternalDir reports whether the directory is, or is inside, an internal directory of the repo.
+// Go only allows the packages rooted at the parent of an internal directory to import it.
+// The directory path starts with the root directory, e.g. "/repo/internal/reportgen",
+// and the name of the root directory itself doesn't matter.
+func isInternalDir(dirPath string) bool {
+	segments := strings.Split(strings.TrimPrefix(filepath.ToSlash(dirPath), "/"), "/")
+	for _, segment := range segments[1:] {
+		if segment == "internal" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsExported reports whether the identifier starts with an upper-case letter,
+// which is how Go decides whether an identifier is visible outside its package.
+func IsExported(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// isExportedComponent reports whether the component is exported, by its visibility if the finder knows it,
+// otherwise by its name.
+func isExportedComponent(comp Component) bool {
+	switch comp.Visibility {
+	case VisibilityExported:
+		return true
+	case VisibilityUnexported:
+		return false
+	default:
+		return isExportedName(comp.File, componentName(comp))
+	}
+}
+
+// isExportedName reports whether the identifier is exported by the rules of the language of the file.
+// Python names are public unless they start with an underscore, but the special names like __init__ are public.
+func isExportedName(filePath string, name string) bool {
+	if filepath.Ext(filePath) == ".py" {
+		return !strings.HasPrefix(name, "_") || (len(name) > 4 && strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+	}
+
+	return IsExported(name)
+}
+
+// componentName returns the bare identifier of a component.
+// Func components store the whole signature as the name, e.g. "Run(rootPath string) error".
+func componentName(comp Component) string {
+	return identifierBefore(comp.Name, "([")
+}
+
+// memberName returns the identifier of a field or method as stored in Component.Fields
+// and Component.Methods, e.g. "Name string `json:\"name\"`", "GetName() string",
+// an embedded "*sync.Mutex" or a Python "name: str".
+func memberName(member string) string {
+	name := identifierBefore(member, "([ \t:")
+	name = strings.TrimLeft(name, "*")
+
+	// Embedded types might be qualified by their package name
+	if idx := strings.LastIndex(name, "."); idx != -1 {
+		name = name[idx+1:]
+	}
+
+	return strings.TrimSuffix(name, ",")
+}
+
+func identifierBefore(s, separators string) string {
+	s = strings.TrimSpace(s)
+	if idx := strings.IndexAny(s, separators); idx != -1 {
+		return strings.TrimSpace(s[:idx])
+	}
+
+	return s
+}
@@ -0,0 +1,181 @@
+package reportgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterVisibility(t *testing.T) {
+	comps := []Component{
+		{
+			Name:    "ReportGenerator",
+			Type:    "struct",
+			Fields:  []string{"Options Options", "rootPath string", "sync.Mutex"},
+			Methods: []string{"GenerateReport(out io.Writer) error", "findCodeStructuresInFiles() error"},
+		},
+		{
+			Name: "NewTraverser(rootPath string) *fileTraverser",
+			Type: "func",
+		},
+		{
+			Name:    "fileTraverser",
+			Type:    "struct",
+			Fields:  []string{"Files []File", "currentFile int"},
+			Methods: []string{"NextFile() (string, bool)"},
+		},
+		{
+			Name: "extractStructName(line string) string",
+			Type: "func",
+		},
+		{
+			Name:   "unreachable",
+			Type:   "struct",
+			Fields: []string{"Name string `json:\"fileTraverser\"`"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		dirPath       string
+		visibility    string
+		expComps      []Component
+		hiddenComps   int
+		hiddenMembers int
+	}{
+		{
+			name:       "All shows every component",
+			dirPath:    "/repo/reportgen",
+			visibility: VisibilityAll,
+			expComps:   comps,
+		},
+		{
+			name:       "Exported keeps reachable unexported types with their exported members",
+			dirPath:    "/repo/reportgen",
+			visibility: VisibilityExported,
+			expComps: []Component{
+				{
+					Name:    "ReportGenerator",
+					Type:    "struct",
+					Fields:  []string{"Options Options", "sync.Mutex"},
+					Methods: []string{"GenerateReport(out io.Writer) error"},
+				},
+				{
+					Name: "NewTraverser(rootPath string) *fileTraverser",
+					Type: "func",
+				},
+				{
+					Name:    "fileTraverser",
+					Type:    "struct",
+					Fields:  []string{"Files []File"},
+					Methods: []string{"NextFile() (string, bool)"},
+				},
+			},
+			hiddenComps:   2,
+			hiddenMembers: 3,
+		},
+		{
+			name:          "Exported hides internal directories",
+			dirPath:       "/repo/internal/reportgen",
+			visibility:    VisibilityExported,
+			expComps:      nil,
+			hiddenComps:   5,
+			hiddenMembers: 0,
+		},
+		{
+			name:       "Unexported keeps unexported components and members",
+			dirPath:    "/repo/reportgen",
+			visibility: VisibilityUnexported,
+			expComps: []Component{
+				{
+					Name:    "ReportGenerator",
+					Type:    "struct",
+					Fields:  []string{"rootPath string"},
+					Methods: []string{"findCodeStructuresInFiles() error"},
+				},
+				comps[2],
+				comps[3],
+				comps[4],
+			},
+			hiddenComps:   1,
+			hiddenMembers: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, hiddenComps, hiddenMembers := filterVisibility(tc.dirPath, comps, tc.visibility)
+
+			assert.Equal(t, tc.expComps, filtered)
+			assert.Equal(t, tc.hiddenComps, hiddenComps)
+			assert.Equal(t, tc.hiddenMembers, hiddenMembers)
+		})
+	}
+}
+
+func TestIsInternalDir(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dirPath  string
+		expected bool
+	}{
+		{name: "Internal directory", dirPath: "/repo/internal", expected: true},
+		{name: "Inside an internal directory", dirPath: "/repo/internal/reportgen", expected: true},
+		{name: "Other directory", dirPath: "/repo/reportgen", expected: false},
+		{name: "Root directory named internal", dirPath: "/internal", expected: false},
+		{name: "Inside a root directory named internal", dirPath: "/internal/reportgen", expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isInternalDir(tc.dirPath))
+		})
+	}
+}
+
+func TestFilterVisibilityPython(t *testing.T) {
+	comps := []Component{
+		{
+			File:       "/home/me/repo/app/user.py",
+			Name:       "User(_Base)",
+			Type:       "class",
+			Fields:     []string{"name: str", "_secret: str"},
+			Methods:    []string{"__init__(name: str)", "save() -> None", "_check()"},
+			Visibility: VisibilityExported,
+		},
+		{
+			File:   "/home/me/repo/app/user.py",
+			Name:   "_Base",
+			Type:   "class",
+			Fields: []string{"id: int"},
+		},
+		{
+			File:       "/home/me/repo/app/user.py",
+			Name:       "helper()",
+			Type:       "func",
+			Visibility: VisibilityUnexported,
+		},
+		{
+			File: "/home/me/repo/app/run.py",
+			Name: "run() -> None",
+			Type: "func",
+		},
+	}
+
+	filtered, hiddenComps, hiddenMembers := filterVisibility("/repo/app", comps, VisibilityExported)
+
+	assert.Equal(t, []Component{
+		{
+			File:       "/home/me/repo/app/user.py",
+			Name:       "User(_Base)",
+			Type:       "class",
+			Fields:     []string{"name: str"},
+			Methods:    []string{"__init__(name: str)", "save() -> None"},
+			Visibility: VisibilityExported,
+		},
+		comps[1],
+		comps[3],
+	}, filtered)
+	assert.Equal(t, 1, hiddenComps)
+	assert.Equal(t, 2, hiddenMembers)
+}